@@ -103,3 +103,43 @@ func CreateApplication(
 
 	return application, nil
 }
+
+func ScaleApplication(orgId string, appSlug string, replicas int) (models.Application, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return models.Application{}, err
+	}
+
+	payload, err := json.Marshal(map[string]int{"replicas": replicas})
+	if err != nil {
+		return models.Application{}, err
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/scale"
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return models.Application{}, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.Application{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return models.Application{}, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var application models.Application
+	if err := json.NewDecoder(resp.Body).Decode(&application); err != nil {
+		return models.Application{}, err
+	}
+
+	return application, nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrCronJobRunning is returned when running a cron job whose previous run hasn't finished yet.
+var ErrCronJobRunning = errors.New("the cron job is already running")
+
+func RetrieveCronJobs(orgId string, appSlug string) ([]models.CronJob, error) {
+	var jobs []models.CronJob
+	err := sendCronJobsRequest("GET", cronJobsUrl(orgId, appSlug), nil, &jobs)
+	return jobs, err
+}
+
+// FindCronJob returns the cron job of an application with the given name.
+func FindCronJob(orgId string, appSlug string, name string) (models.CronJob, error) {
+	jobs, err := RetrieveCronJobs(orgId, appSlug)
+	if err != nil {
+		return models.CronJob{}, err
+	}
+
+	for _, job := range jobs {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+
+	return models.CronJob{}, fmt.Errorf("no cron job named %q", name)
+}
+
+func CreateCronJob(orgId string, appSlug string, name string, schedule string, command string, timeout int) (models.CronJob, error) {
+	payload := map[string]any{
+		"name":     name,
+		"schedule": schedule,
+		"command":  command,
+		"timeout":  timeout,
+	}
+
+	var job models.CronJob
+	err := sendCronJobsRequest("POST", cronJobsUrl(orgId, appSlug), payload, &job)
+	return job, err
+}
+
+func DeleteCronJob(orgId string, appSlug string, jobId string) error {
+	return sendCronJobsRequest("DELETE", cronJobsUrl(orgId, appSlug)+"/"+jobId, nil, nil)
+}
+
+func RunCronJob(orgId string, appSlug string, jobId string) (models.CronJobRun, error) {
+	var run models.CronJobRun
+	err := sendCronJobsRequest("POST", cronJobsUrl(orgId, appSlug)+"/"+jobId+"/run", nil, &run)
+	return run, err
+}
+
+// RetrieveCronJobRuns returns the most recent runs of a cron job, from the most recent.
+func RetrieveCronJobRuns(orgId string, appSlug string, jobId string, limit int) ([]models.CronJobRun, error) {
+	var runs []models.CronJobRun
+	err := sendCronJobsRequest("GET", cronJobsUrl(orgId, appSlug)+"/"+jobId+"/runs?limit="+strconv.Itoa(limit), nil, &runs)
+	return runs, err
+}
+
+func cronJobsUrl(orgId string, appSlug string) string {
+	return RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/cron_jobs"
+}
+
+// sendCronJobsRequest sends a request to the cron jobs API, with the given JSON payload if any,
+// and decodes the JSON response into out if any.
+func sendCronJobsRequest(method string, url string, payload any, out any) error {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return ErrCronJobRunning
+	case resp.StatusCode == http.StatusBadRequest:
+		// The validation errors are keyed by field.
+		var validationErrors map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&validationErrors); err != nil || len(validationErrors) == 0 {
+			return fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+		}
+		messages := []string{}
+		for field, message := range validationErrors {
+			messages = append(messages, field+": "+message)
+		}
+		sort.Strings(messages)
+		return errors.New(strings.Join(messages, "\n"))
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
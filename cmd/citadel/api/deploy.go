@@ -3,19 +3,21 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 
 	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
 )
 
-func DeployFromTarball(tarball io.ReadCloser, orgId string, appSlug string, releaseCmd string) (bool, error) {
+func DeployFromTarball(tarball io.ReadCloser, orgId string, appSlug string, releaseCmd string) (string, bool, error) {
 	// Retrieve the token from the config file
 	token, err := util.RetrieveTokenFromConfig()
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
 	// Create a new HTTP request
@@ -26,25 +28,24 @@ func DeployFromTarball(tarball io.ReadCloser, orgId string, appSlug string, rele
 	writer := multipart.NewWriter(form)
 	part, err := writer.CreateFormFile("tarball", "tarball")
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 	_, err = io.Copy(part, tarball)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
-	err = writer.Close()
+	err = writer.WriteField("releaseCommand", releaseCmd)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
-
-	err = writer.WriteField("releaseCommand", releaseCmd)
+	err = writer.Close()
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
 	req, err := http.NewRequest("POST", url, form)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 	req.Header.Add("Content-Type", "multipart/form-data; boundary="+writer.Boundary())
 	req.Header.Add("Accept", "application/json")
@@ -54,24 +55,32 @@ func DeployFromTarball(tarball io.ReadCloser, orgId string, appSlug string, rele
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == 400 {
+		// An invalid citadel.toml or Procfile is reported along with the reason.
+		var validationErrors map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&validationErrors); err == nil && validationErrors["Processes"] != "" {
+			return "", false, errors.New(validationErrors["Processes"])
+		}
+	}
 	if resp.StatusCode != 200 {
-		return false, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+		return "", false, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
 	}
 
 	var response struct {
-		Healthcheck bool `json:"healthcheck"`
+		DeploymentId string `json:"deployment_id"`
+		Healthcheck  bool   `json:"healthcheck"`
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
-	return response.Healthcheck, nil
+	return response.DeploymentId, response.Healthcheck, nil
 }
 
 func RedeployApplication(
@@ -108,3 +117,103 @@ func RedeployApplication(
 
 	return nil
 }
+
+func RetrieveDeployments(orgId string, appSlug string) ([]models.Deployment, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/deployments/list"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var depls []models.Deployment
+	if err := json.NewDecoder(resp.Body).Decode(&depls); err != nil {
+		return nil, err
+	}
+
+	return depls, nil
+}
+
+func RollbackDeployment(orgId string, appSlug string, deploymentId string) (models.Deployment, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return models.Deployment{}, err
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/deployments/" + deploymentId + "/rollback"
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return models.Deployment{}, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.Deployment{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return models.Deployment{}, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var depl models.Deployment
+	if err := json.NewDecoder(resp.Body).Decode(&depl); err != nil {
+		return models.Deployment{}, err
+	}
+
+	return depl, nil
+}
+
+func CancelDeployment(orgId string, appSlug string, deploymentId string) error {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return err
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/deployments/" + deploymentId + "/cancel"
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 409 {
+		return errors.New("The deployment is already over, and can no longer be canceled.")
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -3,25 +3,35 @@ package api
 import (
 	"bytes"
 	"citadel/cmd/citadel/util"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/websocket"
 )
 
-func ExecuteCommand(orgId, appSlug, command string) error {
+func ExecuteCommand(orgId, appSlug, command string) (int, error) {
 	token, err := util.RetrieveTokenFromConfig()
 	if err != nil {
-		return nil
+		return 1, err
 	}
 
 	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/exec"
 
-	payload := []byte(`{"command": "` + command + `"}`)
-	body := bytes.NewBuffer(payload)
+	payload, err := json.Marshal(map[string]string{"command": command})
+	if err != nil {
+		return 1, err
+	}
 
-	req, err := http.NewRequest("POST", url, body)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
 	if err != nil {
-		return nil
+		return 1, err
 	}
 
 	req.Header.Add("Authorization", "Bearer "+token)
@@ -31,27 +41,59 @@ func ExecuteCommand(orgId, appSlug, command string) error {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil
+		return 1, err
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 404 {
-		fmt.Println("No running deployment found.")
-		return nil
+		return 1, errors.New("No running deployment found.")
 	}
 
 	if resp.StatusCode != 200 {
-		fmt.Println("An error occurred while executing the command.")
-		return nil
+		return 1, errors.New("An error occurred while executing the command.")
 	}
 
 	// Print the output of the command to the console (response body)
-	responseData, err := io.ReadAll(resp.Body)
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		return 1, err
+	}
+
+	exitCode, err := strconv.Atoi(resp.Header.Get("X-Exit-Code"))
+	if err != nil {
+		return 0, nil
+	}
+
+	return exitCode, nil
+}
+
+// OpenConsole opens a WebSocket connection running a command in the application (a shell if empty).
+func OpenConsole(orgId, appSlug, command string, tty bool, cols, rows int) (*websocket.Conn, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	baseUrl := RetrieveApiBaseUrl()
+	wsBaseUrl := strings.Replace(baseUrl, "http", "ws", 1)
+
+	query := url.Values{}
+	query.Set("command", command)
+	query.Set("tty", strconv.FormatBool(tty))
+	query.Set("cols", strconv.Itoa(cols))
+	query.Set("rows", strconv.Itoa(rows))
+
+	config, err := websocket.NewConfig(wsBaseUrl+"/orgs/"+orgId+"/apps/"+appSlug+"/exec/ws?"+query.Encode(), baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	config.Header.Add("Authorization", "Bearer "+token)
+	config.Header.Add("Accept", "application/json")
+
+	ws, err := websocket.DialConfig(config)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to open console: %w", err)
 	}
-	fmt.Print(string(responseData))
 
-	return nil
+	return ws, nil
 }
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type LogsQuery struct {
+	Text       string
+	Since      string
+	Until      string
+	Deployment string
+	Source     string
+	Process    string
+	Stream     string
+	Level      string
+	Limit      int
+}
+
+func SearchLogs(orgId string, appSlug string, query LogsQuery) ([]models.LogEntry, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("q", query.Text)
+	params.Set("since", query.Since)
+	params.Set("until", query.Until)
+	params.Set("deployment", query.Deployment)
+	params.Set("source", query.Source)
+	params.Set("process", query.Process)
+	params.Set("stream", query.Stream)
+	params.Set("level", query.Level)
+	if query.Limit > 0 {
+		params.Set("limit", fmt.Sprint(query.Limit))
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/logs/search?" + params.Encode()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 400 {
+		var errors map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&errors); err == nil && errors["Text"] != "" {
+			return nil, fmt.Errorf("invalid query, %s", errors["Text"])
+		}
+		return nil, fmt.Errorf("invalid query, times must be durations (e.g. 15m, 7d) or RFC 3339 times")
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var entries []models.LogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type MetricsQuery struct {
+	Since   string
+	Until   string
+	Process string
+
+	// Database is the slug of a database of the organization, whose metrics are retrieved instead of the application's.
+	Database string
+}
+
+func RetrieveMetrics(orgId string, appSlug string, query MetricsQuery) ([]models.MetricSample, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("since", query.Since)
+	params.Set("until", query.Until)
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/metrics"
+	if query.Database != "" {
+		url = RetrieveApiBaseUrl() + "/orgs/" + orgId + "/databases/" + query.Database + "/metrics"
+	} else {
+		params.Set("process", query.Process)
+	}
+	req, err := http.NewRequest("GET", url+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 400 {
+		return nil, fmt.Errorf("invalid period, times must be durations (e.g. 15m, 7d) or RFC 3339 times, and end after they start")
+	}
+	if resp.StatusCode == 404 && query.Database != "" {
+		return nil, fmt.Errorf("database %s not found", query.Database)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var samples []models.MetricSample
+	if err := json.NewDecoder(resp.Body).Decode(&samples); err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RetrieveProcessTypes returns the process types of an application, starting with the web one.
+func RetrieveProcessTypes(orgId string, appSlug string) ([]models.ProcessType, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/processes"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var processes []models.ProcessType
+	if err := json.NewDecoder(resp.Body).Decode(&processes); err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// ScaleProcessType sets the number of instances of a process type other than the web one.
+// Its computing specs are set too, or reset to the ones of the application when empty.
+func ScaleProcessType(orgId string, appSlug string, name string, replicas int, cpuConfig string, ramConfig string) (models.ProcessType, error) {
+	token, err := util.RetrieveTokenFromConfig()
+	if err != nil {
+		return models.ProcessType{}, err
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"replicas":   replicas,
+		"cpu_config": cpuConfig,
+		"ram_config": ramConfig,
+	})
+	if err != nil {
+		return models.ProcessType{}, err
+	}
+
+	url := RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/processes/" + name + "/scale"
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return models.ProcessType{}, err
+	}
+
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.ProcessType{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return models.ProcessType{}, fmt.Errorf("no process type named %q, the ones declared by the current deployment are listed by `citadel ps`", name)
+	case resp.StatusCode == http.StatusBadRequest:
+		// The validation errors are keyed by field.
+		var validationErrors map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&validationErrors); err != nil || len(validationErrors) == 0 {
+			return models.ProcessType{}, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+		}
+		messages := []string{}
+		for field, message := range validationErrors {
+			messages = append(messages, field+": "+message)
+		}
+		sort.Strings(messages)
+		return models.ProcessType{}, errors.New(strings.Join(messages, "\n"))
+	case resp.StatusCode != http.StatusOK:
+		return models.ProcessType{}, fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+	}
+
+	var process models.ProcessType
+	if err := json.NewDecoder(resp.Body).Decode(&process); err != nil {
+		return models.ProcessType{}, err
+	}
+
+	return process, nil
+}
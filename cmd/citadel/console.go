@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+	execProtocol "citadel/internal/exec_protocol"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/websocket"
+)
+
+var consoleCmd = &cobra.Command{
+	Use:   "console [command]",
+	Run:   runConsole,
+	Short: "Open an interactive console on the running container",
+	Long:  "Open an interactive console on the running container. Defaults to a shell, e.g. `citadel console rails console` opens a Rails console.",
+}
+
+func init() {
+	rootCmd.AddCommand(consoleCmd)
+}
+
+func runConsole(cmd *cobra.Command, args []string) {
+	if !auth.IsLoggedIn() {
+		fmt.Println("You are not logged in to Software Citadel.")
+		os.Exit(1)
+	}
+
+	orgId, appSlug, err := util.RetrieveOrgIdAppSlugFromConfig()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if exitCode := openConsole(orgId, appSlug, strings.Join(args, " ")); exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// openConsole runs a command in the application, attached to the terminal, and returns its exit code.
+func openConsole(orgId, appSlug, command string) int {
+	// Input piped to the console (e.g. a script) doesn't get a terminal.
+	stdinFd := os.Stdin.Fd()
+	tty := term.IsTerminal(stdinFd)
+
+	cols, rows := 0, 0
+	if tty {
+		cols, rows, _ = term.GetSize(os.Stdout.Fd())
+	}
+
+	ws, err := api.OpenConsole(orgId, appSlug, command, tty, cols, rows)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer ws.Close()
+
+	if tty {
+		state, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		defer term.Restore(stdinFd, state)
+
+		stopWatching := watchTerminalResize(func() {
+			cols, rows, err := term.GetSize(os.Stdout.Fd())
+			if err != nil {
+				return
+			}
+			execProtocol.SendControl(ws, execProtocol.ControlMessage{
+				Type: execProtocol.ControlMessageResize,
+				Cols: uint(cols),
+				Rows: uint(rows),
+			})
+		})
+		defer stopWatching()
+	}
+
+	go forwardStdin(ws)
+
+	exitCode, err := receiveOutput(ws)
+	if err != nil {
+		// In raw mode, new lines don't move the cursor back to the start of the line.
+		fmt.Fprint(os.Stderr, "\r\n"+err.Error()+"\r\n")
+	}
+
+	return exitCode
+}
+
+// forwardStdin sends the standard input to the command, and reports when it is exhausted.
+func forwardStdin(ws *websocket.Conn) {
+	if _, err := io.Copy(execProtocol.NewWriter(ws), os.Stdin); err != nil {
+		return
+	}
+	execProtocol.SendControl(ws, execProtocol.ControlMessage{Type: execProtocol.ControlMessageStdinClosed})
+}
+
+// receiveOutput prints the output of the command until it exits, and returns its exit code.
+func receiveOutput(ws *websocket.Conn) (int, error) {
+	for {
+		frame, err := execProtocol.Receive(ws)
+		if err != nil {
+			return 1, fmt.Errorf("connection lost: %w", err)
+		}
+
+		if frame.Control == nil {
+			os.Stdout.Write(frame.Data)
+			continue
+		}
+
+		if frame.Control.Type == execProtocol.ControlMessageExit {
+			if frame.Control.Error != "" {
+				return 1, fmt.Errorf("%s", frame.Control.Error)
+			}
+			return frame.Control.ExitCode, nil
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+// watchTerminalResize does nothing, as Windows has no signal for terminal resizes.
+func watchTerminalResize(onResize func()) func() {
+	return func() {}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+var cronCmd = &cobra.Command{
+	Use:   "cron",
+	Short: "Manage the cron jobs of your application",
+	Long:  "Manage the commands run periodically in one-off instances of your application, from the image of its current deployment.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if !auth.IsLoggedIn() {
+			fmt.Println("You must be logged in to manage cron jobs.")
+			fmt.Println("Please run `citadel auth login` to log in.")
+			os.Exit(1)
+		}
+
+		if !util.IsAlreadyInitialized() {
+			fmt.Println("Software Citadel is not initialized. Please run `citadel init` to initialize it.")
+			os.Exit(1)
+		}
+	},
+}
+
+var cronListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cron jobs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		orgId, appSlug := retrieveOrgIdAppSlug()
+
+		jobs, err := api.RetrieveCronJobs(orgId, appSlug)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if len(jobs) == 0 {
+			fmt.Println("No cron jobs yet. Add one with `citadel cron add`.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSCHEDULE\tCOMMAND\tTIMEOUT")
+		for _, job := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.Name, job.Schedule, job.Command, job.GetTimeout())
+		}
+		w.Flush()
+	},
+}
+
+var cronAddCmd = &cobra.Command{
+	Use:     "add <name> <schedule> <command>",
+	Short:   "Add a cron job",
+	Long:    "Add a cron job. The schedule is a standard cron expression, or a descriptor like @hourly, in UTC.",
+	Example: `citadel cron add cleanup "0 3 * * *" "npm run cleanup" --timeout 600`,
+	Args:    cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		orgId, appSlug := retrieveOrgIdAppSlug()
+
+		timeout, _ := cmd.Flags().GetInt("timeout")
+		if _, err := models.ParseCronSchedule(args[1]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		job, err := api.CreateCronJob(orgId, appSlug, args[0], args[1], args[2], timeout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		next, _ := job.NextRun(time.Now())
+		fmt.Printf("⏰ Cron job %s added. It runs next at %s UTC.\n", job.Name, next.Format("2006-01-02 15:04"))
+	},
+}
+
+var cronRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a cron job, along with its run history",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		orgId, appSlug := retrieveOrgIdAppSlug()
+		job := findCronJob(orgId, appSlug, args[0])
+
+		if err := api.DeleteCronJob(orgId, appSlug, job.ID); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Cron job %s removed.\n", job.Name)
+	},
+}
+
+var cronRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a cron job now, out of its schedule",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		orgId, appSlug := retrieveOrgIdAppSlug()
+		job := findCronJob(orgId, appSlug, args[0])
+
+		run, err := api.RunCronJob(orgId, appSlug, job.ID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("▶️  Cron job %s started (run %s). Type `citadel cron runs %s` to see how it goes.\n", job.Name, run.ID, job.Name)
+	},
+}
+
+var cronRunsCmd = &cobra.Command{
+	Use:   "runs <name>",
+	Short: "Show the latest runs of a cron job",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		orgId, appSlug := retrieveOrgIdAppSlug()
+		job := findCronJob(orgId, appSlug, args[0])
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		runs, err := api.RetrieveCronJobRuns(orgId, appSlug, job.ID, limit)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if len(runs) == 0 {
+			fmt.Printf("Cron job %s hasn't run yet.\n", job.Name)
+			return
+		}
+
+		showOutput, _ := cmd.Flags().GetBool("output")
+		if showOutput {
+			// The output of the latest run only, which is the one looked for most of the time.
+			fmt.Print(runs[0].Output)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "RUN\tSTATUS\tSTARTED\tDURATION\tEXIT CODE")
+		for _, run := range runs {
+			exitCode := ""
+			if run.Status != models.CronJobRunStatusRunning {
+				exitCode = strconv.Itoa(run.ExitCode)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", run.ID, run.Status, run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.Duration(), exitCode)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	cronAddCmd.Flags().Int("timeout", models.DefaultCronJobTimeout, "How long, in seconds, a run may last before it is killed")
+	cronRunsCmd.Flags().Int("limit", 10, "Number of runs to show")
+	cronRunsCmd.Flags().Bool("output", false, "Show the output of the latest run")
+
+	cronCmd.AddCommand(cronListCmd)
+	cronCmd.AddCommand(cronAddCmd)
+	cronCmd.AddCommand(cronRemoveCmd)
+	cronCmd.AddCommand(cronRunCmd)
+	cronCmd.AddCommand(cronRunsCmd)
+	rootCmd.AddCommand(cronCmd)
+}
+
+func retrieveOrgIdAppSlug() (string, string) {
+	orgId, appSlug, err := util.RetrieveOrgIdAppSlugFromConfig()
+	if err != nil {
+		fmt.Println("Failed to retrieve application id")
+		os.Exit(1)
+	}
+	return orgId, appSlug
+}
+
+func findCronJob(orgId string, appSlug string, name string) models.CronJob {
+	job, err := api.FindCronJob(orgId, appSlug, name)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return job
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
 
 	"citadel/cmd/citadel/api"
 	"citadel/cmd/citadel/auth"
@@ -54,15 +55,33 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	shouldMonitorHealtcheck, err := api.DeployFromTarball(tarball, orgId, appSlug, releaseCmd)
+	deploymentId, shouldMonitorHealtcheck, err := api.DeployFromTarball(tarball, orgId, appSlug, releaseCmd)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	tui.StreamBuildLogs(orgId, appSlug)
+	// From now on, pressing Ctrl-C cancels the deployment rather than leaving it unattended.
+	cancelDeployment := func() {
+		fmt.Println("Canceling deployment...")
+		if err := api.CancelDeployment(orgId, appSlug, deploymentId); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("🛑 Deployment canceled.")
+		os.Exit(130)
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		cancelDeployment()
+	}()
+
+	tui.StreamBuildLogs(orgId, appSlug, deploymentId, cancelDeployment)
 
 	if shouldMonitorHealtcheck {
-		tui.MonitorHealtcheck(orgId, appSlug)
+		tui.MonitorHealtcheck(orgId, appSlug, deploymentId, cancelDeployment)
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"citadel/cmd/citadel/util"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -14,6 +15,7 @@ var execCmd = &cobra.Command{
 	Use:   "exec [command]",
 	Run:   runExec,
 	Short: "Execute a command on the running container",
+	Long:  "Execute a command on the running container, and print its output once it exits. For interactive commands, use `citadel console`.",
 }
 
 func runExec(cmd *cobra.Command, args []string) {
@@ -28,16 +30,18 @@ func runExec(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	if len(args) != 1 {
+	if len(args) == 0 {
 		fmt.Println("Please provide a command to execute.")
 		os.Exit(1)
 	}
 
-	command := args[0]
+	command := strings.Join(args, " ")
 
-	err = api.ExecuteCommand(orgId, appSlug, command)
+	exitCode, err := api.ExecuteCommand(orgId, appSlug, command)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+
+	os.Exit(exitCode)
 }
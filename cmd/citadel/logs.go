@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Run:   runLogs,
+	Short: "Search the logs of your application",
+	Long:  "Search the logs of your application, across its past deployments and builds, as far back as the log retention of your organization.",
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	logsCmd.Flags().StringP("search", "s", "", "Only show the lines containing this text (case-insensitive), over a period of 24 hours at most")
+	logsCmd.Flags().String("since", "1h", "Show the logs since this duration ago (e.g. 15m, 7d) or RFC 3339 time")
+	logsCmd.Flags().String("until", "", "Show the logs until this duration ago (e.g. 15m, 7d) or RFC 3339 time")
+	logsCmd.Flags().StringP("deployment", "d", "", "Only show the logs of this deployment")
+	logsCmd.Flags().String("source", "", "Only show the logs of this source (app, builder or release)")
+	logsCmd.Flags().StringP("process", "p", "", "Only show the logs of this process type (e.g. web or worker)")
+	logsCmd.Flags().String("stream", "", "Only show the lines of this stream (stdout or stderr)")
+	logsCmd.Flags().StringP("level", "l", "", "Only show the structured (JSON) lines of this level or above (debug, info, warn, error or fatal)")
+	logsCmd.Flags().IntP("limit", "n", 0, "Maximum number of lines to show (at most 1000)")
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogs(cmd *cobra.Command, args []string) {
+	if !auth.IsLoggedIn() {
+		fmt.Println("You must be logged in to view logs.")
+		fmt.Println("Please run `citadel auth login` to log in.")
+		return
+	}
+
+	if !util.IsAlreadyInitialized() {
+		fmt.Println("Software Citadel is not initialized. Please run `citadel init` to initialize it.")
+		return
+	}
+
+	orgId, appSlug, err := util.RetrieveOrgIdAppSlugFromConfig()
+	if err != nil {
+		fmt.Println("Failed to retrieve application id")
+		os.Exit(1)
+	}
+
+	query := api.LogsQuery{}
+	query.Text, _ = cmd.Flags().GetString("search")
+	query.Since, _ = cmd.Flags().GetString("since")
+	query.Until, _ = cmd.Flags().GetString("until")
+	query.Deployment, _ = cmd.Flags().GetString("deployment")
+	query.Source, _ = cmd.Flags().GetString("source")
+	query.Process, _ = cmd.Flags().GetString("process")
+	query.Stream, _ = cmd.Flags().GetString("stream")
+	query.Limit, _ = cmd.Flags().GetInt("limit")
+
+	query.Level, _ = cmd.Flags().GetString("level")
+	if query.Level != "" && models.LogLevel(query.Level).Severity() < 0 {
+		fmt.Println("The level must be one of debug, info, warn, error or fatal.")
+		os.Exit(1)
+	}
+
+	entries, err := api.SearchLogs(orgId, appSlug, query)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	for _, entry := range entries {
+		event := entry.Event()
+
+		source := string(entry.Source)
+		if entry.Process != "" {
+			source += "/" + entry.Process
+		}
+
+		out := os.Stdout
+		if event.Stream == models.LogEntryStreamStderr {
+			out = os.Stderr
+		}
+		fmt.Fprintf(out, "%s %s %s\n",
+			logTimestampStyle.Render(event.Timestamp.Local().Format(time.DateTime)),
+			logTimestampStyle.Render("["+source+"]"),
+			logMessageStyle(event).Render(event.Message),
+		)
+	}
+}
+
+var logTimestampStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+
+// logMessageStyle colours the lines by level, and the unstructured ones written to stderr as errors.
+func logMessageStyle(event models.LogEvent) lipgloss.Style {
+	style := lipgloss.NewStyle()
+
+	switch event.Level {
+	case models.LogLevelDebug:
+		return style.Faint(true)
+	case models.LogLevelWarn:
+		return style.Foreground(lipgloss.Color("3"))
+	case models.LogLevelError, models.LogLevelFatal:
+		return style.Foreground(lipgloss.Color("1"))
+	case "":
+		if event.Stream == models.LogEntryStreamStderr {
+			return style.Foreground(lipgloss.Color("1"))
+		}
+	}
+
+	return style
+}
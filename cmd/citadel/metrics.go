@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+
+	"github.com/spf13/cobra"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Run:   runMetrics,
+	Short: "Show the resource usage of your application",
+	Long:  "Show the CPU, memory and network usage of your application, or of one of your databases, along with the restarts of its instances, over a period of time.",
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	metricsCmd.Flags().String("since", "1h", "Show the usage since this duration ago (e.g. 15m, 7d) or RFC 3339 time")
+	metricsCmd.Flags().String("until", "", "Show the usage until this duration ago (e.g. 15m, 7d) or RFC 3339 time")
+	metricsCmd.Flags().StringP("process", "p", "", "Only show the usage of this process type (e.g. web or worker)")
+	metricsCmd.Flags().String("database", "", "Show the usage of this database (by slug) instead")
+	rootCmd.AddCommand(metricsCmd)
+}
+
+func runMetrics(cmd *cobra.Command, args []string) {
+	if !auth.IsLoggedIn() {
+		fmt.Println("You must be logged in to view metrics.")
+		fmt.Println("Please run `citadel auth login` to log in.")
+		return
+	}
+
+	if !util.IsAlreadyInitialized() {
+		fmt.Println("Software Citadel is not initialized. Please run `citadel init` to initialize it.")
+		return
+	}
+
+	orgId, appSlug := retrieveOrgIdAppSlug()
+
+	query := api.MetricsQuery{}
+	query.Since, _ = cmd.Flags().GetString("since")
+	query.Until, _ = cmd.Flags().GetString("until")
+	query.Process, _ = cmd.Flags().GetString("process")
+	query.Database, _ = cmd.Flags().GetString("database")
+
+	samples, err := api.RetrieveMetrics(orgId, appSlug, query)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if len(samples) == 0 {
+		fmt.Println("No metrics were collected over this period.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TIME\tINSTANCES\tCPU\tMEMORY\tNET IN\tNET OUT\tRESTARTS")
+	for _, sample := range samples {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%s / %s\t%s\t%s\t%d\n",
+			sample.Timestamp.Local().Format(time.DateTime),
+			sample.Instances,
+			sample.CPUPercent,
+			formatBytes(sample.MemoryUsage),
+			formatBytes(sample.MemoryLimit),
+			formatBytes(sample.NetworkRx),
+			formatBytes(sample.NetworkTx),
+			sample.Restarts,
+		)
+	}
+	w.Flush()
+}
+
+// formatBytes formats a number of bytes into a human-readable string.
+func formatBytes(size int64) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%dB", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1fKB", float64(size)/1024)
+	case size < 1024*1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(size)/(1024*1024))
+	default:
+		return fmt.Sprintf("%.1fGB", float64(size)/(1024*1024*1024))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+
+	"github.com/spf13/cobra"
+)
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Run:   runPs,
+	Short: "List the process types of your application",
+	Long:  "List the process types of your application, as declared in its citadel.toml or Procfile, along with their number of instances.",
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+}
+
+func runPs(cmd *cobra.Command, args []string) {
+	if !auth.IsLoggedIn() {
+		fmt.Println("You must be logged in to list process types.")
+		fmt.Println("Please run `citadel auth login` to log in.")
+		return
+	}
+
+	if !util.IsAlreadyInitialized() {
+		fmt.Println("Software Citadel is not initialized. Please run `citadel init` to initialize it.")
+		return
+	}
+
+	orgId, appSlug := retrieveOrgIdAppSlug()
+
+	processes, err := api.RetrieveProcessTypes(orgId, appSlug)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PROCESS\tINSTANCES\tCPU\tRAM\tCOMMAND")
+	for _, process := range processes {
+		cpuConfig, ramConfig := process.CpuConfig, process.RamConfig
+		if cpuConfig == "" || ramConfig == "" {
+			cpuConfig, ramConfig = "(app)", "(app)"
+		}
+		command := process.Command
+		if command == "" {
+			command = "(image default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", process.Name, strconv.Itoa(process.GetReplicas()), cpuConfig, ramConfig, command)
+	}
+	w.Flush()
+}
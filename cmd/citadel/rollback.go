@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [deployment-id]",
+	Run:   runRollback,
+	Short: "Roll back to a previous deployment",
+	Long:  "Roll back to a previous deployment, without rebuilding it. Defaults to the deployment preceding the current one.",
+	Args:  cobra.MaximumNArgs(1),
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func runRollback(cmd *cobra.Command, args []string) {
+	if !auth.IsLoggedIn() {
+		fmt.Println("You must be logged in to roll back a deployment.")
+		fmt.Println("Please run `citadel auth login` to log in.")
+		return
+	}
+
+	if !util.IsAlreadyInitialized() {
+		fmt.Println("Software Citadel is not initialized. Please run `citadel init` to initialize it.")
+		return
+	}
+
+	orgId, appSlug, err := util.RetrieveOrgIdAppSlugFromConfig()
+	if err != nil {
+		fmt.Println("Failed to retrieve application id")
+		os.Exit(1)
+	}
+
+	var deploymentId string
+	if len(args) == 1 {
+		deploymentId = args[0]
+	} else {
+		depls, err := api.RetrieveDeployments(orgId, appSlug)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		deploymentId = findPreviousDeploymentId(depls)
+		if deploymentId == "" {
+			fmt.Println("No previous deployment to roll back to.")
+			os.Exit(1)
+		}
+	}
+
+	depl, err := api.RollbackDeployment(orgId, appSlug, deploymentId)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println("⏪ Rolled back to deployment " + deploymentId + " (new deployment: " + depl.ID + ").")
+}
+
+// findPreviousDeploymentId returns the ID of the latest deployment that can be rolled back to,
+// skipping the one currently running. Deployments are expected to be sorted from the most recent.
+func findPreviousDeploymentId(depls []models.Deployment) string {
+	currentSkipped := false
+	for _, depl := range depls {
+		if !depl.CanBeRolledBackTo() {
+			continue
+		}
+		if !currentSkipped {
+			currentSkipped = true
+			continue
+		}
+		return depl.ID
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"citadel/cmd/citadel/api"
+	"citadel/cmd/citadel/auth"
+	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale <instances>",
+	Run:   runScale,
+	Short: "Scale your application",
+	Long:  "Set the number of instances your application runs. The traffic is load-balanced across them.\nThe other process types of your application are scaled with --process, down to zero instances.",
+	Example: `citadel scale 3
+citadel scale 2 --process worker --cpu shared-cpu-2x --ram 1GB`,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	scaleCmd.Flags().StringP("process", "p", models.ProcessTypeWeb, "Process type to scale")
+	scaleCmd.Flags().String("cpu", "", "CPU configuration of the process type (e.g. shared-cpu-2x), the one of the application if empty")
+	scaleCmd.Flags().String("ram", "", "RAM configuration of the process type (e.g. 1GB), the one of the application if empty")
+	rootCmd.AddCommand(scaleCmd)
+}
+
+func runScale(cmd *cobra.Command, args []string) {
+	if !auth.IsLoggedIn() {
+		fmt.Println("You must be logged in to scale an application.")
+		fmt.Println("Please run `citadel auth login` to log in.")
+		return
+	}
+
+	if !util.IsAlreadyInitialized() {
+		fmt.Println("Software Citadel is not initialized. Please run `citadel init` to initialize it.")
+		return
+	}
+
+	process, _ := cmd.Flags().GetString("process")
+	cpuConfig, _ := cmd.Flags().GetString("cpu")
+	ramConfig, _ := cmd.Flags().GetString("ram")
+
+	// The web process type is the application itself, which always runs at least one instance.
+	minReplicas := 0
+	if process == models.ProcessTypeWeb {
+		minReplicas = 1
+	}
+
+	replicas, err := strconv.Atoi(args[0])
+	if err != nil || replicas < minReplicas || replicas > models.MaxReplicas {
+		fmt.Printf("The number of instances must be between %d and %d.\n", minReplicas, models.MaxReplicas)
+		os.Exit(1)
+	}
+
+	orgId, appSlug, err := util.RetrieveOrgIdAppSlugFromConfig()
+	if err != nil {
+		fmt.Println("Failed to retrieve application id")
+		os.Exit(1)
+	}
+
+	if process != models.ProcessTypeWeb {
+		if cpuConfig != "" || ramConfig != "" {
+			if _, err := models.ParseComputingSpecs(cpuConfig, ramConfig); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+
+		processType, err := api.ScaleProcessType(orgId, appSlug, process, replicas, cpuConfig, ramConfig)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("📈 %s now runs %d instance(s).\n", processType.Name, processType.GetReplicas())
+		return
+	}
+	if cpuConfig != "" || ramConfig != "" {
+		fmt.Println("The computing specs of the web process type are the ones of the application, set in its settings.")
+		os.Exit(1)
+	}
+
+	app, err := api.ScaleApplication(orgId, appSlug, replicas)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📈 %s now runs %d instance(s).\n", app.Name, app.GetReplicas())
+}
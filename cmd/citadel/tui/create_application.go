@@ -3,6 +3,7 @@ package tui
 import (
 	"citadel/cmd/citadel/api"
 	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
 	"fmt"
 	"os"
 	"strings"
@@ -45,124 +46,16 @@ func CreateApplication(orgId string) string {
 	return application.Slug
 }
 
+// newChooseComputingSpecs offers every combination of CPU and RAM configurations available.
 func newChooseComputingSpecs() SelectModel {
-	choices := []SelectChoice{
-		{
-			Name: "shared-cpu-1x 256MB",
-			ID:   "shared-cpu-1x-256MB",
-		},
-		{
-			Name: "shared-cpu-1x 512MB",
-			ID:   "shared-cpu-1x-512MB",
-		},
-		{
-			Name: "shared-cpu-1x 1GB",
-			ID:   "shared-cpu-1x-1GB",
-		},
-		{
-			Name: "shared-cpu-1x 2GB",
-			ID:   "shared-cpu-1x-2GB",
-		},
-		{
-			Name: "shared-cpu-2x 512MB",
-			ID:   "shared-cpu-2x-512MB",
-		},
-		{
-			Name: "shared-cpu-2x 1GB",
-			ID:   "shared-cpu-2x-1GB",
-		},
-		{
-			Name: "shared-cpu-2x 2GB",
-			ID:   "shared-cpu-2x-2GB",
-		},
-		{
-			Name: "shared-cpu-2x 4GB",
-			ID:   "shared-cpu-2x-4GB",
-		},
-		{
-			Name: "shared-cpu-4x 1GB",
-			ID:   "shared-cpu-4x-1GB",
-		},
-		{
-			Name: "shared-cpu-4x 2GB",
-			ID:   "shared-cpu-4x-2GB",
-		},
-		{
-			Name: "shared-cpu-4x 4GB",
-			ID:   "shared-cpu-4x-4GB",
-		},
-		{
-			Name: "shared-cpu-4x 8GB",
-			ID:   "shared-cpu-4x-8GB",
-		},
-		{
-			Name: "shared-cpu-8x 2GB",
-			ID:   "shared-cpu-8x-2GB",
-		},
-		{
-			Name: "shared-cpu-8x 4GB",
-			ID:   "shared-cpu-8x-4GB",
-		},
-		{
-			Name: "performance-cpu-1x 2GB",
-			ID:   "performance-cpu-1x-2GB",
-		},
-		{
-			Name: "performance-cpu-1x 4GB",
-			ID:   "performance-cpu-1x-4GB",
-		},
-		{
-			Name: "performance-cpu-1x 8GB",
-			ID:   "performance-cpu-1x-8GB",
-		},
-		{
-			Name: "performance-cpu-2x 4GB",
-			ID:   "performance-cpu-2x-4GB",
-		},
-		{
-			Name: "performance-cpu-2x 8GB",
-			ID:   "performance-cpu-2x-8GB",
-		},
-		{
-			Name: "performance-cpu-2x 16GB",
-			ID:   "performance-cpu-2x-16GB",
-		},
-		{
-			Name: "performance-cpu-4x 8GB",
-			ID:   "performance-cpu-4x-8GB",
-		},
-		{
-			Name: "performance-cpu-4x 16GB",
-			ID:   "performance-cpu-4x-16GB",
-		},
-		{
-			Name: "performance-cpu-4x 32GB",
-			ID:   "performance-cpu-4x-32GB",
-		},
-		{
-			Name: "performance-cpu-8x 16GB",
-			ID:   "performance-cpu-8x-16GB",
-		},
-		{
-			Name: "performance-cpu-8x 32GB",
-			ID:   "performance-cpu-8x-32GB",
-		},
-		{
-			Name: "performance-cpu-8x 64GB",
-			ID:   "performance-cpu-8x-64GB",
-		},
-		{
-			Name: "performance-cpu-16x 32GB",
-			ID:   "performance-cpu-16x-32GB",
-		},
-		{
-			Name: "performance-cpu-16x 64GB",
-			ID:   "performance-cpu-16x-64GB",
-		},
-		{
-			Name: "performance-cpu-16x 128GB",
-			ID:   "performance-cpu-16x-128GB",
-		},
+	var choices []SelectChoice
+	for _, config := range models.ComputingSpecsConfiguration {
+		for _, ram := range config.RAMConfigs {
+			choices = append(choices, SelectChoice{
+				Name: config.Name + " " + ram,
+				ID:   config.Name + "-" + ram,
+			})
+		}
 	}
 
 	return NewSelectModel("Which computing specs would you like to use?", choices)
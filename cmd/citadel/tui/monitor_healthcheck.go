@@ -3,18 +3,25 @@ package tui
 import (
 	"citadel/cmd/citadel/api"
 	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/alevinval/sse/pkg/eventsource"
 	"github.com/charmbracelet/huh/spinner"
 )
 
-func MonitorHealtcheck(orgId string, appSlug string) {
-	healthCheckStatus := "pending"
+func MonitorHealtcheck(orgId string, appSlug string, deploymentId string, onInterrupt func()) {
+	healthCheckStatus := models.HealthCheckStatusPending
+	healthCheckMessage := ""
+
+	// The spinner stops before its action returns when the user presses Ctrl-C.
+	monitored := false
 
 	_ = spinner.New().Title("Waiting for healthcheck...").Action(func() {
+		defer func() { monitored = true }()
+
 		url := api.RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/logs/stream?previous=false"
 
 		token, err := util.RetrieveTokenFromConfig()
@@ -28,37 +35,42 @@ func MonitorHealtcheck(orgId string, appSlug string) {
 			fmt.Println(err)
 			os.Exit(1)
 		}
+		defer es.Close()
+
+		for event := range es.MessageEvents() {
+			if event.Name != "health" {
+				continue
+			}
 
-		for {
-			select {
-			case event := <-es.MessageEvents():
-				if strings.Contains(event.Data, "Health check") {
-					if strings.Contains(event.Data, "is now passing") {
-						healthCheckStatus = "passing"
-						return
-					}
+			var healthEvent models.HealthCheckEvent
+			if err := json.Unmarshal([]byte(event.Data), &healthEvent); err != nil {
+				continue
+			}
+			if deploymentId != "" && healthEvent.DeploymentID != deploymentId {
+				continue
+			}
 
-					if strings.Contains(event.Data, "has failed") {
-						healthCheckStatus = "failed"
-						return
-					}
-				}
+			healthCheckStatus = healthEvent.Status
+			healthCheckMessage = healthEvent.Message
+			if healthCheckStatus != models.HealthCheckStatusPending {
+				return
 			}
 		}
 	}).Run()
 
-	if healthCheckStatus == "pending" {
-		fmt.Println("🟡 Health check is still pending")
-		os.Exit(1)
+	if !monitored {
+		onInterrupt()
 	}
 
-	if healthCheckStatus == "passing" {
-		fmt.Println("🟢 Health check is now passing.")
+	switch healthCheckStatus {
+	case models.HealthCheckStatusPassing:
+		fmt.Println("🟢 " + healthCheckMessage)
 		os.Exit(0)
-	}
-
-	if healthCheckStatus == "failed" {
-		fmt.Println("🔴 Health check has failed.")
+	case models.HealthCheckStatusFailed:
+		fmt.Println("🔴 " + healthCheckMessage)
+		os.Exit(1)
+	default:
+		fmt.Println("🟡 Health check is still pending")
 		os.Exit(1)
 	}
 }
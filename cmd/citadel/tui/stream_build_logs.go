@@ -3,6 +3,8 @@ package tui
 import (
 	"citadel/cmd/citadel/api"
 	"citadel/cmd/citadel/util"
+	"citadel/internal/models"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -11,13 +13,15 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func StreamBuildLogs(orgId string, appSlug string) {
+func StreamBuildLogs(orgId string, appSlug string, deploymentId string, onInterrupt func()) {
 	streamModel := NewStreamModel("Building...")
 
 	buildFailed := false
+	buildCanceled := false
+	buildSuperseded := false
 
-	streamModel.Run(func(p *tea.Program) {
-		url := api.RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/logs/stream?scope=builder&previous=false"
+	interrupted := streamModel.Run(func(p *tea.Program) {
+		url := api.RetrieveApiBaseUrl() + "/orgs/" + orgId + "/apps/" + appSlug + "/logs/stream?scope=builder&deployment=" + deploymentId
 
 		token, err := util.RetrieveTokenFromConfig()
 		if err != nil {
@@ -34,13 +38,30 @@ func StreamBuildLogs(orgId string, appSlug string) {
 		for {
 			select {
 			case event := <-es.MessageEvents():
-				if strings.Contains(event.Data, "Main child exited") || strings.Contains(event.Data, "Pushed Docker image, built with Nixpacks.") || strings.Contains(event.Data, "Pushed Docker image.") {
-					if strings.Contains(event.Data, "Main child exited normally with code: 1") {
+				// The build is over, with or without the output telling so.
+				if event.Name == "end" {
+					buildFailed = event.Data == "failed"
+					buildCanceled = event.Data == "canceled"
+					buildSuperseded = event.Data == "superseded"
+					p.Quit()
+					continue
+				}
+				if event.Name != "log" {
+					continue
+				}
+
+				var logEvent models.LogEvent
+				if err := json.Unmarshal([]byte(event.Data), &logEvent); err != nil {
+					continue
+				}
+
+				if strings.Contains(logEvent.Message, "Main child exited") || strings.Contains(logEvent.Message, "Pushed Docker image, built with Nixpacks.") || strings.Contains(logEvent.Message, "Pushed Docker image.") {
+					if strings.Contains(logEvent.Message, "Main child exited normally with code: 1") {
 						buildFailed = true
 					}
 					p.Quit()
 				}
-				splitted := strings.Split(event.Data, " | ")
+				splitted := strings.Split(logEvent.Message, " | ")
 				if len(splitted) != 2 {
 					continue
 				}
@@ -54,7 +75,17 @@ func StreamBuildLogs(orgId string, appSlug string) {
 		}
 	})
 
-	if buildFailed {
+	if interrupted {
+		onInterrupt()
+	}
+
+	if buildSuperseded {
+		fmt.Println("⏭️  Deployment superseded by a newer one, which will be deployed instead.")
+		os.Exit(0)
+	} else if buildCanceled {
+		fmt.Println("🛑 Build canceled.")
+		os.Exit(1)
+	} else if buildFailed {
 		fmt.Println("🔴 Build failed.")
 		os.Exit(1)
 	} else {
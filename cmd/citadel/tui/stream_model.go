@@ -25,10 +25,11 @@ func (r StreamModelResultMsg) String() string {
 }
 
 type StreamModel struct {
-	spinner  spinner.Model
-	results  []StreamModelResultMsg
-	quitting bool
-	Title    string
+	spinner     spinner.Model
+	results     []StreamModelResultMsg
+	quitting    bool
+	interrupted bool
+	Title       string
 }
 
 func NewStreamModel(Title string) StreamModel {
@@ -50,6 +51,7 @@ func (m StreamModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		m.quitting = true
+		m.interrupted = msg.String() == "ctrl+c"
 		return m, tea.Quit
 	case StreamModelResultMsg:
 		m.results = append(m.results[1:], msg)
@@ -85,7 +87,8 @@ func (m StreamModel) View() string {
 	return appStyle.Render(s)
 }
 
-func (m StreamModel) Run(streamFn func(p *tea.Program)) {
+// Run runs the model while streamFn streams results to it, and reports whether the user pressed Ctrl-C.
+func (m StreamModel) Run(streamFn func(p *tea.Program)) bool {
 	p := tea.NewProgram(m)
 
 	// Simulate activity
@@ -93,8 +96,11 @@ func (m StreamModel) Run(streamFn func(p *tea.Program)) {
 		streamFn(p)
 	}()
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
+
+	return final.(StreamModel).interrupted
 }
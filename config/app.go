@@ -25,14 +25,20 @@ func ProvideApp(env *EnvironmentVariables) *core.App {
 		controllers.NewMailDomainsController,
 		controllers.NewMailApiKeysController,
 		controllers.NewStorageController,
+		controllers.NewVolumesController,
 		controllers.NewDatabasesController,
 		controllers.NewStripeController,
 		authControllers.NewCliController,
 		authControllers.NewResetPwdController,
 		controllers.NewGithubController,
+		controllers.NewCronJobsController,
+		controllers.NewProcessesController,
 		controllers.NewDeploymentsController,
 		controllers.NewEnvController,
 		controllers.NewLogsController,
+		controllers.NewMetricsController,
+		controllers.NewHealthController,
+		controllers.NewExecController,
 		controllers.NewCertsController,
 		authControllers.NewSignOutController,
 		controllers.NewBillingController,
@@ -53,6 +59,11 @@ func ProvideApp(env *EnvironmentVariables) *core.App {
 	app.RegisterProviders(
 		services.NewUsersService,
 		services.NewAppsService,
+		services.NewLogsService,
+		services.NewDeploymentsQueue,
+		services.NewCronScheduler,
+		services.NewVolumesService,
+		services.NewMetricsService,
 	)
 
 	app.RegisterProviders(
@@ -60,7 +71,14 @@ func ProvideApp(env *EnvironmentVariables) *core.App {
 		repositories.NewApplicationsRepository,
 		repositories.NewCertificatesRepository,
 		repositories.NewDeploymentsRepository,
+		repositories.NewCronJobsRepository,
+		repositories.NewCronJobRunsRepository,
+		repositories.NewProcessTypesRepository,
+		repositories.NewLogEntriesRepository,
+		repositories.NewMetricSamplesRepository,
 		repositories.NewStorageBucketsRepository,
+		repositories.NewVolumesRepository,
+		repositories.NewVolumeSnapshotsRepository,
 		repositories.NewDatabasesRepository,
 		repositories.NewMailDomainsRepository,
 		repositories.NewMailApiKeysRepository,
@@ -95,6 +113,21 @@ func ProvideApp(env *EnvironmentVariables) *core.App {
 				}
 			}()
 		},
+		func(logsService *services.LogsService) {
+			go logsService.EnforceRetentionPeriodically()
+		},
+		func(metricsService *services.MetricsService) {
+			go metricsService.MaintainPeriodically()
+		},
+		func(deplsQueue *services.DeploymentsQueue) {
+			go deplsQueue.DispatchPeriodically()
+		},
+		func(cronScheduler *services.CronScheduler) {
+			go cronScheduler.RunPeriodically()
+		},
+		func(volumesService *services.VolumesService) {
+			volumesService.FailInterruptedSnapshots()
+		},
 	)
 
 	return app
@@ -3,18 +3,33 @@ package config
 import (
 	"citadel/internal/drivers"
 	dockerDriver "citadel/internal/drivers/docker_driver"
+	fakeDriver "citadel/internal/drivers/fake_driver"
+	kubernetesDriver "citadel/internal/drivers/kubernetes_driver"
 	ravelDriver "citadel/internal/drivers/ravel_driver"
 	"citadel/internal/repositories"
+
+	"github.com/caesar-rocks/drive"
 )
 
 func ProvideDriver(
 	env *EnvironmentVariables,
 	appsRepo *repositories.ApplicationsRepository,
 	deplsRepo *repositories.DeploymentsRepository,
+	certsRepo *repositories.CertificatesRepository,
+	logsRepo *repositories.LogEntriesRepository,
+	processTypesRepo *repositories.ProcessTypesRepository,
+	volumesRepo *repositories.VolumesRepository,
+	databasesRepo *repositories.DatabasesRepository,
+	metricsRepo *repositories.MetricSamplesRepository,
+	drive *drive.Drive,
 ) drivers.Driver {
 	switch env.DRIVER {
 	case DockerDriver:
-		return dockerDriver.New(appsRepo, deplsRepo)
+		return dockerDriver.New(appsRepo, deplsRepo, certsRepo, logsRepo, processTypesRepo, volumesRepo, databasesRepo, metricsRepo, drive)
+	case KubernetesDriver:
+		return kubernetesDriver.New(appsRepo, deplsRepo, certsRepo, logsRepo, processTypesRepo, volumesRepo)
+	case FakeDriver:
+		return fakeDriver.New(deplsRepo, processTypesRepo)
 	case RavelDriver:
 		return ravelDriver.New()
 	default:
@@ -93,14 +93,18 @@ type EnvironmentVariables struct {
 	SMTP_DOMAIN string
 
 	// SMTP_USER is the user for the SMTP server.
-	DRIVER Driver `validate:"oneof=docker ravel"`
+	DRIVER Driver `validate:"oneof=docker kubernetes ravel fake"`
 }
 
 type Driver string
 
 const (
-	DockerDriver Driver = "docker"
-	RavelDriver  Driver = "ravel"
+	DockerDriver     Driver = "docker"
+	KubernetesDriver Driver = "kubernetes"
+	RavelDriver      Driver = "ravel"
+
+	// FakeDriver runs nothing, and simulates the deployments in memory (e.g. for end-to-end tests).
+	FakeDriver Driver = "fake"
 )
 
 func ProvideEnvironmentVariables() *EnvironmentVariables {
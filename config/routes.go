@@ -25,9 +25,14 @@ func RegisterRoutes(
 	resetPwdController *authControllers.ResetPwdController,
 	authGithubController *authControllers.GithubController,
 	logsController *controllers.LogsController,
+	metricsController *controllers.MetricsController,
+	healthController *controllers.HealthController,
+	execController *controllers.ExecController,
 	appsController *controllers.AppsController,
 	databasesController *controllers.DatabasesController,
 	envController *controllers.EnvController,
+	cronJobsController *controllers.CronJobsController,
+	processesController *controllers.ProcessesController,
 	deploymentsController *controllers.DeploymentsController,
 	certsController *controllers.CertsController,
 	billingController *controllers.BillingController,
@@ -38,6 +43,7 @@ func RegisterRoutes(
 	mailDomainsController *controllers.MailDomainsController,
 	mailApiKeysController *controllers.MailApiKeysController,
 	storageController *controllers.StorageController,
+	volumesController *controllers.VolumesController,
 	emailsController *apiControllers.EmailsController,
 	analyticsWebsitesController *controllers.AnalyticsWebsitesController,
 	orgsRepository *repositories.OrganizationsRepository,
@@ -67,6 +73,9 @@ func RegisterRoutes(
 		return ctx.Redirect("/orgs/no_org/mails")
 	})
 
+	// Health route
+	router.Get("/health", healthController.Show)
+
 	// Auth routes
 	router.Get("/auth/sign_up", signUpController.Show)
 	router.Post("/auth/sign_up", signUpController.Handle)
@@ -111,6 +120,10 @@ func RegisterRoutes(
 		Delete("/orgs/{orgId}/apps/{slug}", appsController.Delete).
 		Use(auth.AuthMiddleware).
 		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.
+		Post("/orgs/{orgId}/apps/{slug}/scale", appsController.Scale).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
 
 	// Apps-related routes
 	router.Get("/orgs/{orgId}/apps/{slug}/certs", certsController.Index).Use(auth.AuthMiddleware)
@@ -173,6 +186,20 @@ func RegisterRoutes(
 		Use(auth.AuthMiddleware)
 	router.Get("/orgs/{orgId}/apps/{slug}/logs/stream", logsController.Stream).
 		Use(auth.AuthMiddleware)
+	router.Get("/orgs/{orgId}/apps/{slug}/logs/search", logsController.Search).
+		Use(auth.AuthMiddleware)
+
+	// Metrics-related routes
+	router.Get("/orgs/{orgId}/apps/{slug}/metrics", metricsController.Show).
+		Use(auth.AuthMiddleware)
+	router.Get("/orgs/{orgId}/databases/{slug}/metrics", metricsController.ShowDatabase).
+		Use(auth.AuthMiddleware)
+
+	// Exec-related routes
+	router.Post("/orgs/{orgId}/apps/{slug}/exec", execController.Run).
+		Use(auth.AuthMiddleware)
+	router.Get("/orgs/{orgId}/apps/{slug}/exec/ws", execController.Attach).
+		Use(auth.AuthMiddleware)
 
 	// Storage-related routes
 	router.
@@ -197,12 +224,65 @@ func RegisterRoutes(
 		Post("/orgs/{orgId}/storage/{slug}/upload", storageController.UploadFile).
 		Use(auth.AuthMiddleware)
 
+	// Volumes-related routes
+	router.Get("/orgs/{orgId}/volumes", volumesController.Index).Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/volumes", volumesController.Store).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.Get("/orgs/{orgId}/volumes/{slug}", volumesController.Show).Use(auth.AuthMiddleware)
+	router.
+		Delete("/orgs/{orgId}/volumes/{slug}", volumesController.Delete).
+		Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/volumes/{slug}/attach", volumesController.Attach).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.
+		Post("/orgs/{orgId}/volumes/{slug}/detach", volumesController.Detach).
+		Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/volumes/{slug}/snapshots", volumesController.Snapshot).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.
+		Post("/orgs/{orgId}/volumes/{slug}/snapshots/{id}/restore", volumesController.Restore).
+		Use(auth.AuthMiddleware)
+	router.
+		Delete("/orgs/{orgId}/volumes/{slug}/snapshots/{id}", volumesController.DeleteSnapshot).
+		Use(auth.AuthMiddleware)
+
 	// Environment variables-related routes
 	router.Get("/orgs/{orgId}/apps/{slug}/env", envController.Edit).Use(auth.AuthMiddleware)
 	router.
 		Patch("/orgs/{orgId}/apps/{slug}/env", envController.Update).
 		Use(auth.AuthMiddleware).
 		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.
+		Patch("/orgs/{orgId}/apps/{slug}/preview_env", envController.UpdatePreview).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
+
+	// Cron jobs-related routes
+	router.Get("/orgs/{orgId}/apps/{slug}/cron_jobs", cronJobsController.Index).Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/apps/{slug}/cron_jobs", cronJobsController.Store).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.
+		Delete("/orgs/{orgId}/apps/{slug}/cron_jobs/{id}", cronJobsController.Delete).
+		Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/apps/{slug}/cron_jobs/{id}/run", cronJobsController.Run).
+		Use(auth.AuthMiddleware)
+	router.Get("/orgs/{orgId}/apps/{slug}/cron_jobs/{id}/runs", cronJobsController.Runs).Use(auth.AuthMiddleware)
+
+	// Process types-related routes
+	router.Get("/orgs/{orgId}/apps/{slug}/processes", processesController.Index).Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/apps/{slug}/processes/{name}/scale", processesController.Scale).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
 
 	// Deployments-related routes
 	router.Get("/orgs/{orgId}/apps/{slug}/deployments", deploymentsController.Index).Use(auth.AuthMiddleware)
@@ -211,6 +291,14 @@ func RegisterRoutes(
 		Use(auth.AuthMiddleware).
 		Use(middleware.PaymentMethodMiddleware(vexillum))
 	router.Get("/orgs/{orgId}/apps/{slug}/deployments/list", deploymentsController.List).Use(auth.AuthMiddleware)
+	router.Get("/orgs/{orgId}/apps/{slug}/deployments/{id}", deploymentsController.Show).Use(auth.AuthMiddleware)
+	router.
+		Post("/orgs/{orgId}/apps/{slug}/deployments/{id}/rollback", deploymentsController.Rollback).
+		Use(auth.AuthMiddleware).
+		Use(middleware.PaymentMethodMiddleware(vexillum))
+	router.
+		Post("/orgs/{orgId}/apps/{slug}/deployments/{id}/cancel", deploymentsController.Cancel).
+		Use(auth.AuthMiddleware)
 
 	// Billing-related routes
 	router.
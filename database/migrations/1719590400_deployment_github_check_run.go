@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func deploymentGitHubCheckRunMigrationUp_1719590400(ctx context.Context, db *bun.DB) error {
+	if err := addColumn(ctx, db, (*models.Deployment)(nil), "commit_sha", "VARCHAR"); err != nil {
+		return err
+	}
+	return addColumn(ctx, db, (*models.Deployment)(nil), "github_check_run_id", "BIGINT")
+}
+
+func deploymentGitHubCheckRunMigrationDown_1719590400(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewDropColumn().Model((*models.Deployment)(nil)).Column("commit_sha").Exec(ctx); err != nil {
+		return err
+	}
+	_, err := db.NewDropColumn().Model((*models.Deployment)(nil)).Column("github_check_run_id").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(deploymentGitHubCheckRunMigrationUp_1719590400, deploymentGitHubCheckRunMigrationDown_1719590400)
+}
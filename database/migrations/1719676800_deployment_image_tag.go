@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func deploymentImageTagMigrationUp_1719676800(ctx context.Context, db *bun.DB) error {
+	return addColumn(ctx, db, (*models.Deployment)(nil), "image_tag", "VARCHAR")
+}
+
+func deploymentImageTagMigrationDown_1719676800(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropColumn().Model((*models.Deployment)(nil)).Column("image_tag").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(deploymentImageTagMigrationUp_1719676800, deploymentImageTagMigrationDown_1719676800)
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func databasesComputingSpecsMigrationUp_1719763200(ctx context.Context, db *bun.DB) error {
+	if err := addColumn(ctx, db, (*models.Database)(nil), "cpu_cfg", "VARCHAR DEFAULT 'shared-cpu-1x'"); err != nil {
+		return err
+	}
+	return addColumn(ctx, db, (*models.Database)(nil), "ram_cfg", "VARCHAR DEFAULT '512MB'")
+}
+
+func databasesComputingSpecsMigrationDown_1719763200(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewDropColumn().Model((*models.Database)(nil)).Column("cpu_cfg").Exec(ctx); err != nil {
+		return err
+	}
+	_, err := db.NewDropColumn().Model((*models.Database)(nil)).Column("ram_cfg").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(databasesComputingSpecsMigrationUp_1719763200, databasesComputingSpecsMigrationDown_1719763200)
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func deploymentReleaseMigrationUp_1719849600(ctx context.Context, db *bun.DB) error {
+	if err := addColumn(ctx, db, (*models.Deployment)(nil), "release_command", "VARCHAR"); err != nil {
+		return err
+	}
+	return addColumn(ctx, db, (*models.Deployment)(nil), "release_output", "TEXT")
+}
+
+func deploymentReleaseMigrationDown_1719849600(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewDropColumn().Model((*models.Deployment)(nil)).Column("release_command").Exec(ctx); err != nil {
+		return err
+	}
+	_, err := db.NewDropColumn().Model((*models.Deployment)(nil)).Column("release_output").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(deploymentReleaseMigrationUp_1719849600, deploymentReleaseMigrationDown_1719849600)
+}
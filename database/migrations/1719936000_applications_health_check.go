@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+var applicationsHealthCheckColumns_1719936000 = map[string]string{
+	"health_check_path":                "VARCHAR",
+	"health_check_port":                "INTEGER",
+	"health_check_interval":            "INTEGER",
+	"health_check_timeout":             "INTEGER",
+	"health_check_healthy_threshold":   "INTEGER",
+	"health_check_unhealthy_threshold": "INTEGER",
+}
+
+func applicationsHealthCheckMigrationUp_1719936000(ctx context.Context, db *bun.DB) error {
+	for column, kind := range applicationsHealthCheckColumns_1719936000 {
+		if err := addColumn(ctx, db, (*models.Application)(nil), column, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applicationsHealthCheckMigrationDown_1719936000(ctx context.Context, db *bun.DB) error {
+	for column := range applicationsHealthCheckColumns_1719936000 {
+		if _, err := db.NewDropColumn().Model((*models.Application)(nil)).Column(column).Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Migrations.MustRegister(applicationsHealthCheckMigrationUp_1719936000, applicationsHealthCheckMigrationDown_1719936000)
+}
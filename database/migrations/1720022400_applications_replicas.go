@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func applicationsReplicasMigrationUp_1720022400(ctx context.Context, db *bun.DB) error {
+	return addColumn(ctx, db, (*models.Application)(nil), "replicas", "INTEGER DEFAULT 1")
+}
+
+func applicationsReplicasMigrationDown_1720022400(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropColumn().Model((*models.Application)(nil)).Column("replicas").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(applicationsReplicasMigrationUp_1720022400, applicationsReplicasMigrationDown_1720022400)
+}
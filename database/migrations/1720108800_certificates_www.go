@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func certificatesWwwMigrationUp_1720108800(ctx context.Context, db *bun.DB) error {
+	return addColumn(ctx, db, (*models.Certificate)(nil), "www", "BOOLEAN DEFAULT FALSE")
+}
+
+func certificatesWwwMigrationDown_1720108800(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropColumn().Model((*models.Certificate)(nil)).Column("www").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(certificatesWwwMigrationUp_1720108800, certificatesWwwMigrationDown_1720108800)
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func logEntriesMigrationUp_1720195200(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewCreateTable().Model((*models.LogEntry)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+
+	// Logs are searched by application and time range, and purged by time.
+	_, err := db.NewCreateIndex().
+		Model((*models.LogEntry)(nil)).
+		Index("log_entries_application_id_timestamp_idx").
+		Column("application_id", "timestamp").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.NewCreateIndex().
+		Model((*models.LogEntry)(nil)).
+		Index("log_entries_deployment_id_idx").
+		Column("deployment_id").
+		Exec(ctx)
+	return err
+}
+
+func logEntriesMigrationDown_1720195200(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropTable().Model((*models.LogEntry)(nil)).Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(logEntriesMigrationUp_1720195200, logEntriesMigrationDown_1720195200)
+}
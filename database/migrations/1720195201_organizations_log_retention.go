@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+	"strconv"
+
+	"github.com/uptrace/bun"
+)
+
+func organizationsLogRetentionMigrationUp_1720195201(ctx context.Context, db *bun.DB) error {
+	return addColumn(ctx, db, (*models.Organization)(nil), "log_retention_days", "INTEGER DEFAULT "+strconv.Itoa(models.DefaultLogRetentionDays))
+}
+
+func organizationsLogRetentionMigrationDown_1720195201(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropColumn().Model((*models.Organization)(nil)).Column("log_retention_days").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(organizationsLogRetentionMigrationUp_1720195201, organizationsLogRetentionMigrationDown_1720195201)
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+	"strconv"
+
+	"github.com/uptrace/bun"
+)
+
+func organizationsMaxConcurrentBuildsMigrationUp_1720281600(ctx context.Context, db *bun.DB) error {
+	return addColumn(ctx, db, (*models.Organization)(nil), "max_concurrent_builds", "INTEGER DEFAULT "+strconv.Itoa(models.DefaultMaxConcurrentBuilds))
+}
+
+func organizationsMaxConcurrentBuildsMigrationDown_1720281600(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropColumn().Model((*models.Organization)(nil)).Column("max_concurrent_builds").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(organizationsMaxConcurrentBuildsMigrationUp_1720281600, organizationsMaxConcurrentBuildsMigrationDown_1720281600)
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+var applicationsPreviewsColumns_1720368000 = map[string]string{
+	"preview_env":           "JSONB DEFAULT '[]'",
+	"parent_application_id": "VARCHAR DEFAULT ''",
+	"pull_request_number":   "INTEGER DEFAULT 0",
+}
+
+func applicationsPreviewsMigrationUp_1720368000(ctx context.Context, db *bun.DB) error {
+	for column, kind := range applicationsPreviewsColumns_1720368000 {
+		if err := addColumn(ctx, db, (*models.Application)(nil), column, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applicationsPreviewsMigrationDown_1720368000(ctx context.Context, db *bun.DB) error {
+	for column := range applicationsPreviewsColumns_1720368000 {
+		if _, err := db.NewDropColumn().Model((*models.Application)(nil)).Column(column).Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Migrations.MustRegister(applicationsPreviewsMigrationUp_1720368000, applicationsPreviewsMigrationDown_1720368000)
+}
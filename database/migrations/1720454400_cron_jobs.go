@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func cronJobsMigrationUp_1720454400(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewCreateTable().Model((*models.CronJob)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := db.NewCreateTable().Model((*models.CronJobRun)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+
+	// The runs are listed by job, from the most recent.
+	_, err := db.NewCreateIndex().
+		Model((*models.CronJobRun)(nil)).
+		Index("cron_job_runs_cron_job_id_started_at_idx").
+		Column("cron_job_id", "started_at").
+		Exec(ctx)
+	return err
+}
+
+func cronJobsMigrationDown_1720454400(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewDropTable().Model((*models.CronJobRun)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+	_, err := db.NewDropTable().Model((*models.CronJob)(nil)).Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(cronJobsMigrationUp_1720454400, cronJobsMigrationDown_1720454400)
+}
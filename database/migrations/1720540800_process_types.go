@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func processTypesMigrationUp_1720540800(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewCreateTable().Model((*models.ProcessType)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := db.NewCreateIndex().
+		Model((*models.ProcessType)(nil)).
+		Index("process_types_application_id_name_idx").
+		Unique().
+		Column("application_id", "name").
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := addColumn(ctx, db, (*models.Deployment)(nil), "processes", "JSONB"); err != nil {
+		return err
+	}
+
+	if err := addColumn(ctx, db, (*models.LogEntry)(nil), "process", "VARCHAR DEFAULT ''"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func processTypesMigrationDown_1720540800(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewDropColumn().Model((*models.LogEntry)(nil)).Column("process").Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := db.NewDropColumn().Model((*models.Deployment)(nil)).Column("processes").Exec(ctx); err != nil {
+		return err
+	}
+	_, err := db.NewDropTable().Model((*models.ProcessType)(nil)).Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(processTypesMigrationUp_1720540800, processTypesMigrationDown_1720540800)
+}
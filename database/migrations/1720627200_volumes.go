@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func volumesMigrationUp_1720627200(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewCreateTable().Model((*models.Volume)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := db.NewCreateTable().Model((*models.VolumeSnapshot)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+
+	// The volumes are looked up by application on every deployment.
+	_, err := db.NewCreateIndex().
+		Model((*models.Volume)(nil)).
+		Index("volumes_application_id_idx").
+		Column("application_id").
+		Exec(ctx)
+	return err
+}
+
+func volumesMigrationDown_1720627200(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewDropTable().Model((*models.VolumeSnapshot)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+	_, err := db.NewDropTable().Model((*models.Volume)(nil)).Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(volumesMigrationUp_1720627200, volumesMigrationDown_1720627200)
+}
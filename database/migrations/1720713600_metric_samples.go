@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func metricSamplesMigrationUp_1720713600(ctx context.Context, db *bun.DB) error {
+	if _, err := db.NewCreateTable().Model((*models.MetricSample)(nil)).Exec(ctx); err != nil {
+		return err
+	}
+
+	// Samples are downsampled and purged by resolution and time.
+	_, err := db.NewCreateIndex().
+		Model((*models.MetricSample)(nil)).
+		Index("metric_samples_resolution_timestamp_idx").
+		Column("resolution", "timestamp").
+		Exec(ctx)
+	return err
+}
+
+func metricSamplesMigrationDown_1720713600(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropTable().Model((*models.MetricSample)(nil)).Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(metricSamplesMigrationUp_1720713600, metricSamplesMigrationDown_1720713600)
+}
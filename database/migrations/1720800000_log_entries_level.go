@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// The level of the entries stored before is left empty: they only match the searches without a level.
+func logEntriesLevelMigrationUp_1720800000(ctx context.Context, db *bun.DB) error {
+	return addColumn(ctx, db, (*models.LogEntry)(nil), "level", "VARCHAR")
+}
+
+func logEntriesLevelMigrationDown_1720800000(ctx context.Context, db *bun.DB) error {
+	_, err := db.NewDropColumn().Model((*models.LogEntry)(nil)).Column("level").Exec(ctx)
+	return err
+}
+
+func init() {
+	Migrations.MustRegister(logEntriesLevelMigrationUp_1720800000, logEntriesLevelMigrationDown_1720800000)
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// addColumn adds a column to the table of a model, unless it has it already. The tables are created
+// from the current models, so on a fresh database they have the columns added by the later migrations.
+func addColumn(ctx context.Context, db *bun.DB, model any, column string, kind string) error {
+	exists, err := columnExists(ctx, db, model, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = db.NewAddColumn().Model(model).ColumnExpr("? "+kind, bun.Ident(column)).Exec(ctx)
+	return err
+}
+
+// columnExists tells whether the table of a model has a column.
+func columnExists(ctx context.Context, db *bun.DB, model any, column string) (bool, error) {
+	table := db.Table(reflect.TypeOf(model)).Name
+
+	var count int
+	var err error
+	switch db.Dialect().Name() {
+	case dialect.SQLite:
+		err = db.NewRaw("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(ctx, &count)
+	case dialect.MySQL:
+		err = db.NewRaw("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?", table, column).Scan(ctx, &count)
+	default:
+		err = db.NewRaw("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?", table, column).Scan(ctx, &count)
+	}
+
+	return count > 0, err
+}
@@ -5,6 +5,7 @@ import (
 	"citadel/internal/models"
 	"citadel/internal/repositories"
 	"citadel/internal/services"
+	"fmt"
 
 	appsPages "citadel/views/concerns/apps/pages"
 
@@ -16,11 +17,12 @@ import (
 type AppsController struct {
 	appsService *services.AppsService
 	appsRepo    *repositories.ApplicationsRepository
+	volumesRepo *repositories.VolumesRepository
 	driver      drivers.Driver
 }
 
-func NewAppsController(appsService *services.AppsService, appsRepo *repositories.ApplicationsRepository, driver drivers.Driver) *AppsController {
-	return &AppsController{appsService, appsRepo, driver}
+func NewAppsController(appsService *services.AppsService, appsRepo *repositories.ApplicationsRepository, volumesRepo *repositories.VolumesRepository, driver drivers.Driver) *AppsController {
+	return &AppsController{appsService, appsRepo, volumesRepo, driver}
 }
 
 func (c *AppsController) Index(ctx *caesar.Context) error {
@@ -58,6 +60,12 @@ func (c *AppsController) Store(ctx *caesar.Context) error {
 	if data.RamConfig == "" {
 		data.RamConfig = "256MB"
 	}
+	if _, err := models.ParseComputingSpecs(data.CpuConfig, data.RamConfig); err != nil {
+		if ctx.WantsJSON() {
+			return caesar.NewError(400)
+		}
+		return ctx.RedirectBack()
+	}
 
 	app := &models.Application{
 		OrganizationID:       ctx.PathValue("orgId"),
@@ -110,6 +118,13 @@ type UpdateApplicationValidator struct {
 	ReleaseCommand string `form:"release_command"`
 	CpuConfig      string `form:"cpu_config" validate:"required"`
 	RamConfig      string `form:"ram_config" validate:"required"`
+
+	HealthCheckPath               string `form:"health_check_path" validate:"omitempty,startswith=/"`
+	HealthCheckPort               int    `form:"health_check_port" validate:"omitempty,min=1,max=65535"`
+	HealthCheckInterval           int    `form:"health_check_interval" validate:"omitempty,min=1,max=300"`
+	HealthCheckTimeout            int    `form:"health_check_timeout" validate:"omitempty,min=1,max=60"`
+	HealthCheckHealthyThreshold   int    `form:"health_check_healthy_threshold" validate:"omitempty,min=1,max=10"`
+	HealthCheckUnhealthyThreshold int    `form:"health_check_unhealthy_threshold" validate:"omitempty,min=1,max=60"`
 }
 
 func (c *AppsController) Update(ctx *caesar.Context) error {
@@ -122,11 +137,23 @@ func (c *AppsController) Update(ctx *caesar.Context) error {
 	if !ok {
 		return ctx.Render(appsPages.ApplicationsSettingsForm(*app, errors))
 	}
+	if _, err := models.ParseComputingSpecs(data.CpuConfig, data.RamConfig); err != nil {
+		return ctx.Render(appsPages.ApplicationsSettingsForm(*app, map[string]string{"CpuConfig": err.Error()}))
+	}
+	if err := models.ValidateHealthCheckPath(data.HealthCheckPath); err != nil {
+		return ctx.Render(appsPages.ApplicationsSettingsForm(*app, map[string]string{"HealthCheckPath": err.Error()}))
+	}
 
 	app.Name = data.Name
 	app.ReleaseCommand = data.ReleaseCommand
 	app.CpuConfig = data.CpuConfig
 	app.RamConfig = data.RamConfig
+	app.HealthCheckPath = data.HealthCheckPath
+	app.HealthCheckPort = data.HealthCheckPort
+	app.HealthCheckInterval = data.HealthCheckInterval
+	app.HealthCheckTimeout = data.HealthCheckTimeout
+	app.HealthCheckHealthyThreshold = data.HealthCheckHealthyThreshold
+	app.HealthCheckUnhealthyThreshold = data.HealthCheckUnhealthyThreshold
 
 	if err := c.appsRepo.UpdateOneWhere(ctx.Context(), app, "slug", ctx.PathValue("slug")); err != nil {
 		return err
@@ -137,6 +164,47 @@ func (c *AppsController) Update(ctx *caesar.Context) error {
 	return ctx.Render(appsPages.ApplicationsSettingsForm(*app, nil))
 }
 
+type ScaleApplicationValidator struct {
+	Replicas int `form:"replicas" json:"replicas" validate:"required,min=1"`
+}
+
+// Scale sets the number of instances the application runs.
+func (c *AppsController) Scale(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, errors, ok := caesar.Validate[ScaleApplicationValidator](ctx)
+	if ok && data.Replicas > models.MaxReplicas {
+		errors["Replicas"] = fmt.Sprintf("An application can run at most %d instances.", models.MaxReplicas)
+		ok = false
+	}
+	if !ok {
+		if ctx.WantsJSON() {
+			return caesar.NewError(400)
+		}
+		return ctx.Render(appsPages.ScaleForm(*app, errors))
+	}
+
+	app.Replicas = data.Replicas
+	if err := c.appsRepo.UpdateOneWhere(ctx.Context(), app, "id", app.ID); err != nil {
+		return err
+	}
+
+	if err := c.driver.ScaleApplication(*app); err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(app)
+	}
+
+	toast.Success(ctx, "Application scaled successfully.")
+
+	return ctx.Render(appsPages.ScaleForm(*app, nil))
+}
+
 type ConnectGitHubValidator struct {
 	GitHubInstallationID int64  `form:"github_installation_id" validate:"required"`
 	GitHubRepository     string `form:"github_repository" validate:"required"`
@@ -188,9 +256,17 @@ func (c *AppsController) Delete(ctx *caesar.Context) error {
 		return err
 	}
 
+	if err := c.appsService.DeletePreviews(ctx.Context(), *app); err != nil {
+		return err
+	}
 	if err := c.appsRepo.DeleteOneWhere(ctx.Context(), "id", app.ID); err != nil {
 		return err
 	}
 
+	// The volumes outlive the application, and can be attached to another one.
+	if err := c.volumesRepo.DetachAllFromApplication(ctx.Context(), app.ID); err != nil {
+		return err
+	}
+
 	return ctx.Redirect("/orgs/" + ctx.PathValue("orgId") + "/apps")
 }
@@ -39,6 +39,7 @@ func (c *CertsController) Index(ctx *caesar.Context) error {
 
 type StoreCertInput struct {
 	Domain string `form:"domain" validate:"required"`
+	Www    bool   `form:"www"`
 }
 
 func (c *CertsController) Store(ctx *caesar.Context) error {
@@ -52,7 +53,7 @@ func (c *CertsController) Store(ctx *caesar.Context) error {
 		return err
 	}
 
-	cert := &models.Certificate{Domain: data.Domain, ApplicationID: app.ID}
+	cert := &models.Certificate{Domain: data.Domain, Www: data.Www, ApplicationID: app.ID}
 
 	dnsEntries, err := c.driver.CreateCertificate(*app, *cert)
 	if err != nil {
@@ -70,6 +71,12 @@ func (c *CertsController) Store(ctx *caesar.Context) error {
 		return err
 	}
 
+	if cert.Status == models.CertificateStatusVerified {
+		if err := c.driver.ActivateCertificate(*app, *cert); err != nil {
+			return err
+		}
+	}
+
 	return ctx.RedirectBack()
 }
 
@@ -92,6 +99,10 @@ func (c *CertsController) Delete(ctx *caesar.Context) error {
 		return err
 	}
 
+	if err := c.driver.DeleteCertificate(*app, *cert); err != nil {
+		return err
+	}
+
 	return ctx.RedirectBack()
 }
 
@@ -110,6 +121,7 @@ func (c *CertsController) Check(ctx *caesar.Context) error {
 		return err
 	}
 
+	wasVerified := cert.Status == models.CertificateStatusVerified
 	if ok, _ := c.driver.CheckDnsConfig(*app, *cert); ok {
 		cert.Status = models.CertificateStatusVerified
 	} else {
@@ -120,5 +132,12 @@ func (c *CertsController) Check(ctx *caesar.Context) error {
 		return err
 	}
 
+	// Domains are routed as soon as they are verified, without waiting for the next deployment.
+	if !wasVerified && cert.Status == models.CertificateStatusVerified {
+		if err := c.driver.ActivateCertificate(*app, *cert); err != nil {
+			return err
+		}
+	}
+
 	return ctx.RedirectBack()
 }
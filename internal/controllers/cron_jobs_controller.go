@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"citadel/internal/services"
+	appsPages "citadel/views/concerns/apps/pages"
+	"errors"
+	"strconv"
+
+	caesar "github.com/caesar-rocks/core"
+	"github.com/caesar-rocks/ui/toast"
+)
+
+// cronJobRunsShown is the number of recent runs shown for each cron job, unless asked otherwise.
+const cronJobRunsShown = 10
+
+type CronJobsController struct {
+	appsService  *services.AppsService
+	cronJobsRepo *repositories.CronJobsRepository
+	runsRepo     *repositories.CronJobRunsRepository
+	scheduler    *services.CronScheduler
+}
+
+func NewCronJobsController(appsService *services.AppsService, cronJobsRepo *repositories.CronJobsRepository, runsRepo *repositories.CronJobRunsRepository, scheduler *services.CronScheduler) *CronJobsController {
+	return &CronJobsController{appsService, cronJobsRepo, runsRepo, scheduler}
+}
+
+func (c *CronJobsController) Index(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := c.cronJobsRepo.FindAllFromApplication(ctx.Context(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(jobs)
+	}
+
+	runs := make(map[string][]models.CronJobRun)
+	for _, job := range jobs {
+		runs[job.ID], err = c.runsRepo.FindLatestFromCronJob(ctx.Context(), job.ID, cronJobRunsShown)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Render(appsPages.CronJobsPage(*app, jobs, runs))
+}
+
+type StoreCronJobValidator struct {
+	Name     string `form:"name" json:"name" validate:"required,max=63"`
+	Schedule string `form:"schedule" json:"schedule" validate:"required"`
+	Command  string `form:"command" json:"command" validate:"required"`
+	Timeout  int    `form:"timeout" json:"timeout" validate:"omitempty,min=1,max=86400"`
+}
+
+func (c *CronJobsController) Store(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, errors, ok := caesar.Validate[StoreCronJobValidator](ctx)
+	if ok {
+		if _, err := models.ParseCronSchedule(data.Schedule); err != nil {
+			errors["Schedule"] = err.Error()
+			ok = false
+		}
+	}
+	if ok {
+		if existing, _ := c.cronJobsRepo.FindOneBy(ctx.Context(), "application_id", app.ID, "name", data.Name); existing != nil {
+			errors["Name"] = "A cron job with this name already exists."
+			ok = false
+		}
+	}
+	if !ok {
+		if ctx.WantsJSON() {
+			return ctx.SendJSON(errors, 400)
+		}
+		return ctx.Render(appsPages.CronJobForm(*app, errors))
+	}
+
+	job := &models.CronJob{
+		ApplicationID: app.ID,
+		Name:          data.Name,
+		Schedule:      data.Schedule,
+		Command:       data.Command,
+		Timeout:       data.Timeout,
+	}
+	if job.Timeout == 0 {
+		job.Timeout = models.DefaultCronJobTimeout
+	}
+	if err := c.cronJobsRepo.Create(ctx.Context(), job); err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(job)
+	}
+
+	toast.Success(ctx, "Cron job created successfully.")
+
+	return ctx.RedirectBack()
+}
+
+func (c *CronJobsController) Delete(ctx *caesar.Context) error {
+	job, err := c.findCronJob(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.runsRepo.DeleteAllFromCronJob(ctx.Context(), job.ID); err != nil {
+		return err
+	}
+	if err := c.cronJobsRepo.DeleteOneWhere(ctx.Context(), "id", job.ID); err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(job)
+	}
+
+	return ctx.RedirectBack()
+}
+
+// Run runs a cron job right away, out of its schedule.
+func (c *CronJobsController) Run(ctx *caesar.Context) error {
+	job, err := c.findCronJob(ctx)
+	if err != nil {
+		return err
+	}
+
+	run, err := c.scheduler.Run(ctx.Context(), *job)
+	if errors.Is(err, services.ErrCronJobRunning) {
+		return caesar.NewError(409)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(run)
+	}
+
+	toast.Success(ctx, "Cron job started.")
+
+	return ctx.RedirectBack()
+}
+
+// Runs lists the most recent runs of a cron job, from the most recent.
+func (c *CronJobsController) Runs(ctx *caesar.Context) error {
+	job, err := c.findCronJob(ctx)
+	if err != nil {
+		return err
+	}
+
+	limit := cronJobRunsShown
+	if value := ctx.Request.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			return caesar.NewError(400)
+		}
+	}
+
+	runs, err := c.runsRepo.FindLatestFromCronJob(ctx.Context(), job.ID, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.SendJSON(runs)
+}
+
+// findCronJob returns the cron job of the path, which must belong to the application of the path.
+// Its application is loaded along.
+func (c *CronJobsController) findCronJob(ctx *caesar.Context) (*models.CronJob, error) {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := c.cronJobsRepo.FindOneBy(
+		ctx.Context(),
+		"id", ctx.PathValue("id"),
+		"application_id", app.ID,
+	)
+	if err != nil {
+		return nil, caesar.NewError(404)
+	}
+	job.Application = app
+
+	return job, nil
+}
@@ -29,10 +29,12 @@ func (c *DatabasesController) Index(ctx *caesar.Context) error {
 }
 
 type StoreDatabaseValidator struct {
-	Name     string      `form:"name" validate:"required"`
-	DBMS     models.DBMS `form:"dbms" validate:"required,oneof=mysql postgres redis"`
-	Username string      `form:"username"`
-	Password string      `form:"password"`
+	Name      string      `form:"name" validate:"required"`
+	DBMS      models.DBMS `form:"dbms" validate:"required,oneof=mysql postgres redis"`
+	Username  string      `form:"username"`
+	Password  string      `form:"password"`
+	CpuConfig string      `form:"cpu_config"`
+	RamConfig string      `form:"ram_config"`
 }
 
 func (c *DatabasesController) Store(ctx *caesar.Context) error {
@@ -41,11 +43,23 @@ func (c *DatabasesController) Store(ctx *caesar.Context) error {
 		return ctx.Redirect("/orgs/" + ctx.PathValue("orgId") + "/databases")
 	}
 
+	if data.CpuConfig == "" {
+		data.CpuConfig = "shared-cpu-1x"
+	}
+	if data.RamConfig == "" {
+		data.RamConfig = "512MB"
+	}
+	if _, err := models.ParseComputingSpecs(data.CpuConfig, data.RamConfig); err != nil {
+		return ctx.Redirect("/orgs/" + ctx.PathValue("orgId") + "/databases")
+	}
+
 	db := &models.Database{
 		Name:           data.Name,
 		DBMS:           data.DBMS,
 		Username:       data.Username,
 		Password:       data.Password,
+		CpuConfig:      data.CpuConfig,
+		RamConfig:      data.RamConfig,
 		OrganizationID: ctx.PathValue("orgId"),
 		Host:           os.Getenv("DB_HOST"),
 	}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bytes"
+	"citadel/internal/drivers"
 	"citadel/internal/models"
 	"citadel/internal/repositories"
 	"citadel/internal/services"
@@ -13,6 +14,7 @@ import (
 	caesar "github.com/caesar-rocks/core"
 	"github.com/caesar-rocks/drive"
 	"github.com/caesar-rocks/events"
+	"github.com/rs/xid"
 )
 
 type DeploymentsController struct {
@@ -21,10 +23,11 @@ type DeploymentsController struct {
 	deplRepo    *repositories.DeploymentsRepository
 	drive       *drive.Drive
 	emitter     *events.EventsEmitter
+	driver      drivers.Driver
 }
 
-func NewDeploymentsController(appsService *services.AppsService, appsRepo *repositories.ApplicationsRepository, deplRepo *repositories.DeploymentsRepository, drive *drive.Drive, emitter *events.EventsEmitter) *DeploymentsController {
-	return &DeploymentsController{appsService: appsService, appsRepo: appsRepo, deplRepo: deplRepo, drive: drive, emitter: emitter}
+func NewDeploymentsController(appsService *services.AppsService, appsRepo *repositories.ApplicationsRepository, deplRepo *repositories.DeploymentsRepository, drive *drive.Drive, emitter *events.EventsEmitter, driver drivers.Driver) *DeploymentsController {
+	return &DeploymentsController{appsService: appsService, appsRepo: appsRepo, deplRepo: deplRepo, drive: drive, emitter: emitter, driver: driver}
 }
 
 func (c *DeploymentsController) Index(ctx *caesar.Context) error {
@@ -52,13 +55,31 @@ func (c *DeploymentsController) Store(ctx *caesar.Context) error {
 		return err
 	}
 
+	// The release command set in citadel.toml overrides the application's one.
+	releaseCommand := ctx.Request.FormValue("releaseCommand")
+	if releaseCommand == "" {
+		releaseCommand = app.ReleaseCommand
+	}
+
+	processes, err := services.ReadDeclaredProcessTypes(buf.Bytes())
+	if err != nil {
+		if ctx.WantsJSON() {
+			return ctx.SendJSON(map[string]string{"Processes": err.Error()}, 400)
+		}
+		return caesar.NewError(400)
+	}
+
 	depl := &models.Deployment{
-		Application:   app,
-		ApplicationID: app.ID,
-		Status:        models.DeploymentStatusBuilding,
-		Origin:        models.DeploymentOriginCli,
+		ID:             xid.New().String(),
+		Application:    app,
+		ApplicationID:  app.ID,
+		Status:         models.DeploymentStatusQueued,
+		Origin:         models.DeploymentOriginCli,
+		ReleaseCommand: releaseCommand,
+		Processes:      processes,
 	}
 
+	// The tarball is stored first, as the deployment may be started as soon as it is queued.
 	if err := c.drive.Use("s3").Put(depl.ID, buf.Bytes()); err != nil {
 		return err
 	}
@@ -73,6 +94,13 @@ func (c *DeploymentsController) Store(ctx *caesar.Context) error {
 	}
 	c.emitter.Emit("deployments.created", bytes)
 
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(map[string]any{
+			"deployment_id": depl.ID,
+			"healthcheck":   app.HasHealthCheck(),
+		})
+	}
+
 	return ctx.SendText("Deployment created")
 }
 
@@ -88,5 +116,119 @@ func (c *DeploymentsController) List(ctx *caesar.Context) error {
 		return err
 	}
 
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(depls)
+	}
+
 	return ctx.Render(appsPages.DeploymentsList(*app, depls))
 }
+
+func (c *DeploymentsController) Show(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	depl, err := c.deplRepo.FindOneBy(
+		ctx.Context(),
+		"id", ctx.PathValue("id"),
+		"application_id", app.ID,
+	)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(depl)
+	}
+
+	return ctx.Render(appsPages.DeploymentPage(*app, *depl))
+}
+
+// Rollback deploys again the image of a previous successful deployment, without rebuilding it.
+func (c *DeploymentsController) Rollback(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	target, err := c.deplRepo.FindOneBy(
+		ctx.Context(),
+		"id", ctx.PathValue("id"),
+		"application_id", app.ID,
+	)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	if !target.CanBeRolledBackTo() {
+		return caesar.NewError(400)
+	}
+
+	depl := &models.Deployment{
+		Application:   app,
+		ApplicationID: app.ID,
+		Status:        models.DeploymentStatusQueued,
+		Origin:        models.DeploymentOriginRollback,
+		CommitSHA:     target.CommitSHA,
+		ImageTag:      target.ImageTag,
+		Processes:     target.Processes,
+	}
+
+	// Rollbacks are queued like the other deployments, so that they never race with an ongoing rollout.
+	if err := c.deplRepo.Create(ctx.Context(), depl); err != nil {
+		return err
+	}
+
+	bytes, err := util.EncodeJSON(depl)
+	if err != nil {
+		return err
+	}
+	c.emitter.Emit("deployments.created", bytes)
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(depl)
+	}
+
+	return ctx.RedirectBack()
+}
+
+// Cancel stops the build, the release or the rollout of a deployment, and deletes its tarball.
+func (c *DeploymentsController) Cancel(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	depl, err := c.deplRepo.FindOneBy(
+		ctx.Context(),
+		"id", ctx.PathValue("id"),
+		"application_id", app.ID,
+	)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	if !depl.CanBeCanceled() {
+		return caesar.NewError(409)
+	}
+
+	if err := c.driver.CancelDeployment(*app, *depl); err != nil {
+		slog.Error("Failed to cancel deployment", "err", err, "deployment_id", depl.ID)
+		return caesar.NewError(500)
+	}
+	depl.Status = models.DeploymentStatusCanceled
+
+	// Rollbacks reuse an image, and have no tarball.
+	if depl.Origin != models.DeploymentOriginRollback {
+		if err := c.drive.Use("s3").Delete(depl.ID); err != nil {
+			slog.Warn("Failed to delete tarball", "err", err, "deployment_id", depl.ID)
+		}
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(depl)
+	}
+
+	return ctx.RedirectBack()
+}
@@ -46,3 +46,24 @@ func (c *EnvController) Update(ctx *caesar.Context) error {
 
 	return ctx.Render(appsPages.EnvForm(*app))
 }
+
+// UpdatePreview updates the variables overriding the application's ones in its previews.
+// They apply to the previews created from then on.
+func (c *EnvController) UpdatePreview(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx.Request.ParseForm()
+	env := ctx.Request.FormValue("preview_env")
+	app.PreviewEnv = []byte(env)
+
+	if err := c.repo.UpdateOneWhere(ctx.Context(), app, "id", app.ID); err != nil {
+		return caesar.NewError(500)
+	}
+
+	toast.Success(ctx, "Preview environment variables updated successfully.")
+
+	return ctx.Render(appsPages.PreviewEnvForm(*app))
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"bytes"
+	"citadel/internal/drivers"
+	execProtocol "citadel/internal/exec_protocol"
+	"citadel/internal/models"
+	"citadel/internal/services"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	caesar "github.com/caesar-rocks/core"
+	"golang.org/x/net/websocket"
+)
+
+// defaultShell starts bash if the image has it, and sh otherwise.
+const defaultShell = "command -v bash >/dev/null && exec bash || exec sh"
+
+type ExecController struct {
+	appsService *services.AppsService
+	driver      drivers.Driver
+}
+
+func NewExecController(appsService *services.AppsService, driver drivers.Driver) *ExecController {
+	return &ExecController{appsService, driver}
+}
+
+type ExecCommandValidator struct {
+	Command string `form:"command" json:"command" validate:"required"`
+}
+
+// Run runs a command in the application, and replies with its output once it exits.
+// The exit code is sent in the X-Exit-Code header.
+func (c *ExecController) Run(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, _, ok := caesar.Validate[ExecCommandValidator](ctx)
+	if !ok {
+		return caesar.NewError(400)
+	}
+
+	output := bytes.NewBuffer(nil)
+	exitCode, err := c.driver.Exec(ctx.Context(), *app, drivers.ExecOptions{
+		Cmd:    []string{"/bin/sh", "-c", data.Command},
+		Stdout: output,
+		Stderr: output,
+	})
+	if errors.Is(err, drivers.ErrNoRunningInstance) {
+		return caesar.NewError(404)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.SetHeader("X-Exit-Code", strconv.Itoa(exitCode))
+
+	return ctx.SendText(output.String())
+}
+
+// Attach runs a command in the application over a WebSocket connection (see execProtocol),
+// streaming its input and output. The "command" query parameter defaults to a shell,
+// and "tty", "cols" and "rows" allocate a terminal to it.
+func (c *ExecController) Attach(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := ctx.Request.URL.Query()
+
+	cmd := []string{"/bin/sh", "-c", defaultShell}
+	if command := query.Get("command"); command != "" {
+		cmd = []string{"/bin/sh", "-c", command}
+	}
+
+	opts := drivers.ExecOptions{Cmd: cmd, Tty: query.Get("tty") == "true"}
+	if cols, err := strconv.ParseUint(query.Get("cols"), 10, 32); err == nil {
+		opts.Size.Cols = uint(cols)
+	}
+	if rows, err := strconv.ParseUint(query.Get("rows"), 10, 32); err == nil {
+		opts.Size.Rows = uint(rows)
+	}
+
+	server := websocket.Server{
+		Handshake: checkWebSocketOrigin,
+		Handler: func(ws *websocket.Conn) {
+			c.serveExec(ws, *app, opts)
+		},
+	}
+	server.ServeHTTP(ctx.ResponseWriter, ctx.Request)
+
+	return nil
+}
+
+func (c *ExecController) serveExec(ws *websocket.Conn, app models.Application, opts drivers.ExecOptions) {
+	defer ws.Close()
+
+	execCtx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	resize := make(chan drivers.TerminalSize, 1)
+
+	go func() {
+		defer stdinWriter.Close()
+
+		for {
+			frame, err := execProtocol.Receive(ws)
+			if err != nil {
+				// The client went away.
+				cancel()
+				return
+			}
+
+			if frame.Control == nil {
+				if _, err := stdinWriter.Write(frame.Data); err != nil {
+					return
+				}
+				continue
+			}
+
+			switch frame.Control.Type {
+			case execProtocol.ControlMessageResize:
+				select {
+				case resize <- drivers.TerminalSize{Cols: frame.Control.Cols, Rows: frame.Control.Rows}:
+				default:
+				}
+			case execProtocol.ControlMessageStdinClosed:
+				stdinWriter.Close()
+			}
+		}
+	}()
+
+	opts.Stdin = stdinReader
+	opts.Stdout = execProtocol.NewWriter(ws)
+	opts.Stderr = opts.Stdout
+	opts.Resize = resize
+
+	exitCode, err := c.driver.Exec(execCtx, app, opts)
+
+	msg := execProtocol.ControlMessage{Type: execProtocol.ControlMessageExit, ExitCode: exitCode}
+	if errors.Is(err, drivers.ErrNoRunningInstance) {
+		msg.Error = "No running instance found."
+	} else if err != nil && execCtx.Err() == nil {
+		slog.Error("Failed to run command", "error", err, "app_id", app.ID)
+		msg.Error = "An error occurred while running the command."
+	}
+
+	execProtocol.SendControl(ws, msg)
+}
+
+// checkWebSocketOrigin accepts the connections of non-browser clients (e.g. the CLI), which don't send any origin,
+// and the ones from the same origin, so that other websites can't open connections on behalf of the user.
+func checkWebSocketOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if originURL.Host != req.Host {
+		return fmt.Errorf("origin not allowed: %s", origin)
+	}
+	config.Origin = originURL
+
+	return nil
+}
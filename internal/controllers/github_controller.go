@@ -1,29 +1,40 @@
 package controllers
 
 import (
+	"citadel/internal/drivers"
+	githubApp "citadel/internal/github_app"
 	"citadel/internal/models"
 	"citadel/internal/repositories"
+	"citadel/internal/services"
 	"citadel/internal/types"
+	"citadel/util"
 	appsPages "citadel/views/concerns/apps/pages"
-	"fmt"
-	"net/http"
+	"context"
+	"log/slog"
 	"os"
-
 	"strconv"
 	"strings"
 
-	"github.com/bradleyfalzon/ghinstallation"
 	"github.com/caesar-rocks/auth"
 	caesar "github.com/caesar-rocks/core"
+	"github.com/caesar-rocks/drive"
+	"github.com/caesar-rocks/events"
 	"github.com/google/go-github/v62/github"
+	"github.com/rs/xid"
 )
 
 type GithubController struct {
-	repo *repositories.UsersRepository
+	repo        *repositories.UsersRepository
+	appsRepo    *repositories.ApplicationsRepository
+	deplsRepo   *repositories.DeploymentsRepository
+	appsService *services.AppsService
+	drive       *drive.Drive
+	emitter     *events.EventsEmitter
+	driver      drivers.Driver
 }
 
-func NewGithubController(repo *repositories.UsersRepository) *GithubController {
-	return &GithubController{repo}
+func NewGithubController(repo *repositories.UsersRepository, appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.DeploymentsRepository, appsService *services.AppsService, drive *drive.Drive, emitter *events.EventsEmitter, driver drivers.Driver) *GithubController {
+	return &GithubController{repo, appsRepo, deplsRepo, appsService, drive, emitter, driver}
 }
 
 func (c *GithubController) HandleWebhook(ctx *caesar.Context) error {
@@ -40,6 +51,8 @@ func (c *GithubController) HandleWebhook(ctx *caesar.Context) error {
 	switch event := event.(type) {
 	case *github.PushEvent:
 		return c.processPushEvent(ctx, event)
+	case *github.PullRequestEvent:
+		return c.processPullRequestEvent(ctx, event)
 	case *github.InstallationEvent:
 		return c.processInstallationEvent(ctx, event)
 	}
@@ -48,26 +61,198 @@ func (c *GithubController) HandleWebhook(ctx *caesar.Context) error {
 }
 
 func (c *GithubController) processPushEvent(ctx *caesar.Context, event *github.PushEvent) error {
-	ghRepo := event.Repo.Owner.GetLogin() + "/" + event.Repo.GetName()
+	// Branch deletions don't point to any commit to deploy.
+	if event.GetDeleted() {
+		return nil
+	}
+
+	ghRepo := event.Repo.GetFullName()
+	branch := strings.TrimPrefix(event.GetRef(), "refs/heads/")
+
+	apps, err := c.appsRepo.FindAllFromGitHubRepository(ctx.Context(), ghRepo, branch)
+	if err != nil {
+		return err
+	}
+
+	// Downloading the tarballs takes a while, and GitHub gives up on the webhooks that don't answer within seconds,
+	// so the deployments are triggered in the background. An application failing to deploy doesn't hold up the others.
+	for _, app := range apps {
+		go func(app models.Application) {
+			if err := c.deployCommit(context.Background(), app, event.GetAfter()); err != nil {
+				slog.Error("Failed to deploy commit from GitHub", "error", err, "app_id", app.ID, "repository", ghRepo, "sha", event.GetAfter())
+			}
+		}(app)
+	}
+
+	return nil
+}
+
+// deployCommit downloads the given commit of the application's repository,
+// stores it in the S3 bucket and triggers a new deployment.
+func (c *GithubController) deployCommit(ctx context.Context, app models.Application, sha string) error {
+	client, err := githubApp.NewInstallationClient(app.GitHubInstallationID)
+	if err != nil {
+		return err
+	}
+
+	tarball, err := githubApp.DownloadTarball(ctx, client, app.GitHubRepository, sha)
+	if err != nil {
+		return err
+	}
 
-	// TODO: Retrrigger the deployment for the given repository.
-	fmt.Println("Push event received for repository:", ghRepo)
+	processes, err := services.ReadDeclaredProcessTypes(tarball)
+	if err != nil {
+		return err
+	}
 
-	// TODO: Check if the branches match
-	branch := strings.Replace(event.GetRef(), "refs/heads/", "", 1)
-	fmt.Println("Branch:", branch)
+	depl := &models.Deployment{
+		ID:             xid.New().String(),
+		Application:    &app,
+		ApplicationID:  app.ID,
+		Status:         models.DeploymentStatusQueued,
+		Origin:         models.DeploymentOriginGithub,
+		CommitSHA:      sha,
+		ReleaseCommand: app.ReleaseCommand,
+		Processes:      processes,
+	}
 
-	// TODO: Mark deploying (GitHub check on the commit)
+	// The tarball is stored first, as the deployment may be started as soon as it is queued.
+	if err := c.drive.Use("s3").Put(depl.ID, tarball); err != nil {
+		return err
+	}
 
-	// TODO: Download the commit, and put it in the S3 bucket
+	if err := c.deplsRepo.Create(ctx, depl); err != nil {
+		return err
+	}
 
-	// TODO: Create the deployment in the db
+	// Reporting the deployment on the commit is best-effort, and must not prevent it.
+	checkRunID, err := githubApp.CreateCheckRun(ctx, app, *depl)
+	if err != nil {
+		slog.Warn("Failed to create GitHub check run", "error", err, "deployment_id", depl.ID)
+	} else {
+		depl.GitHubCheckRunID = checkRunID
+		if err := c.deplsRepo.UpdateOneWhere(ctx, depl, "id", depl.ID); err != nil {
+			return err
+		}
+	}
 
-	// TODO: Ignite the builder
+	bytes, err := util.EncodeJSON(depl)
+	if err != nil {
+		return err
+	}
+	c.emitter.Emit("deployments.created", bytes)
 
 	return nil
 }
 
+// processPullRequestEvent deploys the pull requests opened against the branch of an application to previews of it,
+// and destroys them once the pull requests are closed. The failures are logged, and don't keep the other applications
+// of the repository from being processed.
+func (c *GithubController) processPullRequestEvent(ctx *caesar.Context, event *github.PullRequestEvent) error {
+	pr := event.GetPullRequest()
+	ghRepo := event.Repo.GetFullName()
+
+	apps, err := c.appsRepo.FindAllFromGitHubRepository(ctx.Context(), ghRepo, pr.GetBase().GetRef())
+	if err != nil {
+		return err
+	}
+
+	// Like the deployments of pushes, the previews are processed in the background, for GitHub not to give up on the webhook.
+	for _, app := range apps {
+		go func(app models.Application) {
+			switch event.GetAction() {
+			case "opened", "reopened", "synchronize":
+				if err := c.deployPreview(context.Background(), app, pr); err != nil {
+					slog.Error("Failed to deploy preview from GitHub", "error", err, "app_id", app.ID, "repository", ghRepo, "pull_request", pr.GetNumber())
+				}
+			case "closed":
+				if err := c.destroyPreview(context.Background(), app, pr.GetNumber()); err != nil {
+					slog.Error("Failed to destroy preview", "error", err, "app_id", app.ID, "repository", ghRepo, "pull_request", pr.GetNumber())
+				}
+			}
+		}(app)
+	}
+
+	return nil
+}
+
+// deployPreview deploys the head commit of a pull request to the preview of the application for it,
+// which is created on the first push along with a comment giving its URL.
+// The preview gets its own subdomain, and a copy of the application's environment, with its preview overrides.
+// The pull requests from forks are left out, as their code would run with the application's secrets.
+func (c *GithubController) deployPreview(ctx context.Context, app models.Application, pr *github.PullRequest) error {
+	if pr.GetHead().GetRepo().GetFullName() != pr.GetBase().GetRepo().GetFullName() {
+		slog.Info("Skipped preview of pull request from a fork", "app_id", app.ID, "pull_request", pr.GetNumber(), "head_repository", pr.GetHead().GetRepo().GetFullName())
+		return nil
+	}
+
+	preview, err := c.appsRepo.FindPreview(ctx, app.ID, pr.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	created := false
+	if preview == nil {
+		env, err := app.GetPreviewEnv()
+		if err != nil {
+			return err
+		}
+
+		preview = &models.Application{
+			OrganizationID: app.OrganizationID,
+			Name:           app.Name + "-pr-" + strconv.Itoa(pr.GetNumber()),
+			ReleaseCommand: app.ReleaseCommand,
+			Env:            env,
+			CpuConfig:      app.CpuConfig,
+			RamConfig:      app.RamConfig,
+
+			HealthCheckPath:               app.HealthCheckPath,
+			HealthCheckPort:               app.HealthCheckPort,
+			HealthCheckInterval:           app.HealthCheckInterval,
+			HealthCheckTimeout:            app.HealthCheckTimeout,
+			HealthCheckHealthyThreshold:   app.HealthCheckHealthyThreshold,
+			HealthCheckUnhealthyThreshold: app.HealthCheckUnhealthyThreshold,
+
+			GitHubInstallationID: app.GitHubInstallationID,
+			GitHubRepository:     app.GitHubRepository,
+			GitHubBranch:         pr.GetHead().GetRef(),
+
+			ParentApplicationID: app.ID,
+			PullRequestNumber:   pr.GetNumber(),
+		}
+		if err := c.appsRepo.Create(ctx, preview); err != nil {
+			return err
+		}
+		if err := c.driver.CreateApplication(*preview); err != nil {
+			return err
+		}
+		created = true
+	}
+
+	if err := c.deployCommit(ctx, *preview, pr.GetHead().GetSHA()); err != nil {
+		return err
+	}
+
+	// Commenting on the pull request is best-effort, like the check runs.
+	if created {
+		if err := githubApp.CommentPreviewURL(ctx, *preview); err != nil {
+			slog.Warn("Failed to comment on GitHub pull request", "error", err, "app_id", preview.ID, "pull_request", preview.PullRequestNumber)
+		}
+	}
+
+	return nil
+}
+
+// destroyPreview destroys the preview of the application for a pull request, if any.
+func (c *GithubController) destroyPreview(ctx context.Context, app models.Application, pullRequestNumber int) error {
+	preview, err := c.appsRepo.FindPreview(ctx, app.ID, pullRequestNumber)
+	if err != nil || preview == nil {
+		return err
+	}
+
+	return c.appsService.DeletePreview(ctx, *preview)
+}
+
 func (c *GithubController) processInstallationEvent(ctx *caesar.Context, event *github.InstallationEvent) error {
 	user, _ := c.repo.FindOneBy(ctx.Context(), "github_user_id", event.Sender.ID)
 	if user == nil {
@@ -98,21 +283,14 @@ func (c *GithubController) ListRepositories(ctx *caesar.Context) error {
 		return err
 	}
 
-	githubAppId, err := strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
-	if err != nil {
-		return err
-	}
-
 	for _, installationID := range user.GetGitHubInstallationIDs() {
 		ownerAdded := false
 
-		itr, err := ghinstallation.NewKeyFromFile(http.DefaultTransport, githubAppId, installationID, os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"))
+		client, err := githubApp.NewInstallationClient(installationID)
 		if err != nil {
 			return err
 		}
 
-		client := github.NewClient(&http.Client{Transport: itr})
-
 		opt := &github.ListOptions{}
 		for {
 			repos, resp, err := client.Apps.ListRepos(ctx.Context(), opt)
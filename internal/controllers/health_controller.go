@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"citadel/internal/drivers"
+	dockerDriver "citadel/internal/drivers/docker_driver"
+
+	caesar "github.com/caesar-rocks/core"
+)
+
+type HealthController struct {
+	driver drivers.Driver
+}
+
+func NewHealthController(driver drivers.Driver) *HealthController {
+	return &HealthController{driver}
+}
+
+// healthStatus is what the health endpoint returns.
+type healthStatus struct {
+	Healthy bool `json:"healthy"`
+
+	// EventsWatcher is the health of the watching of the Docker events, when the Docker driver is used.
+	EventsWatcher *dockerDriver.EventsWatcherHealth `json:"events_watcher,omitempty"`
+}
+
+// Show tells whether Citadel works properly, responding with a 503 status when it doesn't,
+// e.g. when the Docker events can't be received anymore.
+func (c *HealthController) Show(ctx *caesar.Context) error {
+	status := healthStatus{Healthy: true}
+
+	if watcher, ok := c.driver.(interface {
+		EventsWatcherHealth() dockerDriver.EventsWatcherHealth
+	}); ok {
+		health := watcher.EventsWatcherHealth()
+		status.EventsWatcher = &health
+		status.Healthy = health.Connected
+	}
+
+	if !status.Healthy {
+		return ctx.SendJSON(status, 503)
+	}
+	return ctx.SendJSON(status)
+}
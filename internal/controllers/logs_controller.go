@@ -2,56 +2,177 @@ package controllers
 
 import (
 	"citadel/internal/drivers"
+	"citadel/internal/models"
 	"citadel/internal/repositories"
+	"citadel/internal/services"
 	appsPages "citadel/views/concerns/apps/pages"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	caesar "github.com/caesar-rocks/core"
 )
 
 type LogsController struct {
-	driver   drivers.Driver
-	appsRepo *repositories.ApplicationsRepository
+	driver           drivers.Driver
+	appsService      *services.AppsService
+	deplsRepo        *repositories.DeploymentsRepository
+	logsRepo         *repositories.LogEntriesRepository
+	processTypesRepo *repositories.ProcessTypesRepository
 }
 
-func NewLogsController(driver drivers.Driver, appsRepo *repositories.ApplicationsRepository) *LogsController {
-	return &LogsController{driver, appsRepo}
+func NewLogsController(
+	driver drivers.Driver,
+	appsService *services.AppsService,
+	deplsRepo *repositories.DeploymentsRepository,
+	logsRepo *repositories.LogEntriesRepository,
+	processTypesRepo *repositories.ProcessTypesRepository,
+) *LogsController {
+	return &LogsController{driver, appsService, deplsRepo, logsRepo, processTypesRepo}
 }
 
 func (c *LogsController) Index(ctx *caesar.Context) error {
-	// user, err := auth.RetrieveUserFromCtx[models.User](ctx)
-	// if err != nil {
-	// 	return err
-	// }
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return caesar.NewError(404)
+	}
 
-	app, err := c.appsRepo.FindOneBy(ctx.Context(), "slug", ctx.PathValue("slug"))
+	depls, err := c.deplsRepo.FindAllFromApplication(ctx.Context(), app.ID)
 	if err != nil {
-		return caesar.NewError(400)
+		return err
 	}
 
-	// if app.UserID != user.ID {
-	// 	return caesar.NewError(403)
-	// }
+	processes, err := c.processTypesRepo.FindAllFromApplication(ctx.Context(), app.ID)
+	if err != nil {
+		return err
+	}
+	processNames := []string{models.ProcessTypeWeb}
+	for _, process := range processes {
+		processNames = append(processNames, process.Name)
+	}
 
-	return ctx.Render(appsPages.LogsPage(*app))
+	return ctx.Render(appsPages.LogsPage(*app, depls, processNames))
 }
 
+// Stream streams the logs of the application as server-sent events.
+// The "scope" query parameter selects the logs of the application (app, the default) or of a build (builder),
+// and "deployment" the deployment they belong to, which defaults to the latest one for builds.
+// The lines are sent as "log" events, whose data is a JSON models.LogEvent, or the line as it is with "format=text".
 func (c *LogsController) Stream(ctx *caesar.Context) error {
-	// user, err := auth.RetrieveUserFromCtx[models.User](ctx)
-	// if err != nil {
-	// 	return err
-	// }
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	params := ctx.Request.URL.Query()
 
-	app, err := c.appsRepo.FindOneBy(ctx.Context(), "slug", ctx.PathValue("slug"))
+	opts := drivers.StreamLogsOptions{
+		Scope:     drivers.LogsScopeApp,
+		PlainText: params.Get("format") == "text",
+	}
+	if scope := params.Get("scope"); scope != "" {
+		opts.Scope = drivers.LogsScope(scope)
+	}
+	if opts.Scope != drivers.LogsScopeApp && opts.Scope != drivers.LogsScopeBuilder {
+		return caesar.NewError(400)
+	}
+
+	if deploymentId := params.Get("deployment"); deploymentId != "" {
+		depl, err := c.deplsRepo.FindOneBy(ctx.Context(), "id", deploymentId, "application_id", app.ID)
+		if err != nil {
+			return caesar.NewError(404)
+		}
+		opts.Deployment = depl
+	} else if opts.Scope == drivers.LogsScopeBuilder {
+		depls, err := c.deplsRepo.FindAllFromApplication(ctx.Context(), app.ID)
+		if err != nil {
+			return err
+		}
+		if len(depls) == 0 {
+			return caesar.NewError(404)
+		}
+		opts.Deployment = &depls[0]
+	}
+
+	ctx.SetSSEHeaders()
+
+	return c.driver.StreamLogs(ctx, *app, opts)
+}
+
+// Search returns the stored logs of the application, across its deployments.
+// The results can be filtered with the "q" (case-insensitive text), "since", "until", "deployment", "source", "process",
+// "stream", "level" (the structured lines of this level or above) and "limit" query parameters. "since" and "until" are either RFC 3339 times, or durations before now (e.g. "15m", "7d").
+// Text searches cover repositories.MaxLogTextSearchPeriod at most, and are rejected over longer periods.
+func (c *LogsController) Search(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	params := ctx.Request.URL.Query()
+	now := time.Now()
+
+	query := repositories.LogEntriesQuery{
+		ApplicationID: app.ID,
+		DeploymentID:  params.Get("deployment"),
+		Source:        models.LogEntrySource(params.Get("source")),
+		Process:       params.Get("process"),
+		Stream:        models.LogEntryStream(params.Get("stream")),
+		Text:          params.Get("q"),
+		Level:         models.LogLevel(params.Get("level")),
+	}
+	if query.Level != "" && query.Level.Severity() < 0 {
+		return caesar.NewError(400)
+	}
+	if query.Since, err = parseLogTime(params.Get("since"), now); err != nil {
+		return caesar.NewError(400)
+	}
+	if query.Until, err = parseLogTime(params.Get("until"), now); err != nil {
+		return caesar.NewError(400)
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return caesar.NewError(400)
+		}
+	}
+
+	entries, err := c.logsRepo.Search(ctx.Context(), query)
+	if errors.Is(err, repositories.ErrLogTextSearchPeriodTooLong) {
+		if ctx.WantsJSON() {
+			return ctx.SendJSON(map[string]string{"Text": err.Error()}, 400)
+		}
+		return ctx.Render(appsPages.LogSearchError(err.Error()))
+	}
 	if err != nil {
 		return err
 	}
 
-	// TODO: Implement this
-	// if app.UserID != user.ID {
-	// 	return caesar.NewError(403)
-	// }
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(entries)
+	}
+
+	return ctx.Render(appsPages.LogSearchResults(entries))
+}
 
-	ctx.SetSSEHeaders()
+// parseLogTime parses a time given as a duration before now, which may be expressed in days (e.g. "7d"),
+// as a RFC 3339 time, or as the value of a datetime-local input (in UTC). An empty value gives the zero time.
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
 
-	return c.driver.StreamLogs(ctx, *app)
+	return time.Parse("2006-01-02T15:04", value)
 }
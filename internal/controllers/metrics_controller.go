@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"citadel/internal/services"
+	appsPages "citadel/views/concerns/apps/pages"
+	"errors"
+	"time"
+
+	caesar "github.com/caesar-rocks/core"
+)
+
+// defaultMetricsSince is the period whose metrics are returned when none is given.
+const defaultMetricsSince = "1h"
+
+type MetricsController struct {
+	driver           drivers.Driver
+	appsService      *services.AppsService
+	dbsRepo          *repositories.DatabasesRepository
+	processTypesRepo *repositories.ProcessTypesRepository
+}
+
+func NewMetricsController(
+	driver drivers.Driver,
+	appsService *services.AppsService,
+	dbsRepo *repositories.DatabasesRepository,
+	processTypesRepo *repositories.ProcessTypesRepository,
+) *MetricsController {
+	return &MetricsController{driver, appsService, dbsRepo, processTypesRepo}
+}
+
+// Show shows the resource usage of the application over the period given by the "since" and "until" query parameters,
+// which default to the last hour, as graphs, or returns it as JSON. The "process" query parameter restricts it to a process type.
+func (c *MetricsController) Show(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	query, err := parseMetricsQuery(ctx)
+	if err != nil {
+		return caesar.NewError(400)
+	}
+	query.ResourceType = models.MetricResourceApplication
+	query.ResourceID = app.ID
+	query.Process = ctx.Request.URL.Query().Get("process")
+
+	samples, err := c.driver.Metrics(ctx.Context(), query)
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(samples)
+	}
+
+	processes, err := c.processTypesRepo.FindAllFromApplication(ctx.Context(), app.ID)
+	if err != nil {
+		return err
+	}
+	processNames := []string{models.ProcessTypeWeb}
+	for _, process := range processes {
+		processNames = append(processNames, process.Name)
+	}
+
+	since := ctx.Request.URL.Query().Get("since")
+	if since == "" {
+		since = defaultMetricsSince
+	}
+
+	return ctx.Render(appsPages.MetricsPage(*app, processNames, samples, since, query.Process))
+}
+
+// ShowDatabase returns the resource usage of the database as JSON, over the same period as Show.
+func (c *MetricsController) ShowDatabase(ctx *caesar.Context) error {
+	db, err := c.dbsRepo.FindOneBy(
+		ctx.Context(),
+		"slug", ctx.PathValue("slug"),
+		"organization_id", ctx.PathValue("orgId"),
+	)
+	if err != nil {
+		return caesar.NewError(404)
+	}
+
+	query, err := parseMetricsQuery(ctx)
+	if err != nil {
+		return caesar.NewError(400)
+	}
+	query.ResourceType = models.MetricResourceDatabase
+	query.ResourceID = db.ID
+
+	samples, err := c.driver.Metrics(ctx.Context(), query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.SendJSON(samples)
+}
+
+// parseMetricsQuery reads the period of the "since" and "until" query parameters, given like the ones of the log search.
+func parseMetricsQuery(ctx *caesar.Context) (drivers.MetricsQuery, error) {
+	params := ctx.Request.URL.Query()
+	now := time.Now()
+	query := drivers.MetricsQuery{Until: now}
+
+	since := params.Get("since")
+	if since == "" {
+		since = defaultMetricsSince
+	}
+	var err error
+	if query.Since, err = parseLogTime(since, now); err != nil {
+		return query, err
+	}
+	if until := params.Get("until"); until != "" {
+		if query.Until, err = parseLogTime(until, now); err != nil {
+			return query, err
+		}
+	}
+	if !query.Since.Before(query.Until) {
+		return query, errors.New("the period must end after it starts")
+	}
+
+	return query, nil
+}
@@ -99,7 +99,9 @@ func (c *OrganizationsController) Edit(ctx *caesar.Context) error {
 }
 
 type UpdateOrgValidator struct {
-	Name string `form:"name" validate:"required,min=3"`
+	Name                string `form:"name" validate:"required,min=3"`
+	LogRetentionDays    int    `form:"log_retention_days" validate:"required,min=1,max=90"`
+	MaxConcurrentBuilds int    `form:"max_concurrent_builds" validate:"required,min=1,max=10"`
 }
 
 func (c *OrganizationsController) Update(ctx *caesar.Context) error {
@@ -116,6 +118,8 @@ func (c *OrganizationsController) Update(ctx *caesar.Context) error {
 
 	// Update the organization
 	org.Name = data.Name
+	org.LogRetentionDays = data.LogRetentionDays
+	org.MaxConcurrentBuilds = data.MaxConcurrentBuilds
 	if err := c.orgsRepo.UpdateOneWhere(ctx.Request.Context(), org, "id", org.ID); err != nil {
 		return err
 	}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"citadel/internal/services"
+	appsPages "citadel/views/concerns/apps/pages"
+	"fmt"
+
+	caesar "github.com/caesar-rocks/core"
+	"github.com/caesar-rocks/ui/toast"
+)
+
+type ProcessesController struct {
+	appsService      *services.AppsService
+	processTypesRepo *repositories.ProcessTypesRepository
+	deplRepo         *repositories.DeploymentsRepository
+	driver           drivers.Driver
+}
+
+func NewProcessesController(appsService *services.AppsService, processTypesRepo *repositories.ProcessTypesRepository, deplRepo *repositories.DeploymentsRepository, driver drivers.Driver) *ProcessesController {
+	return &ProcessesController{appsService, processTypesRepo, deplRepo, driver}
+}
+
+// Index lists the process types of the application, starting with the web one.
+func (c *ProcessesController) Index(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	processes, err := c.processTypesRepo.FindAllFromApplication(ctx.Context(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	// The web process type is the application itself, which runs the web command
+	// of its current deployment, if it declares one.
+	web := models.ProcessType{
+		Name:          models.ProcessTypeWeb,
+		Replicas:      app.GetReplicas(),
+		CpuConfig:     app.CpuConfig,
+		RamConfig:     app.RamConfig,
+		ApplicationID: app.ID,
+	}
+	depl, err := c.deplRepo.FindLatestSuccessfulFromApplication(ctx.Context(), app.ID)
+	if err != nil {
+		return err
+	}
+	if depl != nil {
+		web.Command = depl.WebCommand()
+	}
+	processes = append([]models.ProcessType{web}, processes...)
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(processes)
+	}
+
+	return ctx.Render(appsPages.ProcessesPage(*app, processes))
+}
+
+type ScaleProcessTypeValidator struct {
+	Replicas  int    `form:"replicas" json:"replicas" validate:"min=0"`
+	CpuConfig string `form:"cpu_config" json:"cpu_config"`
+	RamConfig string `form:"ram_config" json:"ram_config"`
+}
+
+// Scale sets the number of instances of a process type other than the web one, which may be zero,
+// and optionally its own computing specs. The web one is scaled along with the application.
+func (c *ProcessesController) Scale(ctx *caesar.Context) error {
+	app, err := c.appsService.GetAppOwnedByCurrentOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	process, err := c.processTypesRepo.FindOneFromApplication(ctx.Context(), app.ID, ctx.PathValue("name"))
+	if err != nil {
+		return err
+	}
+	if process == nil {
+		return caesar.NewError(404)
+	}
+
+	data, errors, ok := caesar.Validate[ScaleProcessTypeValidator](ctx)
+	if ok && data.Replicas > models.MaxReplicas {
+		errors["Replicas"] = fmt.Sprintf("A process type can run at most %d instances.", models.MaxReplicas)
+		ok = false
+	}
+	if ok && (data.CpuConfig != "" || data.RamConfig != "") {
+		if _, err := models.ParseComputingSpecs(data.CpuConfig, data.RamConfig); err != nil {
+			errors["CpuConfig"] = err.Error()
+			ok = false
+		}
+	}
+	if !ok {
+		if ctx.WantsJSON() {
+			return ctx.SendJSON(errors, 400)
+		}
+		return ctx.Render(appsPages.ProcessScaleForm(*app, *process, errors))
+	}
+
+	process.Replicas = data.Replicas
+	process.CpuConfig = data.CpuConfig
+	process.RamConfig = data.RamConfig
+	if err := c.processTypesRepo.UpdateOneWhere(ctx.Context(), process, "id", process.ID); err != nil {
+		return err
+	}
+
+	if err := c.driver.ScaleProcessType(*app, *process); err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(process)
+	}
+
+	toast.Success(ctx, "Process type scaled successfully.")
+
+	return ctx.Render(appsPages.ProcessScaleForm(*app, *process, nil))
+}
@@ -0,0 +1,381 @@
+package controllers
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"citadel/internal/services"
+	volumesPages "citadel/views/concerns/volumes/pages"
+	"errors"
+
+	caesarAuth "github.com/caesar-rocks/auth"
+	caesar "github.com/caesar-rocks/core"
+	"github.com/caesar-rocks/ui/toast"
+)
+
+type VolumesController struct {
+	volumesRepo    *repositories.VolumesRepository
+	snapshotsRepo  *repositories.VolumeSnapshotsRepository
+	appsRepo       *repositories.ApplicationsRepository
+	orgMembersRepo *repositories.OrganizationMembersRepository
+	volumesService *services.VolumesService
+	driver         drivers.Driver
+}
+
+func NewVolumesController(volumesRepo *repositories.VolumesRepository, snapshotsRepo *repositories.VolumeSnapshotsRepository, appsRepo *repositories.ApplicationsRepository, orgMembersRepo *repositories.OrganizationMembersRepository, volumesService *services.VolumesService, driver drivers.Driver) *VolumesController {
+	return &VolumesController{volumesRepo, snapshotsRepo, appsRepo, orgMembersRepo, volumesService, driver}
+}
+
+func (c *VolumesController) Index(ctx *caesar.Context) error {
+	volumes, err := c.volumesRepo.FindAllFromOrg(ctx.Context(), ctx.PathValue("orgId"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(volumes)
+	}
+
+	apps, err := c.appsRepo.FindAllFromOrg(ctx.Context(), ctx.PathValue("orgId"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Render(volumesPages.IndexPage(volumes, apps))
+}
+
+type StoreVolumeValidator struct {
+	Name        string `form:"name" json:"name" validate:"required,min=3,lowercase"`
+	Size        int    `form:"size" json:"size" validate:"required,min=1,max=500"`
+	MountPath   string `form:"mount_path" json:"mount_path" validate:"required"`
+	Application string `form:"application" json:"application"`
+}
+
+// Store creates a volume, and attaches it to the given application, if any.
+func (c *VolumesController) Store(ctx *caesar.Context) error {
+	data, errors, ok := caesar.Validate[StoreVolumeValidator](ctx)
+
+	volume := &models.Volume{
+		Name:           data.Name,
+		Size:           data.Size,
+		MountPath:      data.MountPath,
+		OrganizationID: ctx.PathValue("orgId"),
+	}
+	if ok {
+		ok = c.validateAttachment(ctx, volume, data.Application, errors)
+	}
+	if !ok {
+		if ctx.WantsJSON() {
+			return ctx.SendJSON(errors, 400)
+		}
+		apps, err := c.appsRepo.FindAllFromOrg(ctx.Context(), ctx.PathValue("orgId"))
+		if err != nil {
+			return err
+		}
+		return ctx.Render(volumesPages.VolumeForm(apps, errors))
+	}
+
+	if err := c.volumesRepo.Create(ctx.Context(), volume); err != nil {
+		return err
+	}
+
+	if err := c.driver.CreateVolume(*volume); err != nil {
+		c.volumesRepo.DeleteOneWhere(ctx.Context(), "id", volume.ID)
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(volume)
+	}
+
+	return ctx.Redirect("/orgs/" + ctx.PathValue("orgId") + "/volumes/" + volume.Slug)
+}
+
+// Show shows a volume, along with its usage and its snapshots.
+func (c *VolumesController) Show(ctx *caesar.Context) error {
+	volume, err := c.findVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage, err := c.driver.GetVolumeUsage(*volume)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := c.snapshotsRepo.FindAllFromVolume(ctx.Context(), volume.ID)
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(map[string]any{
+			"volume":    volume,
+			"usage":     usage,
+			"snapshots": snapshots,
+		})
+	}
+
+	apps, err := c.appsRepo.FindAllFromOrg(ctx.Context(), ctx.PathValue("orgId"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Render(volumesPages.ShowPage(*volume, usage, snapshots, apps))
+}
+
+type AttachVolumeValidator struct {
+	Application string `form:"application" json:"application" validate:"required"`
+	MountPath   string `form:"mount_path" json:"mount_path"`
+}
+
+// Attach attaches a volume to an application, at its mount path unless another one is given.
+// The volume is mounted in the containers of the application from its next deployment.
+func (c *VolumesController) Attach(ctx *caesar.Context) error {
+	volume, err := c.findVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, errors, ok := caesar.Validate[AttachVolumeValidator](ctx)
+	if ok {
+		if data.MountPath != "" {
+			volume.MountPath = data.MountPath
+		}
+		ok = c.validateAttachment(ctx, volume, data.Application, errors)
+	}
+	if !ok {
+		if ctx.WantsJSON() {
+			return ctx.SendJSON(errors, 400)
+		}
+		apps, err := c.appsRepo.FindAllFromOrg(ctx.Context(), ctx.PathValue("orgId"))
+		if err != nil {
+			return err
+		}
+		return ctx.Render(volumesPages.AttachForm(*volume, apps, errors))
+	}
+
+	if err := c.volumesRepo.UpdateOneWhere(ctx.Context(), volume, "id", volume.ID); err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(volume)
+	}
+
+	toast.Success(ctx, "Volume attached successfully. It will be mounted from the next deployment.")
+
+	return ctx.RedirectBack()
+}
+
+// Detach detaches a volume from its application. It stays mounted in the containers of the application
+// until its next deployment.
+func (c *VolumesController) Detach(ctx *caesar.Context) error {
+	volume, err := c.findVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.volumesRepo.Detach(ctx.Context(), volume.ID); err != nil {
+		return err
+	}
+	volume.ApplicationID = ""
+	volume.Application = nil
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(volume)
+	}
+
+	toast.Success(ctx, "Volume detached successfully. It will be unmounted from the next deployment.")
+
+	return ctx.RedirectBack()
+}
+
+// Delete removes a volume, along with its content and its snapshots. The volumes attached to an application
+// must be detached first.
+func (c *VolumesController) Delete(ctx *caesar.Context) error {
+	volume, err := c.findVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	if volume.ApplicationID != "" {
+		return caesar.NewError(409)
+	}
+
+	err = c.volumesService.Delete(ctx.Context(), *volume)
+	if errors.Is(err, services.ErrVolumeBusy) {
+		return caesar.NewError(409)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(volume)
+	}
+
+	return ctx.Redirect("/orgs/" + ctx.PathValue("orgId") + "/volumes")
+}
+
+// Snapshot starts a snapshot of a volume, taken in the background.
+func (c *VolumesController) Snapshot(ctx *caesar.Context) error {
+	volume, err := c.findVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := c.volumesService.Snapshot(ctx.Context(), *volume)
+	if errors.Is(err, services.ErrVolumeBusy) {
+		return caesar.NewError(409)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(snapshot)
+	}
+
+	toast.Success(ctx, "Snapshot started.")
+
+	return ctx.RedirectBack()
+}
+
+// Restore starts replacing the content of a volume with the one of a completed snapshot, in the background.
+func (c *VolumesController) Restore(ctx *caesar.Context) error {
+	volume, snapshot, err := c.findSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Status != models.VolumeSnapshotStatusCompleted {
+		return caesar.NewError(409)
+	}
+
+	err = c.volumesService.Restore(*volume, *snapshot)
+	if errors.Is(err, services.ErrVolumeBusy) {
+		return caesar.NewError(409)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(snapshot)
+	}
+
+	toast.Success(ctx, "Restore started.")
+
+	return ctx.RedirectBack()
+}
+
+func (c *VolumesController) DeleteSnapshot(ctx *caesar.Context) error {
+	volume, snapshot, err := c.findSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Status == models.VolumeSnapshotStatusPending {
+		return caesar.NewError(409)
+	}
+
+	if err := c.volumesService.DeleteSnapshot(ctx.Context(), *volume, *snapshot); err != nil {
+		return err
+	}
+
+	if ctx.WantsJSON() {
+		return ctx.SendJSON(snapshot)
+	}
+
+	return ctx.RedirectBack()
+}
+
+// validateAttachment checks the mount path of a volume and, when an application is given by slug, that it
+// belongs to the organization of the path and has no other volume at this mount path. The volume is then
+// attached to it. The errors are added to the given ones, and false is returned if there are any.
+func (c *VolumesController) validateAttachment(ctx *caesar.Context, volume *models.Volume, appSlug string, errors map[string]string) bool {
+	if err := models.ValidateMountPath(volume.MountPath); err != nil {
+		errors["MountPath"] = err.Error()
+		return false
+	}
+	if appSlug == "" {
+		return true
+	}
+
+	app, err := c.appsRepo.FindOneBy(ctx.Context(), "slug", appSlug, "organization_id", ctx.PathValue("orgId"))
+	if err != nil {
+		errors["Application"] = "This application doesn't exist."
+		return false
+	}
+
+	attached, err := c.volumesRepo.FindAllFromApplication(ctx.Context(), app.ID)
+	if err != nil {
+		errors["Application"] = err.Error()
+		return false
+	}
+	for _, other := range attached {
+		if other.ID != volume.ID && other.MountPath == volume.MountPath {
+			errors["MountPath"] = "Another volume is mounted at this path in this application."
+			return false
+		}
+	}
+
+	volume.ApplicationID = app.ID
+	volume.Application = app
+	return true
+}
+
+// findVolume returns the volume of the path, which must belong to the organization of the path,
+// of which the current user must be a member. Its application, if any, is loaded along.
+func (c *VolumesController) findVolume(ctx *caesar.Context) (*models.Volume, error) {
+	user, err := caesarAuth.RetrieveUserFromCtx[models.User](ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	isMember, err := c.orgMembersRepo.IsMember(ctx.Context(), ctx.PathValue("orgId"), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, caesar.NewError(404)
+	}
+
+	volume, err := c.volumesRepo.FindOneBy(
+		ctx.Context(),
+		"slug", ctx.PathValue("slug"),
+		"organization_id", ctx.PathValue("orgId"),
+	)
+	if err != nil {
+		return nil, caesar.NewError(404)
+	}
+
+	if volume.ApplicationID != "" {
+		volume.Application, err = c.appsRepo.FindOneBy(ctx.Context(), "id", volume.ApplicationID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return volume, nil
+}
+
+// findSnapshot returns the volume of the path, and its snapshot of the path.
+func (c *VolumesController) findSnapshot(ctx *caesar.Context) (*models.Volume, *models.VolumeSnapshot, error) {
+	volume, err := c.findVolume(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot, err := c.snapshotsRepo.FindOneBy(
+		ctx.Context(),
+		"id", ctx.PathValue("id"),
+		"volume_id", volume.ID,
+	)
+	if err != nil {
+		return nil, nil, caesar.NewError(404)
+	}
+
+	return volume, snapshot, nil
+}
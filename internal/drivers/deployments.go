@@ -0,0 +1,161 @@
+package drivers
+
+import (
+	githubApp "citadel/internal/github_app"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	caesar "github.com/caesar-rocks/core"
+)
+
+// ErrDeploymentCanceled is returned when carrying on with a deployment that has been canceled.
+var ErrDeploymentCanceled = errors.New("deployment canceled")
+
+// BuilderPollInterval is how often a deployment is checked while its build logs wait for its builder.
+const BuilderPollInterval = time.Second
+
+// DeploymentTracker walks the deployments through their statuses on behalf of a driver, and keeps the canceled
+// ones from being carried on with. It also serves and stores their logs, which the drivers collect.
+type DeploymentTracker struct {
+	DeplsRepo        *repositories.DeploymentsRepository
+	LogsRepo         *repositories.LogEntriesRepository
+	ProcessTypesRepo *repositories.ProcessTypesRepository
+
+	// canceling holds the IDs of the deployments being canceled, until their canceled status is stored.
+	canceling sync.Map
+}
+
+func NewDeploymentTracker(deplsRepo *repositories.DeploymentsRepository, logsRepo *repositories.LogEntriesRepository, processTypesRepo *repositories.ProcessTypesRepository) *DeploymentTracker {
+	return &DeploymentTracker{DeplsRepo: deplsRepo, LogsRepo: logsRepo, ProcessTypesRepo: processTypesRepo}
+}
+
+// UpdateStatus persists the new status of a deployment, and reports it on the GitHub commit the deployment
+// was triggered by, if any. Canceled deployments keep their status, and ErrDeploymentCanceled is returned.
+// Once a deployment succeeds, the process types of its application become the ones it declares.
+func (t *DeploymentTracker) UpdateStatus(depl *models.Deployment, status models.DeploymentStatus) error {
+	if t.IsCanceled(*depl) && status != models.DeploymentStatusCanceled {
+		return ErrDeploymentCanceled
+	}
+
+	// The process types are declared first, so that they are there as soon as the deployment is seen succeeding.
+	if status == models.DeploymentStatusSuccess {
+		if err := t.ProcessTypesRepo.Declare(context.Background(), depl.ApplicationID, depl.Processes); err != nil {
+			return err
+		}
+	}
+
+	// Only the canceled status is stored unconditionally, the others are not if the deployment was canceled
+	// since it was checked above.
+	depl.Status = status
+	if status == models.DeploymentStatusCanceled {
+		if err := t.DeplsRepo.UpdateOneWhere(context.Background(), depl, "id", depl.ID); err != nil {
+			return err
+		}
+	} else {
+		updated, err := t.DeplsRepo.UpdateUnlessCanceled(context.Background(), depl)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			depl.Status = models.DeploymentStatusCanceled
+			return ErrDeploymentCanceled
+		}
+	}
+
+	if depl.GitHubCheckRunID != 0 && depl.Application != nil {
+		if err := githubApp.UpdateCheckRun(context.Background(), *depl.Application, *depl); err != nil {
+			slog.Warn("Failed to update GitHub check run", "error", err, "deployment_id", depl.ID)
+		}
+	}
+
+	return nil
+}
+
+// Cancel stores the canceled status of a deployment. The driver stops what the deployment was going through afterwards.
+func (t *DeploymentTracker) Cancel(depl *models.Deployment) error {
+	// The deployment is flagged first, so that no other status overwrites the canceled one
+	// until it is stored, after which IsCanceled relies on the stored status.
+	t.canceling.Store(depl.ID, struct{}{})
+	defer t.canceling.Delete(depl.ID)
+
+	return t.UpdateStatus(depl, models.DeploymentStatusCanceled)
+}
+
+// IsCanceled reports whether a deployment is being canceled, or has been, as stored, so that the deployments
+// canceled before a restart stay so.
+func (t *DeploymentTracker) IsCanceled(depl models.Deployment) bool {
+	if _, canceling := t.canceling.Load(depl.ID); canceling {
+		return true
+	}
+
+	stored, err := t.DeplsRepo.FindOneBy(context.Background(), "id", depl.ID)
+	return err == nil && stored.Status == models.DeploymentStatusCanceled
+}
+
+// WaitForBuilder waits for a queued deployment to leave the queue, telling the client once, then for its builder
+// to start if it is being built, as reported by builderStarted. It returns the deployment as it is by then.
+func (t *DeploymentTracker) WaitForBuilder(ctx *caesar.Context, depl models.Deployment, opts StreamLogsOptions, builderStarted func(depl models.Deployment) (bool, error)) (models.Deployment, error) {
+	if depl.Status == models.DeploymentStatusQueued {
+		event := models.NewLogEvent(time.Now(), models.LogEntryStreamStdout, depl.ID, "Deployment queued, waiting for it to start...")
+		if err := SendLogEvent(ctx, event, opts); err != nil {
+			return depl, err
+		}
+	}
+
+	for {
+		switch depl.Status {
+		case models.DeploymentStatusQueued:
+		case models.DeploymentStatusBuilding:
+			started, err := builderStarted(depl)
+			if err != nil || started {
+				return depl, err
+			}
+		default:
+			return depl, nil
+		}
+
+		select {
+		case <-ctx.Context().Done():
+			return depl, ctx.Context().Err()
+		case <-time.After(BuilderPollInterval):
+		}
+
+		latest, err := t.DeplsRepo.FindOneBy(ctx.Context(), "id", depl.ID)
+		if err != nil {
+			return depl, err
+		}
+		depl = *latest
+	}
+}
+
+// ReplayLogs sends the stored logs of a deployment from a given source.
+func (t *DeploymentTracker) ReplayLogs(ctx *caesar.Context, depl models.Deployment, source models.LogEntrySource, opts StreamLogsOptions) error {
+	entries, err := t.LogsRepo.FindAllFromDeployment(ctx.Context(), depl.ID, source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := SendLogEvent(ctx, entry.Event(), opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StoredBuildOutcome returns the outcome of a build whose builder is gone.
+func StoredBuildOutcome(depl models.Deployment) string {
+	switch depl.Status {
+	case models.DeploymentStatusBuildFailed:
+		return BuildOutcomeFailed
+	case models.DeploymentStatusCanceled:
+		return BuildOutcomeCanceled
+	}
+	return BuildOutcomeSucceeded
+}
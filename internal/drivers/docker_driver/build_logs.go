@@ -0,0 +1,94 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+
+	caesar "github.com/caesar-rocks/core"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// streamBuildLogs streams the output of the build of a deployment from its start, following it until the
+// builder exits. The output of the builders that are gone is replayed from the stored logs.
+func (d *DockerDriver) streamBuildLogs(ctx *caesar.Context, depl models.Deployment, opts drivers.StreamLogsOptions) error {
+	// The builder containers are named after their deployment.
+	depl, err := d.deployments.WaitForBuilder(ctx, depl, opts, func(depl models.Deployment) (bool, error) {
+		_, err := d.Client.ContainerInspect(ctx.Context(), depl.ID)
+		return !client.IsErrNotFound(err), nil
+	})
+	if err != nil {
+		return err
+	}
+	switch depl.Status {
+	case models.DeploymentStatusSuperseded:
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeSuperseded)
+	case models.DeploymentStatusCanceled:
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeCanceled)
+	}
+
+	reader, err := d.Client.ContainerLogs(ctx.Context(), depl.ID, container.LogsOptions{
+		Follow:     true,
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	})
+	if client.IsErrNotFound(err) {
+		if err := d.deployments.ReplayLogs(ctx, depl, models.LogEntrySourceBuilder, opts); err != nil {
+			return err
+		}
+		return drivers.SendEndEvent(ctx, drivers.StoredBuildOutcome(depl))
+	}
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var sendErr error
+	send := func(stream models.LogEntryStream) *lineWriter {
+		return &lineWriter{onLine: func(line string) {
+			if sendErr != nil {
+				return
+			}
+			timestamp, message := splitTimestamp(line)
+			sendErr = drivers.SendLogEvent(ctx, models.NewLogEvent(timestamp, stream, depl.ID, message), opts)
+		}}
+	}
+	stdout := send(models.LogEntryStreamStdout)
+	stderr := send(models.LogEntryStreamStderr)
+
+	err = demuxLogs(reader, d.isTtyContainer(depl.ID), stdout, stderr)
+	stdout.flush()
+	stderr.flush()
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if d.deployments.IsCanceled(depl) {
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeCanceled)
+	}
+
+	info, err := d.Client.ContainerInspect(ctx.Context(), depl.ID)
+	if err != nil {
+		return err
+	}
+	if info.State.ExitCode != 0 {
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeFailed)
+	}
+
+	return drivers.SendEndEvent(ctx, drivers.BuildOutcomeSucceeded)
+}
+
+// isRunningDeployment reports whether a deployment is the one the application's service runs.
+func (d *DockerDriver) isRunningDeployment(app models.Application, depl models.Deployment) bool {
+	svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), app.ID, types.ServiceInspectOptions{})
+	if err != nil {
+		return false
+	}
+	return svc.Spec.TaskTemplate.ContainerSpec.Labels["deployment_id"] == depl.ID
+}
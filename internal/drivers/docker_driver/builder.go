@@ -9,16 +9,27 @@ import (
 )
 
 func (driver *DockerDriver) IgniteBuilder(app models.Application, depl models.Deployment) error {
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return err
+	}
+
+	labels := logLabels(app, depl, models.LogEntrySourceBuilder)
+	labels["traefik.enable"] = "false"
+
 	ct, err := driver.Client.ContainerCreate(
 		context.Background(),
 		&container.Config{
-			Image: os.Getenv("BUILDER_IMAGE"),
-			Env:   prepareBuilderEnv(app.ID, depl.ID),
-			Labels: map[string]string{
-				"traefik.enable": "false",
-			},
+			Image:  os.Getenv("BUILDER_IMAGE"),
+			Env:    prepareBuilderEnv(app.ID, depl.ID),
+			Labels: labels,
+		},
+		&container.HostConfig{
+			AutoRemove:  false,
+			NetworkMode: "host",
+			Privileged:  true,
+			Resources:   containerResources(specs),
 		},
-		&container.HostConfig{AutoRemove: false, NetworkMode: "host", Privileged: true},
 		nil,
 		nil,
 		depl.ID,
@@ -40,7 +51,8 @@ func (driver *DockerDriver) IgniteBuilder(app models.Application, depl models.De
 
 func prepareBuilderEnv(appID string, deplID string) []string {
 	envList := []string{}
-	envList = append(envList, "IMAGE_NAME="+appID)
+	// Each build is tagged with its deployment ID, so that previous releases can be rolled back to.
+	envList = append(envList, "IMAGE_NAME="+appID+":"+deplID)
 	envList = append(envList, "FILE_NAME="+deplID)
 	envList = append(envList, "REGISTRY_HOST="+os.Getenv("REGISTRY_HOST"))
 	envList = append(envList, "REGISTRY_TOKEN="+os.Getenv("REGISTRY_TOKEN"))
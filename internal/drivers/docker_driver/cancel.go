@@ -0,0 +1,26 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+)
+
+// CancelDeployment stops the build, the release or the rollout of a deployment. The builder and release
+// containers are removed, and the rollouts are rolled back by completeRollout.
+func (d *DockerDriver) CancelDeployment(app models.Application, depl models.Deployment) error {
+	phase := depl.Status
+
+	depl.Application = &app
+	if err := d.deployments.Cancel(&depl); err != nil {
+		return err
+	}
+
+	switch phase {
+	case models.DeploymentStatusBuilding:
+		// The builder containers are named after their deployment.
+		return d.retireContainer(depl.ID)
+	case models.DeploymentStatusReleasing:
+		return d.retireContainer(depl.ID + "-release")
+	}
+
+	return nil
+}
@@ -6,12 +6,33 @@ import (
 	"fmt"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
 )
 
+// CreateDatabase runs a database in a container attached to the private network of its organization,
+// where it is reachable at its internal hostname. Traefik routes the traffic of its public hostname to it on this network.
 func (driver *DockerDriver) CreateDatabase(db models.Database) error {
+	specs, err := db.GetComputingSpecs()
+	if err != nil {
+		return err
+	}
+
+	if err := driver.ensureOrgNetwork(db.OrganizationID); err != nil {
+		return err
+	}
+
 	cfg := buildConfig(db)
-	resp, err := driver.Client.ContainerCreate(context.Background(), cfg, nil, nil, nil, "citadel-"+db.Slug)
+	hostCfg := &container.HostConfig{Resources: containerResources(specs)}
+	netCfg := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			orgNetworkName(db.OrganizationID): {
+				NetworkID: orgNetworkName(db.OrganizationID),
+				Aliases:   []string{db.InternalHostname()},
+			},
+		},
+	}
+	resp, err := driver.Client.ContainerCreate(context.Background(), cfg, hostCfg, netCfg, nil, "citadel-"+db.Slug)
 	if err != nil {
 		return err
 	}
@@ -87,7 +108,9 @@ func prepareLabels(db models.Database) map[string]string {
 	}
 
 	return map[string]string{
-		"traefik.enable": "true",
+		labelOrganizationID:      db.OrganizationID,
+		"traefik.enable":         "true",
+		"traefik.docker.network": orgNetworkName(db.OrganizationID),
 		fmt.Sprintf("traefik.tcp.routers.%s.rule", routerName):                      hostname,
 		fmt.Sprintf("traefik.tcp.routers.%s.entrypoints", routerName):               string(db.DBMS),
 		fmt.Sprintf("traefik.tcp.services.%s.loadbalancer.server.port", routerName): fmt.Sprintf("%d", port),
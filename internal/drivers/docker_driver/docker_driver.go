@@ -1,21 +1,21 @@
 package dockerDriver
 
 import (
-	"bufio"
+	"citadel/internal/drivers"
 	"citadel/internal/models"
 	"citadel/internal/repositories"
 	"context"
 	"errors"
 	"log"
+	"log/slog"
 	"net"
 	"os"
 	"strings"
+	"sync"
 
 	caesar "github.com/caesar-rocks/core"
+	"github.com/caesar-rocks/drive"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
 	"github.com/minio/madmin-go/v3"
@@ -24,17 +24,63 @@ import (
 )
 
 type DockerDriver struct {
-	Client       *client.Client
-	RegistryAuth string
-	AppsRepo     *repositories.ApplicationsRepository
-	DeplsRepo    *repositories.DeploymentsRepository
-	ipv4         string
-	ipv6         string
-	minioClient  *minio.Client
-	minioAdmin   *madmin.AdminClient
+	Client           *client.Client
+	RegistryAuth     string
+	AppsRepo         *repositories.ApplicationsRepository
+	DeplsRepo        *repositories.DeploymentsRepository
+	CertsRepo        *repositories.CertificatesRepository
+	LogsRepo         *repositories.LogEntriesRepository
+	ProcessTypesRepo *repositories.ProcessTypesRepository
+	VolumesRepo      *repositories.VolumesRepository
+	DatabasesRepo    *repositories.DatabasesRepository
+	MetricsRepo      *repositories.MetricSamplesRepository
+	ipv4             string
+	ipv6             string
+	minioClient      *minio.Client
+	minioAdmin       *madmin.AdminClient
+
+	// drive stores the snapshots of the volumes, on the platform's S3 storage.
+	drive *drive.Drive
+
+	// retiredContainers holds the IDs of the containers removed by the driver itself.
+	retiredContainers sync.Map
+
+	// deployments walks the deployments through their statuses, and stores their logs.
+	deployments *drivers.DeploymentTracker
+
+	// handledBuilds holds the IDs of the deployments whose build outcome has been handled.
+	handledBuilds sync.Map
+
+	// releases and rollouts hold the IDs of the deployments whose release command is being run,
+	// and whose rollout is being monitored, by this process. The other ones are left to the reconciler.
+	releases sync.Map
+	rollouts sync.Map
+
+	reconcileMu sync.Mutex
+	networksMu  sync.Mutex
+
+	// eventsWatcher keeps track of the subscription to the Docker events.
+	eventsWatcher eventsWatcher
+
+	healthEvents drivers.HealthEventsBroker
+
+	// collectedContainers holds the IDs of the containers whose logs are being collected.
+	collectedContainers sync.Map
+	logEntries          chan models.LogEntry
+
+	// networkCounters holds the network counters of the containers at their previous sample, which their traffic
+	// is measured from. It is only used by the metrics collector.
+	networkCounters map[string]networkCounters
+
+	// restarts counts the unexpected exits of the containers since the previous sample, by resource.
+	restarts   map[metricsKey]int
+	restartsMu sync.Mutex
+
+	// killedContainers holds the IDs of the containers stopped on purpose, whose exit isn't a restart.
+	killedContainers sync.Map
 }
 
-func New(appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.DeploymentsRepository) *DockerDriver {
+func New(appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.DeploymentsRepository, certsRepo *repositories.CertificatesRepository, logsRepo *repositories.LogEntriesRepository, processTypesRepo *repositories.ProcessTypesRepository, volumesRepo *repositories.VolumesRepository, databasesRepo *repositories.DatabasesRepository, metricsRepo *repositories.MetricSamplesRepository, drive *drive.Drive) *DockerDriver {
 	client, err := client.NewClientWithOpts(
 		client.FromEnv,
 		client.WithAPIVersionNegotiation(),
@@ -73,11 +119,22 @@ func New(appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.
 	}
 
 	return &DockerDriver{
-		Client:       client,
-		RegistryAuth: registryAuth,
-		DeplsRepo:    deplsRepo,
-		minioClient:  minioClient,
-		minioAdmin:   minioAdmin,
+		Client:           client,
+		RegistryAuth:     registryAuth,
+		AppsRepo:         appsRepo,
+		DeplsRepo:        deplsRepo,
+		CertsRepo:        certsRepo,
+		LogsRepo:         logsRepo,
+		deployments:      drivers.NewDeploymentTracker(deplsRepo, logsRepo, processTypesRepo),
+		ProcessTypesRepo: processTypesRepo,
+		VolumesRepo:      volumesRepo,
+		DatabasesRepo:    databasesRepo,
+		MetricsRepo:      metricsRepo,
+		minioClient:      minioClient,
+		minioAdmin:       minioAdmin,
+		drive:            drive,
+		networkCounters:  make(map[string]networkCounters),
+		restarts:         make(map[metricsKey]int),
 	}
 }
 
@@ -85,8 +142,28 @@ func (d *DockerDriver) Init() error {
 	if err := d.initializeSwarm(); err != nil {
 		return err
 	}
+	if err := d.ensureServicesNetwork(); err != nil {
+		return err
+	}
+	if err := d.connectTraefikToOrgNetworks(); err != nil {
+		return err
+	}
+	if err := d.attachToOrgNetworks(); err != nil {
+		slog.Error("Failed to attach the applications and databases to the networks of their organizations", "error", err)
+	}
+	if err := d.startLogCollector(); err != nil {
+		return err
+	}
 	d.watchEvents()
 	d.setIPs()
+	go d.collectMetricsPeriodically()
+
+	// The events missed while Citadel wasn't running are made up for once the events are watched.
+	if err := d.reconcile(); err != nil {
+		slog.Error("Failed to reconcile the deployments", "error", err)
+	}
+	go d.reconcilePeriodically()
+
 	return nil
 }
 
@@ -95,150 +172,136 @@ func (d *DockerDriver) CreateApplication(app models.Application) error {
 }
 
 func (d *DockerDriver) DeleteApplication(app models.Application) error {
-	if d.ContainerExists(app.ID) {
-		if err := d.Client.ContainerRemove(context.Background(), app.ID, container.RemoveOptions{Force: true}); err != nil {
-			return err
-		}
+	if err := d.removeService(app); err != nil {
+		return err
 	}
 
-	return nil
+	return d.retireApplicationContainers(app)
 }
 
 func (d *DockerDriver) CreateCertificate(app models.Application, cert models.Certificate) ([]models.DnsEntry, error) {
-	return []models.DnsEntry{
-		{Hostname: cert.Domain, Type: "A", Value: d.ipv4},
-		{Hostname: cert.Domain, Type: "AAAA", Value: d.ipv6},
-	}, nil
+	entries := []models.DnsEntry{}
+	for _, hostname := range cert.Hostnames() {
+		entries = append(entries,
+			models.DnsEntry{Hostname: hostname, Type: "A", Value: d.ipv4},
+			models.DnsEntry{Hostname: hostname, Type: "AAAA", Value: d.ipv6},
+		)
+	}
+	return entries, nil
 }
 
 func (d *DockerDriver) CheckDnsConfig(app models.Application, cert models.Certificate) (bool, error) {
-	records, err := net.LookupIP(cert.Domain)
-	if err != nil {
-		return false, err
-	}
-
-	ipv4Found := false
-	ipv6Found := false
+	for _, hostname := range cert.Hostnames() {
+		records, err := net.LookupIP(hostname)
+		if err != nil {
+			return false, err
+		}
 
-	for _, ip := range records {
-		if ip.To4() != nil {
-			if ip.String() == d.ipv4 {
-				ipv4Found = true
-			}
-		} else {
-			if ip.String() == d.ipv6 {
-				ipv6Found = true
+		ipv4Found := false
+		ipv6Found := false
+
+		for _, ip := range records {
+			if ip.To4() != nil {
+				if ip.String() == d.ipv4 {
+					ipv4Found = true
+				}
+			} else {
+				if ip.String() == d.ipv6 {
+					ipv6Found = true
+				}
 			}
 		}
-	}
 
-	if !ipv4Found || !ipv6Found {
-		return false, errors.New("no IPv4 or IPv6 address found for the domain")
+		if !ipv4Found || !ipv6Found {
+			return false, errors.New("no IPv4 or IPv6 address found for " + hostname)
+		}
 	}
 
 	return true, nil
 }
 
+// DeleteCertificate stops routing the traffic of a deleted domain to the application.
 func (d *DockerDriver) DeleteCertificate(app models.Application, cert models.Certificate) error {
-	return nil
+	return d.refreshRouting(app)
 }
 
+// IgniteApplication rolls out a deployment to the swarm service of the application.
+// The previous replicas keep serving the traffic until the new ones are healthy (see completeRollout).
+// Images that can't run the health check of the application aren't rolled out at all.
 func (d *DockerDriver) IgniteApplication(app models.Application, depl models.Deployment) error {
-	if _, err := d.Client.ImagePull(
-		context.Background(),
-		os.Getenv("REGISTRY_HOST")+"/"+app.ID,
-		image.PullOptions{RegistryAuth: d.RegistryAuth},
-	); err != nil {
+	if err := d.checkHealthCheckTools(app, depl); err != nil {
+		d.publishHealthEvent(app, depl, models.HealthCheckStatusFailed, "has failed: %s.", err)
 		return err
 	}
 
-	if d.ContainerExists(app.ID) {
-		if err := d.Client.ContainerRemove(context.Background(), app.ID, container.RemoveOptions{Force: true}); err != nil {
-			return err
-		}
-	}
-
-	traefikRule := "Host(`" + app.Slug + "." + os.Getenv("WILDCARD_TRAEFIK_DOMAIN")
-	for _, cert := range depl.Application.Certificates {
-		traefikRule += "`, `www." + cert.Domain
-	}
-	traefikRule += "`)"
-
-	if _, err := d.Client.ContainerCreate(
-		context.Background(),
-		&container.Config{
-			Image: os.Getenv("REGISTRY_HOST") + "/" + app.ID,
-			Labels: map[string]string{
-				"deployment_id": depl.ID,
-
-				"traefik.enable": "true",
-				"traefik.http.routers." + app.ID + ".rule":                      "Host(`" + app.Slug + "." + os.Getenv("WILDCARD_TRAEFIK_DOMAIN") + "`)",
-				"traefik.http.routers." + app.ID + ".entrypoints":               "websecure",
-				"traefik.http.routers." + app.ID + ".tls.certresolver":          "myresolver",
-				"traefik.http.services." + app.ID + ".loadbalancer.server.port": app.GetEnvVar("PORT", "3000"),
-				"traefik.http.routers." + app.ID + ".tls":                       "true",
-			},
-		},
-		&container.HostConfig{AutoRemove: true},
-		&network.NetworkingConfig{
-			EndpointsConfig: map[string]*network.EndpointSettings{
-				"traefik": {NetworkID: "traefik"},
-			},
-		},
-		nil,
-		app.ID,
-	); err != nil {
+	if err := d.deployService(app, depl); err != nil {
 		return err
 	}
 
-	if err := d.Client.ContainerStart(context.Background(), app.ID, container.StartOptions{}); err != nil {
-		return err
-	}
+	d.rollouts.Store(depl.ID, struct{}{})
+	go d.completeRollout(app, depl)
 
 	return nil
 }
 
-func (d *DockerDriver) StreamLogs(ctx *caesar.Context, app models.Application) error {
-	if !d.ContainerExists(app.ID) {
-		return nil
+// StreamLogs streams the logs of the replicas of all the process types of an application, along with
+// the health check events of its new deployments, until the client disconnects.
+// The logs of builds and of the deployments that are no longer running are sent up to their end (see streamBuildLogs).
+func (d *DockerDriver) StreamLogs(ctx *caesar.Context, app models.Application, opts drivers.StreamLogsOptions) error {
+	if opts.Scope == drivers.LogsScopeBuilder {
+		return d.streamBuildLogs(ctx, *opts.Deployment, opts)
+	}
+	if opts.Deployment != nil && !d.isRunningDeployment(app, *opts.Deployment) {
+		if err := d.deployments.ReplayLogs(ctx, *opts.Deployment, models.LogEntrySourceApp, opts); err != nil {
+			return err
+		}
+		return drivers.SendEndEvent(ctx, "")
 	}
 
-	logStream, err := d.Client.ContainerLogs(ctx.Context(), app.ID, container.LogsOptions{
-		Follow:     true,
-		ShowStdout: true,
-		ShowStderr: true,
-		Timestamps: true,
-		Tail:       "50",
-	})
+	healthEvents, unsubscribe := d.healthEvents.Subscribe(app.ID)
+	defer unsubscribe()
+
+	closed := ctx.Context().Done()
+	logEvents := make(chan models.LogEvent)
+
+	streams, err := d.openLogStreams(ctx.Context(), app)
 	if err != nil {
 		return err
 	}
+	streamErrs := make(chan error, len(streams))
+	for _, stream := range streams {
+		defer stream.Close()
 
-	closed := ctx.Context().Done()
+		go func(stream *logStream) {
+			streamErrs <- d.followLogStream(stream, logEvents, closed)
+		}(stream)
+	}
 
-	scanner := bufio.NewScanner(logStream)
-	for scanner.Scan() {
+	for {
 		select {
 		case <-closed:
 			return nil
-		default:
-			logLine := strings.TrimLeft(scanner.Text(), " ")
-			if len(logLine) > 2 {
-				logLine = logLine[8:]
-			}
-			if !ctx.WantsJSON() {
-				logLine = "<pre>" + logLine + "</pre>"
+		case event := <-logEvents:
+			if err := drivers.SendLogEvent(ctx, event, opts); err != nil {
+				return err
 			}
-
-			if err := ctx.SendSSE("log", logLine); err != nil {
+		case event := <-healthEvents:
+			if err := drivers.SendHealthEvent(ctx, event); err != nil {
 				return err
 			}
+		case err := <-streamErrs:
+			// The container is gone (e.g. replaced by a new deployment): flush the pending
+			// health check events, as they may tell why, before ending the stream.
+			for {
+				select {
+				case event := <-healthEvents:
+					if err := drivers.SendHealthEvent(ctx, event); err != nil {
+						return err
+					}
+				default:
+					return err
+				}
+			}
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	return nil
 }
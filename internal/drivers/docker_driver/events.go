@@ -1,80 +1,148 @@
 package dockerDriver
 
 import (
+	"citadel/internal/drivers"
 	"citadel/internal/models"
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
 	"os"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
 )
 
-func (driver *DockerDriver) watchEvents() {
-	eventsChan, errChan := driver.Client.Events(context.Background(), types.EventsOptions{})
+// containerEvent is a container event, with the labels Citadel puts on its containers.
+type containerEvent struct {
+	Action      events.Action
+	ContainerID string
 
-	go func() {
-		for {
-			select {
-			case err := <-errChan:
-				slog.Error("Error while watching events", "error", err)
-			case event := <-eventsChan:
-				if err := driver.handleEvent(event); err != nil {
-					slog.Error("Error while handling event", "error", err)
-				}
-			}
+	// Source is where the logs of the container come from, if they are collected.
+	Source models.LogEntrySource
+
+	// DeploymentID is the deployment the container runs or builds, if any.
+	DeploymentID string
+
+	ExitCode   string
+	IsTask     bool
+	Attributes map[string]string
+}
+
+// parseContainerEvent reads a container event, and reports whether the container is one of Citadel's.
+func parseContainerEvent(event events.Message) (containerEvent, bool) {
+	attrs := event.Actor.Attributes
+	ce := containerEvent{
+		Action:       event.Action,
+		ContainerID:  event.Actor.ID,
+		Source:       models.LogEntrySource(attrs[logLabelSource]),
+		DeploymentID: attrs["deployment_id"],
+		ExitCode:     attrs["exitCode"],
+		Attributes:   attrs,
+	}
+	_, ce.IsTask = attrs["com.docker.swarm.task.id"]
+
+	// The builders started before the log labels existed are only known by their image, and named after their deployment.
+	if ce.Source == "" && attrs["image"] == os.Getenv("BUILDER_IMAGE") {
+		ce.Source = models.LogEntrySourceBuilder
+	}
+	if ce.Source == models.LogEntrySourceBuilder {
+		ce.DeploymentID = attrs[logLabelDeploymentID]
+		if ce.DeploymentID == "" {
+			ce.DeploymentID = attrs["name"]
 		}
-	}()
+	}
+
+	_, hasAppID := attrs["application_id"]
+	return ce, ce.Source != "" || hasAppID
 }
 
+// handleEvent dispatches the events of Citadel's containers to their handler. The other containers are ignored.
 func (driver *DockerDriver) handleEvent(event events.Message) error {
-	if event.Type != "container" {
+	if event.Type != events.ContainerEventType {
 		return nil
 	}
 
-	image := event.Actor.Attributes["image"]
+	ce, owned := parseContainerEvent(event)
+	driver.handleMetricsEvent(ce)
+	if !owned {
+		return nil
+	}
 
-	if image == os.Getenv("BUILDER_IMAGE") && event.Action == "die" {
-		depl, err := driver.DeplsRepo.FindOneByIdWithRelatedAppAndCerts(context.Background(), event.Actor.Attributes["name"])
-		if err != nil {
-			slog.Error("Error while finding deployment", "error", err, "deployment_id", event.Actor.Attributes["name"])
-			return err
+	switch ce.Action {
+	case events.ActionStart:
+		if _, collected := ce.Attributes[logLabelSource]; collected {
+			go driver.collectLogs(ce.ContainerID, ce.Attributes)
 		}
-
-		if event.Actor.Attributes["exitCode"] != "0" {
-			return driver.handleBuildFailed(depl)
+	case events.ActionDie:
+		if ce.Source == models.LogEntrySourceBuilder {
+			return driver.handleBuilderDie(ce)
 		}
+		return driver.handleContainerDie(ce)
+	case events.ActionDestroy:
+		driver.retiredContainers.Delete(ce.ContainerID)
+	}
 
-		return driver.handleBuildSuccess(depl)
-	} else {
-		// Get deployment
-		depl, err := driver.DeplsRepo.FindOneBy(context.Background(), "id", event.Actor.Attributes["deployment_id"])
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		if event.Action == "die" {
-			depl.Status = models.DeploymentStatusDeployFailed
-			if err := driver.DeplsRepo.UpdateOneWhere(context.Background(), depl, "id", depl.ID); err != nil {
-				return err
-			}
-		} else if event.Action == "start" {
-			depl.Status = models.DeploymentStatusSuccess
-			if err := driver.DeplsRepo.UpdateOneWhere(context.Background(), depl, "id", depl.ID); err != nil {
-				return err
-			}
-		}
+// handleBuilderDie handles the outcome of the build of a deployment.
+func (driver *DockerDriver) handleBuilderDie(ce containerEvent) error {
+	depl, err := driver.DeplsRepo.FindOneByIdWithRelatedAppAndCerts(context.Background(), ce.DeploymentID)
+	if err != nil {
+		slog.Error("Error while finding deployment", "error", err, "deployment_id", ce.DeploymentID)
+		return err
+	}
 
-		slog.Info("Unknown event", "event", fmt.Sprintf("%+v", event))
+	// The builders of canceled deployments are killed.
+	if depl.Status == models.DeploymentStatusCanceled {
+		return nil
 	}
 
-	return nil
+	return driver.handleBuildExit(depl, ce.ExitCode == "0")
+}
+
+// handleContainerDie marks the live deployment of a standalone container that died as failed.
+func (driver *DockerDriver) handleContainerDie(ce containerEvent) error {
+	// Swarm restarts the failing replicas of application services itself, and rolls back
+	// the updates whose replicas fail (see completeRollout).
+	if ce.IsTask || ce.DeploymentID == "" {
+		return nil
+	}
+
+	// Containers removed by the driver (e.g. replaced by a newer deployment) haven't failed.
+	if _, retired := driver.retiredContainers.Load(ce.ContainerID); retired {
+		return nil
+	}
+
+	depl, err := driver.DeplsRepo.FindOneByIdWithRelatedAppAndCerts(context.Background(), ce.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	// Containers dying during a rollout are handled by completeRollout,
+	// so only the ones of live deployments are reported here.
+	if depl.Status != models.DeploymentStatusSuccess {
+		return nil
+	}
+
+	slog.Info("Container of a live deployment died", "deployment_id", depl.ID, "container_id", ce.ContainerID, "exit_code", ce.ExitCode)
+	return driver.deployments.UpdateStatus(depl, models.DeploymentStatusDeployFailed)
+}
+
+// handleBuildExit handles the outcome of a build once, whether its builder's exit is reported
+// by the events or found by the reconciler.
+func (driver *DockerDriver) handleBuildExit(depl *models.Deployment, succeeded bool) error {
+	if _, handled := driver.handledBuilds.LoadOrStore(depl.ID, struct{}{}); handled {
+		return nil
+	}
+
+	if !succeeded {
+		return driver.handleBuildFailed(depl)
+	}
+	return driver.handleBuildSuccess(depl)
 }
 
 func (driver *DockerDriver) handleBuildFailed(depl *models.Deployment) error {
-	depl.Status = models.DeploymentStatusBuildFailed
-	if err := driver.DeplsRepo.UpdateOneWhere(context.Background(), depl, "id", depl.ID); err != nil {
+	if err := driver.deployments.UpdateStatus(depl, models.DeploymentStatusBuildFailed); err != nil {
 		return err
 	}
 
@@ -82,12 +150,46 @@ func (driver *DockerDriver) handleBuildFailed(depl *models.Deployment) error {
 }
 
 func (driver *DockerDriver) handleBuildSuccess(depl *models.Deployment) error {
-	depl.Status = models.DeploymentStatusDeploying
-	if err := driver.DeplsRepo.UpdateOneWhere(context.Background(), depl, "id", depl.ID); err != nil {
+	depl.ImageTag = depl.ID
+
+	// The release phase may take a while, and must not hold up the handling of other events.
+	go func() {
+		if err := driver.releaseAndIgnite(depl); err != nil && !errors.Is(err, drivers.ErrDeploymentCanceled) {
+			slog.Error("Error while deploying", "error", err, "deployment_id", depl.ID)
+		}
+	}()
+
+	return nil
+}
+
+// releaseAndIgnite runs the release command of a freshly built deployment, if any, and rolls it out
+// once it succeeds. A failing release command leaves the running deployment untouched.
+func (driver *DockerDriver) releaseAndIgnite(depl *models.Deployment) error {
+	if depl.ReleaseCommand != "" {
+		if err := driver.deployments.UpdateStatus(depl, models.DeploymentStatusReleasing); err != nil {
+			return err
+		}
+
+		ok, err := driver.runReleaseCommand(*depl.Application, depl)
+		if driver.deployments.IsCanceled(*depl) {
+			return drivers.ErrDeploymentCanceled
+		}
+		if err != nil || !ok {
+			if err := driver.deployments.UpdateStatus(depl, models.DeploymentStatusReleaseFailed); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := driver.deployments.UpdateStatus(depl, models.DeploymentStatusDeploying); err != nil {
 		return err
 	}
 
 	if err := driver.IgniteApplication(*depl.Application, *depl); err != nil {
+		if err := driver.deployments.UpdateStatus(depl, models.DeploymentStatusDeployFailed); err != nil {
+			return err
+		}
 		return err
 	}
 
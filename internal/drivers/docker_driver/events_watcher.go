@@ -0,0 +1,251 @@
+package dockerDriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	// The delay before subscribing to the events again after the stream failed, doubling on each failure in a row.
+	eventsMinBackoff = time.Second
+	eventsMaxBackoff = time.Minute
+
+	// eventsSubscribeGrace is how long a subscription has to go without failing to be deemed successful,
+	// as Docker only tells about the failed ones, and events may not come for a while.
+	eventsSubscribeGrace = 5 * time.Second
+)
+
+// errEventsStreamClosed is returned when the events stream ends without telling why.
+var errEventsStreamClosed = errors.New("events stream closed")
+
+// EventsWatcherHealth tells how the watching of the Docker events goes.
+type EventsWatcherHealth struct {
+	// Connected reports whether the events are being received.
+	Connected bool `json:"connected"`
+
+	// Reconnects is the number of times the events had to be subscribed to again.
+	Reconnects int `json:"reconnects"`
+
+	// LastEventAt is the time of the last event handled, if any.
+	LastEventAt *time.Time `json:"last_event_at"`
+
+	// LastError is the error the events stream last failed with, if any.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// eventsWatcher holds the state of the events subscription, so that it can resume where it stopped.
+type eventsWatcher struct {
+	mu       sync.Mutex
+	health   EventsWatcherHealth
+	lastNano int64
+}
+
+// EventsWatcherHealth returns the health of the events watcher.
+func (d *DockerDriver) EventsWatcherHealth() EventsWatcherHealth {
+	d.eventsWatcher.mu.Lock()
+	defer d.eventsWatcher.mu.Unlock()
+	return d.eventsWatcher.health
+}
+
+// watchEvents follows the events of the containers, subscribing to them again with a backoff whenever
+// the stream fails (e.g. when the Docker daemon restarts), and resuming right after the last event handled.
+func (d *DockerDriver) watchEvents() {
+	// The stream starts from now, so that the first subscription can be resumed from it too.
+	d.eventsWatcher.startAt(time.Now())
+
+	go func() {
+		backoff := eventsMinBackoff
+		resumed := false
+
+		for {
+			err := d.followEvents(resumed, func() {
+				backoff = eventsMinBackoff
+			})
+			resumed = true
+
+			reconnects := d.eventsWatcher.disconnected(err)
+			slog.Warn("Docker events stream interrupted, subscribing again", "error", err, "retry_in", backoff, "reconnects", reconnects)
+
+			time.Sleep(backoff)
+			backoff = min(2*backoff, eventsMaxBackoff)
+		}
+	}()
+}
+
+// eventsFilters returns the filters of the subscriptions to the events of Citadel's containers, which are
+// the replicas, carrying the "application_id" label, the builders and release containers, carrying the one of the logs,
+// the databases, carrying the one of their organization, and the builders started before the log labels existed,
+// only known by their image. Docker ANDs the label filters of a subscription, hence one subscription per filter.
+func eventsFilters() []filters.KeyValuePair {
+	eventsFilters := []filters.KeyValuePair{
+		filters.Arg("label", "application_id"),
+		filters.Arg("label", logLabelApplicationID),
+		filters.Arg("label", labelOrganizationID),
+	}
+	if image := os.Getenv("BUILDER_IMAGE"); image != "" {
+		eventsFilters = append(eventsFilters, filters.Arg("image", image))
+	}
+	return eventsFilters
+}
+
+// hasAnyLabel reports whether an event is one of a container carrying one of the labels of the given filters,
+// i.e. whether a subscription to them receives it too.
+func hasAnyLabel(event events.Message, eventsFilters []filters.KeyValuePair) bool {
+	for _, filter := range eventsFilters {
+		if filter.Key != "label" {
+			continue
+		}
+		if _, ok := event.Actor.Attributes[filter.Value]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// followEvents subscribes to the container events since the last one handled, and handles them until the stream fails.
+// When resuming, the events missed in the meantime are replayed by Docker, within the limits of its history,
+// and the reconciler makes up for the others. onConnected is called once the subscription is established.
+func (d *DockerDriver) followEvents(resumed bool, onConnected func()) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	since := d.eventsWatcher.since()
+
+	// The subscriptions are merged into a single stream. A container matching several of them
+	// is only received from the first one.
+	eventsFilters := eventsFilters()
+	messages := make(chan events.Message)
+	errs := make(chan error, len(eventsFilters))
+	for i, filter := range eventsFilters {
+		opts := types.EventsOptions{
+			Filters: filters.NewArgs(
+				filters.Arg("type", string(events.ContainerEventType)),
+				filters.Arg("event", string(events.ActionStart)),
+				filters.Arg("event", string(events.ActionKill)),
+				filters.Arg("event", string(events.ActionDie)),
+				filters.Arg("event", string(events.ActionDestroy)),
+				filter,
+			),
+			Since: fmt.Sprintf("%d.%09d", since/int64(time.Second), since%int64(time.Second)),
+		}
+
+		subMessages, subErrs := d.Client.Events(ctx, opts)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err := <-subErrs:
+					errs <- err
+					return
+				case event := <-subMessages:
+					if hasAnyLabel(event, eventsFilters[:i]) {
+						continue
+					}
+					select {
+					case messages <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	subscribed := time.After(eventsSubscribeGrace)
+
+	if !resumed {
+		slog.Info("Watching Docker events")
+	} else {
+		slog.Info("Docker events stream resumed", "since", time.Unix(0, since))
+		go func() {
+			if err := d.reconcile(); err != nil {
+				slog.Error("Failed to reconcile the deployments", "error", err)
+			}
+		}()
+	}
+
+	// The subscription is established once it has gone without failing for a while, or an event has come.
+	connected := false
+	connect := func() {
+		if !connected {
+			connected = true
+			d.eventsWatcher.connected()
+			onConnected()
+		}
+	}
+
+	for {
+		select {
+		case err := <-errs:
+			if err == nil {
+				err = errEventsStreamClosed
+			}
+			return err
+		case <-subscribed:
+			connect()
+		case event := <-messages:
+			connect()
+
+			// The events up to the one the stream resumed from are sent again.
+			if event.TimeNano <= since {
+				continue
+			}
+			d.eventsWatcher.handled(event)
+
+			if err := d.handleEvent(event); err != nil {
+				slog.Error("Error while handling event", "error", err, "action", event.Action, "container_id", event.Actor.ID)
+			}
+		}
+	}
+}
+
+// since returns the time of the last event handled, in nanoseconds.
+func (w *eventsWatcher) since() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastNano
+}
+
+// startAt sets the time the events are first subscribed to since. No event counts as handled yet.
+func (w *eventsWatcher) startAt(t time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastNano = t.UnixNano()
+}
+
+// handled records an event as the last one handled.
+func (w *eventsWatcher) handled(event events.Message) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastNano = max(w.lastNano, event.TimeNano)
+	lastEventAt := time.Unix(0, w.lastNano)
+	w.health.LastEventAt = &lastEventAt
+}
+
+// connected records that the events are being received, once one has come or the subscription has gone without failing for a while.
+func (w *eventsWatcher) connected() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.health.Connected = true
+}
+
+// disconnected records the failure of the stream, and returns the number of times it has been subscribed to again.
+func (w *eventsWatcher) disconnected(err error) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.health.Connected = false
+	w.health.LastError = err.Error()
+	w.health.Reconnects++
+	return w.health.Reconnects
+}
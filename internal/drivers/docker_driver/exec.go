@@ -0,0 +1,141 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"io"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Exec runs a command in the most recent running instance of an application, with Docker exec.
+func (d *DockerDriver) Exec(ctx context.Context, app models.Application, opts drivers.ExecOptions) (int, error) {
+	containerID, err := d.findRunningContainer(ctx, app)
+	if err != nil {
+		return -1, err
+	}
+	if containerID == "" {
+		return -1, drivers.ErrNoRunningInstance
+	}
+
+	config := types.ExecConfig{
+		Cmd:          opts.Cmd,
+		Tty:          opts.Tty,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if opts.Tty {
+		config.Env = []string{"TERM=xterm-256color"}
+		if opts.Size.Cols > 0 && opts.Size.Rows > 0 {
+			config.ConsoleSize = &[2]uint{opts.Size.Rows, opts.Size.Cols}
+		}
+	}
+
+	exec, err := d.Client.ContainerExecCreate(ctx, containerID, config)
+	if err != nil {
+		return -1, err
+	}
+
+	resp, err := d.Client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{
+		Tty:         opts.Tty,
+		ConsoleSize: config.ConsoleSize,
+	})
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// The hijacked connection outlives the context, so it is closed once the client goes away.
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Close()
+		case <-done:
+		}
+	}()
+
+	if opts.Resize != nil {
+		go func() {
+			for {
+				select {
+				case size := <-opts.Resize:
+					d.Client.ContainerExecResize(ctx, exec.ID, container.ResizeOptions{
+						Height: size.Rows,
+						Width:  size.Cols,
+					})
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	if opts.Stdin != nil {
+		go func() {
+			io.Copy(resp.Conn, opts.Stdin)
+			resp.CloseWrite()
+		}()
+	}
+
+	if opts.Tty {
+		_, err = io.Copy(opts.Stdout, resp.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(opts.Stdout, opts.Stderr, resp.Reader)
+	}
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	info, err := d.Client.ContainerExecInspect(context.Background(), exec.ID)
+	if err != nil {
+		return -1, err
+	}
+
+	return info.ExitCode, nil
+}
+
+// findRunningContainer returns the ID of the most recent running instance of the web process of an application,
+// be it a replica of its service or a standalone container. The replicas of the other process types are left out.
+func (d *DockerDriver) findRunningContainer(ctx context.Context, app models.Application) (string, error) {
+	containers, err := d.Client.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", "application_id="+app.ID),
+			filters.Arg("status", "running"),
+		),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	web := containers[:0]
+	for _, ct := range containers {
+		// The standalone containers predate the process types, and aren't labelled with any.
+		if process := ct.Labels[logLabelProcess]; process == "" || process == models.ProcessTypeWeb {
+			web = append(web, ct)
+		}
+	}
+	containers = web
+
+	if len(containers) == 0 {
+		// Applications deployed before blue/green rollouts run in a container named after them.
+		return d.findApplicationContainer(app)
+	}
+
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Created > containers[j].Created
+	})
+
+	return containers[0].ID, nil
+}
@@ -0,0 +1,87 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+const (
+	// healthCheckToolsCommand succeeds if the image has one of the tools the health check probes the replicas with.
+	healthCheckToolsCommand = "command -v wget >/dev/null 2>&1 || command -v curl >/dev/null 2>&1"
+
+	// healthCheckToolsTimeout is how long looking for the tools of the health check in an image may take.
+	healthCheckToolsTimeout = 2 * time.Minute
+)
+
+// errHealthCheckToolsMissing is returned when the image of a deployment can't run the health check of its application.
+var errHealthCheckToolsMissing = errors.New("the health check probes the replicas with wget or curl, but the image has neither: install one of them, or remove the health check path")
+
+// serviceHealthcheck turns the HTTP health check of an application into the Docker health check of its
+// replicas, which swarm waits for before routing them traffic and retiring the previous ones.
+// The probe runs inside the containers, with either wget or curl.
+func serviceHealthcheck(app models.Application) *container.HealthConfig {
+	if !app.HasHealthCheck() {
+		return nil
+	}
+
+	hc := app.GetHealthCheck()
+	url := shellQuote("http://127.0.0.1:" + hc.Port + hc.Path)
+
+	return &container.HealthConfig{
+		Test: []string{
+			"CMD-SHELL",
+			fmt.Sprintf("wget -q -O /dev/null -T %d %s || curl -fs -o /dev/null -m %d %s || exit 1",
+				int(hc.Timeout.Seconds()), url, int(hc.Timeout.Seconds()), url),
+		},
+		Interval: hc.Interval,
+		Timeout:  hc.Timeout,
+		Retries:  hc.UnhealthyThreshold,
+	}
+}
+
+// shellQuote quotes a string so that the shell of a CMD-SHELL health check reads it as a single word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// publishHealthEvent reports the progress of the health check of a new deployment, if the application has one.
+func (d *DockerDriver) publishHealthEvent(app models.Application, depl models.Deployment, status models.HealthCheckStatus, format string, args ...any) {
+	if !app.HasHealthCheck() {
+		return
+	}
+
+	d.healthEvents.Publish(app.ID, models.HealthCheckEvent{
+		DeploymentID: depl.ID,
+		Status:       status,
+		Message:      "Health check on " + app.HealthCheckPath + " " + fmt.Sprintf(format, args...),
+		Timestamp:    time.Now(),
+	})
+}
+
+// checkHealthCheckTools makes sure the image of a deployment has wget or curl when the application has a health check,
+// in a one-off container, so that the deployment fails with a clear error rather than with replicas that never get healthy.
+func (d *DockerDriver) checkHealthCheckTools(app models.Application, depl models.Deployment) error {
+	if !app.HasHealthCheck() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckToolsTimeout)
+	defer cancel()
+
+	labels := map[string]string{"deployment_id": depl.ID}
+	exitCode, _, err := d.runOneOff(ctx, app, depl, depl.ID+"-health-check-tools", healthCheckToolsCommand, labels)
+	if err != nil {
+		return fmt.Errorf("failed to look for wget or curl in the image: %w", err)
+	}
+	if exitCode != 0 {
+		return errHealthCheckToolsMissing
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package dockerDriver
 
 import (
 	"context"
+	"io"
 
 	"github.com/docker/docker/api/types/image"
 )
@@ -27,3 +28,19 @@ func (driver *DockerDriver) ImageExists(imageName string) (bool, error) {
 
 	return false, nil
 }
+
+// pullImage pulls an image from the registry, and waits for the pull to complete.
+func (driver *DockerDriver) pullImage(ref string) error {
+	reader, err := driver.Client.ImagePull(
+		context.Background(),
+		ref,
+		image.PullOptions{RegistryAuth: driver.RegistryAuth},
+	)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
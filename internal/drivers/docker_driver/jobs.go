@@ -0,0 +1,153 @@
+package dockerDriver
+
+import (
+	"bytes"
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"errors"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// maxOutputSize is the number of trailing bytes of the output of the one-off containers that is kept.
+const maxOutputSize = 64 * 1024
+
+// RunJob runs a command in a one-off container from the image of a deployment, named after the job.
+func (driver *DockerDriver) RunJob(app models.Application, depl models.Deployment, opts drivers.JobOptions) (drivers.JobResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	// The "deployment_id" label is left out, as the exit of the container doesn't tell anything about the deployment.
+	labels := map[string]string{
+		logLabelApplicationID: app.ID,
+		"job":                 opts.Name,
+	}
+
+	exitCode, output, err := driver.runOneOff(ctx, app, depl, app.ID+"-job-"+opts.Name, opts.Cmd, labels)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return drivers.JobResult{ExitCode: int(exitCode), Output: output}, drivers.ErrJobTimedOut
+	}
+	if err != nil {
+		return drivers.JobResult{}, err
+	}
+
+	return drivers.JobResult{ExitCode: int(exitCode), Output: output}, nil
+}
+
+// runOneOff runs a command in a one-off container from the image of a deployment, with the application's
+// environment and volumes, and waits for it to exit, or for the context to be done. It returns the exit code of the command,
+// or -1 if it didn't exit, and its trailing output. The container is removed afterwards.
+func (driver *DockerDriver) runOneOff(ctx context.Context, app models.Application, depl models.Deployment, containerName string, command string, labels map[string]string) (int64, string, error) {
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return -1, "", err
+	}
+
+	volumes, err := driver.VolumesRepo.FindAllFromApplication(ctx, app.ID)
+	if err != nil {
+		return -1, "", err
+	}
+
+	imageRef := drivers.ImageReference(app, depl)
+	if err := driver.pullImage(imageRef); err != nil {
+		return -1, "", err
+	}
+
+	// The releases and jobs reach the databases of the organization on its private network, e.g. to migrate them.
+	if err := driver.ensureOrgNetwork(app.OrganizationID); err != nil {
+		return -1, "", err
+	}
+
+	if driver.ContainerExists(containerName) {
+		if err := driver.retireContainer(containerName); err != nil {
+			return -1, "", err
+		}
+	}
+
+	labels["traefik.enable"] = "false"
+
+	ct, err := driver.Client.ContainerCreate(
+		ctx,
+		&container.Config{
+			Image:      imageRef,
+			Entrypoint: []string{"/bin/sh", "-c"},
+			Cmd:        []string{command},
+			Env:        applicationEnv(app),
+			Labels:     labels,
+		},
+		&container.HostConfig{
+			Resources: containerResources(specs),
+			Mounts:    volumeMounts(volumes),
+		},
+		&network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				orgNetworkName(app.OrganizationID): {NetworkID: orgNetworkName(app.OrganizationID)},
+			},
+		},
+		nil,
+		containerName,
+	)
+	if err != nil {
+		return -1, "", err
+	}
+	defer driver.retireContainer(ct.ID)
+
+	if err := driver.Client.ContainerStart(ctx, ct.ID, container.StartOptions{}); err != nil {
+		return -1, "", err
+	}
+
+	exitCode := int64(-1)
+	statusCh, errCh := driver.Client.ContainerWait(ctx, ct.ID, container.WaitConditionNotRunning)
+	select {
+	case err = <-errCh:
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	}
+
+	// The output is kept even if the command didn't exit in time.
+	output, outputErr := driver.readContainerOutput(ct.ID)
+	if err != nil {
+		return exitCode, output, err
+	}
+	if outputErr != nil {
+		return exitCode, "", outputErr
+	}
+
+	return exitCode, output, nil
+}
+
+// readContainerOutput returns the trailing stdout and stderr output of a container.
+func (driver *DockerDriver) readContainerOutput(containerID string) (string, error) {
+	reader, err := driver.Client.ContainerLogs(context.Background(), containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := stdcopy.StdCopy(buf, buf, reader); err != nil {
+		return "", err
+	}
+
+	output := buf.Bytes()
+	if len(output) > maxOutputSize {
+		output = output[len(output)-maxOutputSize:]
+	}
+
+	return string(output), nil
+}
+
+// applicationEnv returns the environment variables of an application, in the "KEY=value" form.
+func applicationEnv(app models.Application) []string {
+	envList := []string{}
+	for key, value := range app.GetEnv() {
+		envList = append(envList, key+"="+value)
+	}
+	return envList
+}
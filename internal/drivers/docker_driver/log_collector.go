@@ -0,0 +1,114 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// The containers whose output is collected are labelled with the application and deployment it belongs to,
+// and the replicas with their process type. Builders and release containers don't carry the "application_id"
+// label, which identifies the replicas.
+const (
+	logLabelSource        = "logs.source"
+	logLabelApplicationID = "logs.application_id"
+	logLabelDeploymentID  = "logs.deployment_id"
+	logLabelProcess       = "logs.process"
+)
+
+// logLabels returns the labels telling the log collector where the output of a container comes from.
+func logLabels(app models.Application, depl models.Deployment, source models.LogEntrySource) map[string]string {
+	return map[string]string{
+		logLabelSource:        string(source),
+		logLabelApplicationID: app.ID,
+		logLabelDeploymentID:  depl.ID,
+	}
+}
+
+// startLogCollector stores the log entries collected from the containers, and starts collecting
+// the ones of the containers already running. The others are picked up as they start (see handleEvent).
+func (d *DockerDriver) startLogCollector() error {
+	d.logEntries = make(chan models.LogEntry, 4*drivers.LogBatchSize)
+	go d.deployments.StoreLogEntries(d.logEntries)
+
+	containers, err := d.Client.ContainerList(context.Background(), container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", logLabelSource)),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ct := range containers {
+		go d.collectLogs(ct.ID, ct.Labels)
+	}
+
+	return nil
+}
+
+// collectLogs follows the output of a container until it stops. When the collection resumes
+// (e.g. after a restart of Citadel), it starts right after the last entry stored for the container.
+func (d *DockerDriver) collectLogs(containerID string, labels map[string]string) {
+	if _, collecting := d.collectedContainers.LoadOrStore(containerID, struct{}{}); collecting {
+		return
+	}
+	defer d.collectedContainers.Delete(containerID)
+
+	instance := containerID
+	if len(instance) > 12 {
+		instance = instance[:12]
+	}
+
+	opts := container.LogsOptions{
+		Follow:     true,
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	}
+
+	latest, err := d.LogsRepo.FindLatestTimestampOfInstance(context.Background(), instance)
+	if err != nil {
+		slog.Error("Failed to resume log collection", "error", err, "container_id", containerID)
+		return
+	}
+	if !latest.IsZero() {
+		latest = latest.Add(time.Nanosecond)
+		opts.Since = fmt.Sprintf("%d.%09d", latest.Unix(), latest.Nanosecond())
+	}
+
+	reader, err := d.Client.ContainerLogs(context.Background(), containerID, opts)
+	if err != nil {
+		slog.Error("Failed to collect logs", "error", err, "container_id", containerID)
+		return
+	}
+	defer reader.Close()
+
+	collect := func(stream models.LogEntryStream) *lineWriter {
+		return &lineWriter{onLine: func(line string) {
+			timestamp, message := splitTimestamp(line)
+			d.logEntries <- models.LogEntry{
+				ApplicationID: labels[logLabelApplicationID],
+				DeploymentID:  labels[logLabelDeploymentID],
+				Source:        models.LogEntrySource(labels[logLabelSource]),
+				Process:       labels[logLabelProcess],
+				Stream:        stream,
+				Instance:      instance,
+				Message:       message,
+				Timestamp:     timestamp,
+			}
+		}}
+	}
+	stdout := collect(models.LogEntryStreamStdout)
+	stderr := collect(models.LogEntryStreamStderr)
+
+	if err := demuxLogs(reader, d.isTtyContainer(containerID), stdout, stderr); err != nil {
+		slog.Error("Log collection interrupted", "error", err, "container_id", containerID)
+	}
+	stdout.flush()
+	stderr.flush()
+}
@@ -0,0 +1,78 @@
+package dockerDriver
+
+import (
+	"bytes"
+	"citadel/internal/drivers"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// demuxLogs splits the logs of a container into its stdout and stderr. The output of the containers
+// with a terminal isn't multiplexed, and goes to stdout as it is.
+func demuxLogs(reader io.Reader, tty bool, stdout io.Writer, stderr io.Writer) error {
+	if tty {
+		_, err := io.Copy(stdout, reader)
+		return err
+	}
+	_, err := stdcopy.StdCopy(stdout, stderr, reader)
+	return err
+}
+
+// isTtyContainer reports whether a container has a terminal allocated.
+func (d *DockerDriver) isTtyContainer(containerID string) bool {
+	info, err := d.Client.ContainerInspect(context.Background(), containerID)
+	return err == nil && info.Config != nil && info.Config.Tty
+}
+
+// lineWriter calls onLine for each line written to it.
+type lineWriter struct {
+	onLine  func(line string)
+	pending []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(sanitizeLogLine(string(w.pending[:i])))
+		w.pending = w.pending[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush handles the last line of the output, if it didn't end with a newline.
+func (w *lineWriter) flush() {
+	if len(w.pending) > 0 {
+		w.onLine(sanitizeLogLine(string(w.pending)))
+		w.pending = nil
+	}
+}
+
+// sanitizeLogLine truncates a line of logs, and strips the characters that can't be stored or displayed.
+func sanitizeLogLine(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	if len(line) > drivers.MaxLogLineSize {
+		line = line[:drivers.MaxLogLineSize]
+	}
+	return strings.ToValidUTF8(strings.ReplaceAll(line, "\x00", ""), "")
+}
+
+// splitTimestamp splits a line of logs into the timestamp Docker prefixes it with and its message.
+// Lines without a timestamp are timestamped with the current time.
+func splitTimestamp(line string) (time.Time, string) {
+	if timestamp, message, found := strings.Cut(line, " "); found {
+		if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+			return t, message
+		}
+	}
+	return time.Now(), line
+}
@@ -0,0 +1,157 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// logStream is the followed output of the replicas of one of the services of an application,
+// or of its standalone container.
+type logStream struct {
+	io.ReadCloser
+	tty bool
+
+	// service tells whether the lines come from the replicas of a service, which are prefixed with their details
+	// (see splitLogDetails). Otherwise, they all come from the given deployment.
+	service      bool
+	deploymentID string
+
+	// process is the process type the lines come from.
+	process string
+}
+
+// openLogStreams follows the logs of the replicas of an application, with a stream for each of its process types,
+// or of its standalone container if it hasn't been deployed as a swarm service yet.
+// It returns no stream if the application isn't running.
+func (d *DockerDriver) openLogStreams(ctx context.Context, app models.Application) ([]*logStream, error) {
+	opts := container.LogsOptions{
+		Follow:     true,
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Tail:       "50",
+	}
+
+	if svc, _, err := d.Client.ServiceInspectWithRaw(ctx, app.ID, types.ServiceInspectOptions{}); err == nil {
+		processServices, err := d.listProcessServices(app)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Details = true
+		streams := []*logStream{}
+		for _, svc := range append([]swarm.Service{svc}, processServices...) {
+			process := svc.Spec.Labels[labelProcess]
+			if process == "" {
+				process = models.ProcessTypeWeb
+			}
+
+			reader, err := d.Client.ServiceLogs(ctx, svc.ID, opts)
+			if err != nil {
+				for _, stream := range streams {
+					stream.Close()
+				}
+				return nil, err
+			}
+			streams = append(streams, &logStream{ReadCloser: reader, tty: svc.Spec.TaskTemplate.ContainerSpec.TTY, service: true, process: process})
+		}
+		return streams, nil
+	}
+
+	containerID, err := d.findApplicationContainer(app)
+	if err != nil || containerID == "" {
+		return nil, err
+	}
+
+	info, err := d.Client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := d.Client.ContainerLogs(ctx, containerID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*logStream{{ReadCloser: reader, tty: info.Config.Tty, deploymentID: info.Config.Labels["deployment_id"], process: models.ProcessTypeWeb}}, nil
+}
+
+// followLogStream sends the events of the lines of a log stream, until it ends or the client is gone.
+func (d *DockerDriver) followLogStream(stream *logStream, events chan<- models.LogEvent, closed <-chan struct{}) error {
+	// The deployments of the replicas, by task ID.
+	taskDeployments := map[string]string{}
+
+	send := func(streamType models.LogEntryStream) *lineWriter {
+		return &lineWriter{onLine: func(line string) {
+			timestamp, message := splitTimestamp(line)
+
+			deploymentID := stream.deploymentID
+			if stream.service {
+				var details map[string]string
+				details, message = splitLogDetails(message)
+				deploymentID = d.findTaskDeployment(details["com.docker.swarm.task.id"], taskDeployments)
+			}
+
+			event := models.NewLogEvent(timestamp, streamType, deploymentID, message)
+			event.Process = stream.process
+
+			select {
+			case events <- event:
+			case <-closed:
+			}
+		}}
+	}
+	stdout := send(models.LogEntryStreamStdout)
+	stderr := send(models.LogEntryStreamStderr)
+
+	err := demuxLogs(stream, stream.tty, stdout, stderr)
+	stdout.flush()
+	stderr.flush()
+
+	return err
+}
+
+// splitLogDetails splits a line of the logs of a service into the details Docker prefixes it with
+// (e.g. "com.docker.swarm.task.id=...,com.docker.swarm.node.id=...") and its message.
+func splitLogDetails(line string) (map[string]string, string) {
+	prefix, message, _ := strings.Cut(line, " ")
+	if !strings.Contains(prefix, "=") {
+		return nil, line
+	}
+
+	details := map[string]string{}
+	for _, pair := range strings.Split(prefix, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		details[key] = value
+	}
+
+	return details, message
+}
+
+// findTaskDeployment returns the deployment a replica runs, caching it by task ID.
+func (d *DockerDriver) findTaskDeployment(taskID string, cache map[string]string) string {
+	if taskID == "" {
+		return ""
+	}
+	if deploymentID, ok := cache[taskID]; ok {
+		return deploymentID
+	}
+
+	task, _, err := d.Client.TaskInspectWithRaw(context.Background(), taskID)
+	if err != nil || task.Spec.ContainerSpec == nil {
+		return ""
+	}
+
+	cache[taskID] = task.Spec.ContainerSpec.Labels["deployment_id"]
+	return cache[taskID]
+}
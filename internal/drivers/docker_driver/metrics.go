@@ -0,0 +1,313 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+)
+
+const (
+	// metricsInterval is how often the resource usage of the containers is sampled.
+	metricsInterval = models.MetricResolutionRaw * time.Second
+
+	// metricsConcurrency is the number of containers whose stats are read at once. Reading them takes a second or two,
+	// as Docker waits for a second reading to compute the CPU usage.
+	metricsConcurrency = 16
+
+	// maxMetricPoints is the number of periods the samples returned by Metrics are aggregated into, at most.
+	maxMetricPoints = 120
+)
+
+// metricsKey identifies the samples of a process type of an application, or of a database.
+type metricsKey struct {
+	resourceType models.MetricResourceType
+	resourceID   string
+	process      string
+}
+
+// networkCounters are the bytes received and sent by a container since it started.
+type networkCounters struct {
+	rx uint64
+	tx uint64
+}
+
+// collectMetricsPeriodically samples the resource usage of the replicas of the applications and of the databases
+// running on this node every metricsInterval, forever.
+func (d *DockerDriver) collectMetricsPeriodically() {
+	ticker := time.NewTicker(metricsInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if err := d.collectMetrics(now.Truncate(metricsInterval)); err != nil {
+			slog.Error("Failed to collect metrics", "error", err)
+		}
+	}
+}
+
+// collectMetrics samples the resource usage of the containers, and stores it summed up by application process type
+// and by database, along with the restarts since the previous sample.
+func (d *DockerDriver) collectMetrics(timestamp time.Time) error {
+	ctx := context.Background()
+
+	containers, err := d.Client.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	databases, err := d.DatabasesRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	databaseIDs := make(map[string]string, len(databases))
+	for _, db := range databases {
+		databaseIDs["/citadel-"+db.Slug] = db.ID
+	}
+
+	keys := make(map[string]metricsKey)
+	for _, ct := range containers {
+		if key, ok := containerMetricsKey(ct, databaseIDs); ok {
+			keys[ct.ID] = key
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, metricsConcurrency)
+		samples = make(map[metricsKey]*models.MetricSample)
+	)
+	for containerID, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(containerID string, key metricsKey) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			stats, err := d.containerStats(ctx, containerID)
+			if err != nil {
+				slog.Warn("Failed to read container stats", "error", err, "container_id", containerID)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			sample, ok := samples[key]
+			if !ok {
+				sample = newMetricSample(key, timestamp)
+				samples[key] = sample
+			}
+			d.addContainerStats(sample, containerID, stats)
+		}(containerID, key)
+	}
+	wg.Wait()
+
+	// The counters of the containers that are gone are forgotten.
+	for containerID := range d.networkCounters {
+		if _, running := keys[containerID]; !running {
+			delete(d.networkCounters, containerID)
+		}
+	}
+
+	// The restarts are reported even when no instance is running anymore, e.g. when they all crash.
+	for key, restarts := range d.takeRestarts() {
+		sample, ok := samples[key]
+		if !ok {
+			sample = newMetricSample(key, timestamp)
+			samples[key] = sample
+		}
+		sample.Restarts = restarts
+	}
+
+	batch := make([]models.MetricSample, 0, len(samples))
+	for _, sample := range samples {
+		batch = append(batch, *sample)
+	}
+
+	return d.MetricsRepo.CreateMany(ctx, batch)
+}
+
+// containerMetricsKey returns what the resource usage of a container is accounted to, if anything. The replicas
+// of the applications are labelled with their application and process type, and the databases are named after their slug.
+func containerMetricsKey(ct types.Container, databaseIDs map[string]string) (metricsKey, bool) {
+	if appID := ct.Labels["application_id"]; appID != "" {
+		process := ct.Labels[logLabelProcess]
+		if process == "" {
+			process = models.ProcessTypeWeb
+		}
+		return metricsKey{resourceType: models.MetricResourceApplication, resourceID: appID, process: process}, true
+	}
+
+	for _, name := range ct.Names {
+		if dbID, ok := databaseIDs[name]; ok {
+			return metricsKey{resourceType: models.MetricResourceDatabase, resourceID: dbID}, true
+		}
+	}
+
+	return metricsKey{}, false
+}
+
+func newMetricSample(key metricsKey, timestamp time.Time) *models.MetricSample {
+	return &models.MetricSample{
+		ResourceType: key.resourceType,
+		ResourceID:   key.resourceID,
+		Process:      key.process,
+		Resolution:   models.MetricResolutionRaw,
+		Timestamp:    timestamp,
+	}
+}
+
+// containerStats reads the resource usage of a container.
+func (d *DockerDriver) containerStats(ctx context.Context, containerID string) (types.StatsJSON, error) {
+	var stats types.StatsJSON
+
+	resp, err := d.Client.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return stats, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	return stats, err
+}
+
+// addContainerStats adds the resource usage of a container to a sample. Its network traffic is the one since
+// its previous sample, or none for its first one.
+func (d *DockerDriver) addContainerStats(sample *models.MetricSample, containerID string, stats types.StatsJSON) {
+	sample.Instances++
+	sample.CPUPercent += cpuPercent(stats)
+
+	// The page cache is left out, as the kernel reclaims it under memory pressure, like `docker stats` does.
+	memory := stats.MemoryStats.Usage
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if inactive, ok := stats.MemoryStats.Stats[key]; ok && inactive < memory {
+			memory -= inactive
+			break
+		}
+	}
+	sample.MemoryUsage += int64(memory)
+	sample.MemoryLimit += int64(stats.MemoryStats.Limit)
+
+	current := networkCounters{}
+	for _, network := range stats.Networks {
+		current.rx += network.RxBytes
+		current.tx += network.TxBytes
+	}
+	if previous, ok := d.networkCounters[containerID]; ok && current.rx >= previous.rx && current.tx >= previous.tx {
+		sample.NetworkRx += int64(current.rx - previous.rx)
+		sample.NetworkTx += int64(current.tx - previous.tx)
+	}
+	d.networkCounters[containerID] = current
+}
+
+// cpuPercent returns the CPU usage of a container between the two readings of its stats, in percent of one CPU.
+func cpuPercent(stats types.StatsJSON) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// recordRestart counts an unexpected exit of a container towards the next sample of its resource.
+func (d *DockerDriver) recordRestart(key metricsKey) {
+	d.restartsMu.Lock()
+	defer d.restartsMu.Unlock()
+
+	d.restarts[key]++
+}
+
+// takeRestarts returns the restarts counted since the previous sample, and starts counting again.
+func (d *DockerDriver) takeRestarts() map[metricsKey]int {
+	d.restartsMu.Lock()
+	defer d.restartsMu.Unlock()
+
+	restarts := d.restarts
+	d.restarts = make(map[metricsKey]int)
+	return restarts
+}
+
+// handleMetricsEvent counts the containers of the applications and databases that exit without being stopped
+// on purpose (e.g. by a rollout, a scale-down or a deletion), which are killed first, as restarts.
+func (driver *DockerDriver) handleMetricsEvent(ce containerEvent) {
+	switch ce.Action {
+	case events.ActionKill:
+		driver.killedContainers.Store(ce.ContainerID, struct{}{})
+	case events.ActionDestroy:
+		driver.killedContainers.Delete(ce.ContainerID)
+	case events.ActionDie:
+		if _, killed := driver.killedContainers.Load(ce.ContainerID); killed {
+			return
+		}
+		if _, retired := driver.retiredContainers.Load(ce.ContainerID); retired {
+			return
+		}
+
+		if appID := ce.Attributes["application_id"]; appID != "" && ce.IsTask {
+			process := ce.Attributes[logLabelProcess]
+			if process == "" {
+				process = models.ProcessTypeWeb
+			}
+			driver.recordRestart(metricsKey{resourceType: models.MetricResourceApplication, resourceID: appID, process: process})
+			return
+		}
+
+		slug, ok := strings.CutPrefix(ce.Attributes["name"], "citadel-")
+		if !ok {
+			return
+		}
+		db, err := driver.DatabasesRepo.FindOneBy(context.Background(), "slug", slug)
+		if err != nil {
+			return
+		}
+		driver.recordRestart(metricsKey{resourceType: models.MetricResourceDatabase, resourceID: db.ID})
+	}
+}
+
+// Metrics aggregates the samples of a resource at the finest resolution still kept for the whole period.
+func (d *DockerDriver) Metrics(ctx context.Context, query drivers.MetricsQuery) ([]models.MetricSample, error) {
+	resolution, step := metricsStep(query.Since, query.Until, time.Now())
+
+	return d.MetricsRepo.Aggregate(ctx, repositories.MetricSamplesQuery{
+		ResourceType: query.ResourceType,
+		ResourceID:   query.ResourceID,
+		Process:      query.Process,
+		Resolution:   resolution,
+		Step:         step,
+		Since:        query.Since,
+		Until:        query.Until,
+	})
+}
+
+// metricsStep returns the finest resolution whose samples are still kept since a given time, and the length
+// of the periods, a multiple of it, the samples until another time are aggregated into.
+func metricsStep(since time.Time, until time.Time, now time.Time) (int, int) {
+	resolution := models.MetricResolutionHour
+	for _, candidate := range []int{models.MetricResolutionRaw, models.MetricResolutionMinute} {
+		if now.Sub(since) <= models.MetricRetentions[candidate] {
+			resolution = candidate
+			break
+		}
+	}
+
+	periods := math.Ceil(until.Sub(since).Seconds() / float64(resolution) / maxMetricPoints)
+	return resolution, resolution * int(math.Max(periods, 1))
+}
@@ -0,0 +1,214 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"log/slog"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+// labelOrganizationID is the label of the private networks of the organizations, and of the containers
+// of their databases.
+const labelOrganizationID = "organization_id"
+
+// orgNetworkName returns the name of the private network of an organization. The applications and databases
+// of the organization are attached to it, and reachable from each other at their internal hostnames
+// (e.g. "my-app.internal"), while the ones of the other organizations aren't attached to it.
+func orgNetworkName(orgID string) string {
+	return "citadel_org_" + orgID
+}
+
+// serviceNetworks returns the network attachments of the swarm service of an application, which is reachable
+// at its internal hostname on the private network of its organization.
+func serviceNetworks(app models.Application) []swarm.NetworkAttachmentConfig {
+	return []swarm.NetworkAttachmentConfig{{
+		Target:  orgNetworkName(app.OrganizationID),
+		Aliases: []string{app.InternalHostname()},
+	}}
+}
+
+// ensureOrgNetwork creates the private network of an organization, unless it exists, and connects Traefik to it,
+// so that it can route the traffic of the applications and databases attached to it. The network is an attachable
+// overlay one, which both the swarm services and the standalone containers (e.g. databases and jobs) can join.
+func (d *DockerDriver) ensureOrgNetwork(orgID string) error {
+	ctx := context.Background()
+	name := orgNetworkName(orgID)
+
+	// Overlay networks aren't unique by name, so they're never created twice at once.
+	d.networksMu.Lock()
+	defer d.networksMu.Unlock()
+
+	if _, err := d.Client.NetworkInspect(ctx, name, types.NetworkInspectOptions{}); err != nil {
+		if !client.IsErrNotFound(err) {
+			return err
+		}
+		if _, err := d.Client.NetworkCreate(ctx, name, types.NetworkCreate{
+			Driver:     "overlay",
+			Attachable: true,
+			Labels:     map[string]string{labelOrganizationID: orgID},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return d.connectTraefik(ctx, name)
+}
+
+// connectTraefik connects the Traefik container to a network, unless it is already.
+func (d *DockerDriver) connectTraefik(ctx context.Context, network string) error {
+	info, err := d.Client.ContainerInspect(ctx, traefikContainerName)
+	if err != nil {
+		slog.Warn("Traefik container not found, applications won't be reachable", "error", err, "container", traefikContainerName)
+		return nil
+	}
+	if _, connected := info.NetworkSettings.Networks[network]; connected {
+		return nil
+	}
+
+	return d.Client.NetworkConnect(ctx, network, info.ID, nil)
+}
+
+// connectTraefikToOrgNetworks connects Traefik to the private networks of all the organizations,
+// e.g. after its container has been recreated.
+func (d *DockerDriver) connectTraefikToOrgNetworks() error {
+	ctx := context.Background()
+
+	networks, err := d.Client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", labelOrganizationID)),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, network := range networks {
+		if err := d.connectTraefik(ctx, network.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachToOrgNetworks attaches the services and standalone containers of the applications, and the containers
+// of the databases, created before the organizations got their own private network to the one of their organization,
+// so that they are reachable at their internal hostnames without being redeployed. The services are updated,
+// which rolls their replicas, and keep their former networks.
+func (d *DockerDriver) attachToOrgNetworks() error {
+	ctx := context.Background()
+
+	apps, err := d.AppsRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	appsByID := make(map[string]models.Application, len(apps))
+	for _, app := range apps {
+		appsByID[app.ID] = app
+	}
+
+	services, err := d.Client.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "application_id")),
+	})
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		app, ok := appsByID[svc.Spec.Labels["application_id"]]
+		if !ok {
+			continue
+		}
+		if err := d.attachServiceToOrgNetwork(ctx, app, svc); err != nil {
+			slog.Warn("Failed to attach service to the network of its organization", "error", err, "service", svc.Spec.Name)
+		}
+	}
+
+	containers, err := d.Client.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "application_id")),
+	})
+	if err != nil {
+		return err
+	}
+	for _, ct := range containers {
+		app, ok := appsByID[ct.Labels["application_id"]]
+		if _, isTask := ct.Labels["com.docker.swarm.task.id"]; isTask || !ok {
+			continue
+		}
+		if err := d.attachContainerToOrgNetwork(ctx, ct.ID, app.OrganizationID, app.InternalHostname()); err != nil {
+			slog.Warn("Failed to attach container to the network of its organization", "error", err, "container_id", ct.ID, "app_id", app.ID)
+		}
+	}
+
+	databases, err := d.DatabasesRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, db := range databases {
+		err := d.attachContainerToOrgNetwork(ctx, "citadel-"+db.Slug, db.OrganizationID, db.InternalHostname())
+		if err != nil && !client.IsErrNotFound(err) {
+			slog.Warn("Failed to attach database to the network of its organization", "error", err, "db_id", db.ID)
+		}
+	}
+
+	return nil
+}
+
+// attachServiceToOrgNetwork attaches a service of an application to the private network of its organization,
+// unless it is already. The web service gets the internal hostname of the application on it.
+func (d *DockerDriver) attachServiceToOrgNetwork(ctx context.Context, app models.Application, svc swarm.Service) error {
+	if err := d.ensureOrgNetwork(app.OrganizationID); err != nil {
+		return err
+	}
+
+	name := orgNetworkName(app.OrganizationID)
+	orgNetwork, err := d.Client.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Swarm refers to the networks of the services by ID.
+	for _, attachment := range svc.Spec.TaskTemplate.Networks {
+		if attachment.Target == orgNetwork.ID || attachment.Target == name {
+			return nil
+		}
+	}
+
+	attachment := swarm.NetworkAttachmentConfig{Target: name}
+	if _, isProcess := svc.Spec.Labels[labelProcess]; !isProcess {
+		attachment = serviceNetworks(app)[0]
+	}
+	svc.Spec.TaskTemplate.Networks = append(svc.Spec.TaskTemplate.Networks, attachment)
+
+	if spec := svc.Spec.TaskTemplate.ContainerSpec; spec != nil && spec.Labels["traefik.enable"] == "true" {
+		spec.Labels["traefik.docker.network"] = name
+	}
+
+	_, err = d.Client.ServiceUpdate(ctx, svc.ID, svc.Version, svc.Spec, types.ServiceUpdateOptions{})
+	return err
+}
+
+// attachContainerToOrgNetwork connects a container to the private network of an organization, unless it is already,
+// with an internal hostname.
+func (d *DockerDriver) attachContainerToOrgNetwork(ctx context.Context, containerID string, orgID string, hostname string) error {
+	info, err := d.Client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	name := orgNetworkName(orgID)
+	if _, connected := info.NetworkSettings.Networks[name]; connected {
+		return nil
+	}
+
+	if err := d.ensureOrgNetwork(orgID); err != nil {
+		return err
+	}
+
+	return d.Client.NetworkConnect(ctx, name, info.ID, &network.EndpointSettings{
+		Aliases: []string{hostname},
+	})
+}
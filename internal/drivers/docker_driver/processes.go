@@ -0,0 +1,163 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+// labelProcess is the label of the services running the process types other than the web one,
+// which holds the name of their process type.
+const labelProcess = "process"
+
+// processServiceName returns the name of the swarm service running a process type of an application.
+func processServiceName(app models.Application, name string) string {
+	return app.ID + "-" + name
+}
+
+// processServiceSpec returns the specification of the swarm service running a process type of a deployment.
+// Its replicas don't receive any traffic, and are considered healthy once they've kept running for a while.
+// They are attached to the private network of the organization, without any internal hostname.
+// The volumes attached to the application are mounted in them too.
+func processServiceSpec(app models.Application, depl models.Deployment, process models.ProcessType, specs models.ComputingSpecs, volumes []models.Volume) swarm.ServiceSpec {
+	replicas := uint64(process.GetReplicas())
+
+	labels := logLabels(app, depl, models.LogEntrySourceApp)
+	labels[logLabelProcess] = process.Name
+	labels["application_id"] = app.ID
+	labels["deployment_id"] = depl.ID
+
+	return swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name: processServiceName(app, process.Name),
+			Labels: map[string]string{
+				"application_id": app.ID,
+				labelProcess:     process.Name,
+			},
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:   drivers.ImageReference(app, depl),
+				Command: drivers.ProcessCommand(depl.Processes[process.Name]),
+				Env:     applicationEnv(app),
+				Labels:  labels,
+				Mounts:  volumeMounts(volumes),
+			},
+			Resources:     serviceResources(specs),
+			RestartPolicy: &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionAny},
+			Networks:      []swarm.NetworkAttachmentConfig{{Target: orgNetworkName(app.OrganizationID)}},
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+		},
+		UpdateConfig: &swarm.UpdateConfig{
+			Parallelism:   1,
+			FailureAction: swarm.UpdateFailureActionRollback,
+			Monitor:       rolloutGracePeriod,
+			Order:         swarm.UpdateOrderStartFirst,
+		},
+		RollbackConfig: &swarm.UpdateConfig{
+			Parallelism:   1,
+			FailureAction: swarm.UpdateFailureActionPause,
+			Monitor:       rolloutGracePeriod,
+			Order:         swarm.UpdateOrderStartFirst,
+		},
+	}
+}
+
+// deployProcessServices creates the services of the process types declared by a deployment besides the web one,
+// or rolls the deployment out to them.
+func (d *DockerDriver) deployProcessServices(app models.Application, depl models.Deployment) error {
+	processes, err := d.ProcessTypesRepo.FindAllFromApplication(context.Background(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := d.VolumesRepo.FindAllFromApplication(context.Background(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range depl.BackgroundProcessTypes() {
+		// The process types are declared once a deployment declaring them succeeds, so the ones
+		// new to this deployment get a single instance until then.
+		process := models.ProcessType{Name: name, Replicas: 1}
+		for _, p := range processes {
+			if p.Name == name {
+				process = p
+			}
+		}
+
+		specs, err := process.GetComputingSpecs(app)
+		if err != nil {
+			return err
+		}
+
+		if err := d.applyServiceSpec(processServiceSpec(app, depl, process, specs, volumes)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listProcessServices lists the services running the process types of an application other than the web one.
+func (d *DockerDriver) listProcessServices(app models.Application) ([]swarm.Service, error) {
+	return d.Client.ServiceList(context.Background(), types.ServiceListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", "application_id="+app.ID),
+			filters.Arg("label", labelProcess),
+		),
+	})
+}
+
+// removeProcessServices removes the services of the process types of an application that aren't part of
+// the declared ones, i.e. all of them if none is.
+func (d *DockerDriver) removeProcessServices(app models.Application, declared map[string]string) error {
+	services, err := d.listProcessServices(app)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		if _, ok := declared[svc.Spec.Labels[labelProcess]]; ok {
+			continue
+		}
+		if err := d.Client.ServiceRemove(context.Background(), svc.ID); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScaleProcessType sets the number of replicas and the resources of the service of a process type to its own.
+// The process types that haven't been deployed yet get them on their first deployment.
+func (d *DockerDriver) ScaleProcessType(app models.Application, process models.ProcessType) error {
+	svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), processServiceName(app, process.Name), types.ServiceInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	specs, err := process.GetComputingSpecs(app)
+	if err != nil {
+		return err
+	}
+
+	replicas := uint64(process.GetReplicas())
+	svc.Spec.Mode = swarm.ServiceMode{
+		Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+	}
+	svc.Spec.TaskTemplate.Resources = serviceResources(specs)
+
+	_, err = d.Client.ServiceUpdate(context.Background(), svc.ID, svc.Version, svc.Spec, types.ServiceUpdateOptions{})
+	return err
+}
@@ -0,0 +1,286 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// reconcileInterval is how often the deployments and applications are compared with what Docker runs.
+	reconcileInterval = 5 * time.Minute
+
+	// reconcileGracePeriod is how long a deployment is left alone after its status changed,
+	// as its containers may be about to start.
+	reconcileGracePeriod = time.Minute
+)
+
+// reconcilePeriodically runs reconcile every reconcileInterval, forever.
+func (d *DockerDriver) reconcilePeriodically() {
+	for {
+		time.Sleep(reconcileInterval)
+		if err := d.reconcile(); err != nil {
+			slog.Error("Failed to reconcile the deployments", "error", err)
+		}
+	}
+}
+
+// reconcile brings the deployments and applications in line with the containers, services and images Docker
+// actually has, as the events missed while Citadel wasn't running (e.g. during a restart) are lost for good:
+// the deployments stuck in an active status are carried on or marked as failed, the services of the applications
+// that are gone are started again, and the ones of the deleted applications are removed. Every change is logged.
+func (d *DockerDriver) reconcile() error {
+	d.reconcileMu.Lock()
+	defer d.reconcileMu.Unlock()
+
+	ctx := context.Background()
+
+	apps, err := d.AppsRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	active, err := d.DeplsRepo.FindAllActiveUpdatedSince(ctx, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	busyApps := make(map[string]bool)
+	for i := range active {
+		depl := &active[i]
+		busyApps[depl.ApplicationID] = true
+
+		if depl.Application == nil || time.Since(depl.UpdatedAt) < reconcileGracePeriod {
+			continue
+		}
+		if err := d.reconcileDeployment(ctx, depl); err != nil {
+			slog.Error("Failed to reconcile deployment", "error", err, "deployment_id", depl.ID)
+		}
+	}
+
+	knownApps := make(map[string]bool)
+	for _, app := range apps {
+		knownApps[app.ID] = true
+
+		// The applications being deployed get their service from their deployment.
+		if busyApps[app.ID] {
+			continue
+		}
+		if err := d.reconcileApplication(ctx, app); err != nil {
+			slog.Error("Failed to reconcile application", "error", err, "app_id", app.ID)
+		}
+	}
+
+	return d.removeOrphans(ctx, knownApps)
+}
+
+// reconcileDeployment carries on with an active deployment that this process doesn't take care of,
+// or marks it as failed if it can't be.
+func (d *DockerDriver) reconcileDeployment(ctx context.Context, depl *models.Deployment) error {
+	switch depl.Status {
+	case models.DeploymentStatusBuilding:
+		// The builder containers are named after their deployment.
+		info, err := d.Client.ContainerInspect(ctx, depl.ID)
+		if err != nil {
+			if !client.IsErrNotFound(err) {
+				return err
+			}
+			slog.Info("Reconciled deployment: its builder is gone, marking it as failed", "deployment_id", depl.ID)
+			return d.deployments.UpdateStatus(depl, models.DeploymentStatusBuildFailed)
+		}
+		if info.State.Running {
+			return nil
+		}
+
+		slog.Info("Reconciled deployment: its builder exited unnoticed, handling its outcome", "deployment_id", depl.ID, "exit_code", info.State.ExitCode)
+		return d.handleBuildExit(depl, info.State.ExitCode == 0)
+
+	case models.DeploymentStatusReleasing:
+		if _, running := d.releases.Load(depl.ID); running {
+			return nil
+		}
+
+		// The release command may still be running, but its outcome would go unnoticed.
+		slog.Info("Reconciled deployment: its release was interrupted, marking it as failed", "deployment_id", depl.ID)
+		if err := d.retireContainer(depl.ID + "-release"); err != nil {
+			return err
+		}
+		return d.deployments.UpdateStatus(depl, models.DeploymentStatusReleaseFailed)
+
+	case models.DeploymentStatusDeploying:
+		if _, monitored := d.rollouts.Load(depl.ID); monitored {
+			return nil
+		}
+
+		svc, _, err := d.Client.ServiceInspectWithRaw(ctx, depl.ApplicationID, types.ServiceInspectOptions{})
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+		if err == nil && svc.Spec.TaskTemplate.ContainerSpec != nil && svc.Spec.TaskTemplate.ContainerSpec.Labels["deployment_id"] == depl.ID {
+			slog.Info("Reconciled deployment: resuming the monitoring of its rollout", "deployment_id", depl.ID)
+			d.rollouts.Store(depl.ID, struct{}{})
+			go d.completeRollout(*depl.Application, *depl)
+			return nil
+		}
+
+		slog.Info("Reconciled deployment: its rollout never started, marking it as failed", "deployment_id", depl.ID)
+		return d.deployments.UpdateStatus(depl, models.DeploymentStatusDeployFailed)
+	}
+
+	return nil
+}
+
+// reconcileApplication starts the services of an application again, with its latest successful deployment,
+// if it is gone (e.g. removed by hand, or lost with the swarm).
+func (d *DockerDriver) reconcileApplication(ctx context.Context, app models.Application) error {
+	depl, err := d.DeplsRepo.FindLatestSuccessfulFromApplication(ctx, app.ID)
+	if err != nil || depl == nil {
+		return err
+	}
+
+	_, _, err = d.Client.ServiceInspectWithRaw(ctx, app.ID, types.ServiceInspectOptions{})
+	if err == nil {
+		return d.reconcileProcessServices(ctx, app, *depl)
+	}
+	if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	// Applications deployed before swarm services run in standalone containers.
+	containerID, err := d.findApplicationContainer(app)
+	if err != nil || containerID != "" {
+		return err
+	}
+
+	slog.Info("Reconciled application: its service is missing, starting it again", "app_id", app.ID, "deployment_id", depl.ID)
+	return d.deployService(app, *depl)
+}
+
+// reconcileProcessServices starts the services of the process types of an application again, if any is gone.
+func (d *DockerDriver) reconcileProcessServices(ctx context.Context, app models.Application, depl models.Deployment) error {
+	for _, name := range depl.BackgroundProcessTypes() {
+		_, _, err := d.Client.ServiceInspectWithRaw(ctx, processServiceName(app, name), types.ServiceInspectOptions{})
+		if err == nil {
+			continue
+		}
+		if !client.IsErrNotFound(err) {
+			return err
+		}
+
+		slog.Info("Reconciled application: the service of a process type is missing, starting it again", "app_id", app.ID, "deployment_id", depl.ID, "process", name)
+		return d.deployProcessServices(app, depl)
+	}
+
+	return nil
+}
+
+// removeOrphans removes the services, containers and images of the applications that don't exist anymore.
+// The applications missing from knownApps are looked up again before anything of theirs is removed,
+// as they may have been created after it was listed (see isDeletedApp).
+func (d *DockerDriver) removeOrphans(ctx context.Context, knownApps map[string]bool) error {
+	services, err := d.Client.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", "application_id")),
+	})
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		appID := svc.Spec.Labels["application_id"]
+		if !d.isDeletedApp(ctx, knownApps, appID) {
+			continue
+		}
+		slog.Info("Reconciled application: removing the service of a deleted application", "app_id", appID, "service_id", svc.ID)
+		if err := d.Client.ServiceRemove(ctx, svc.ID); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+
+	// The replicas carry the "application_id" label, and the builders and release containers the one of the logs.
+	for _, label := range []string{"application_id", logLabelApplicationID} {
+		containers, err := d.Client.ContainerList(ctx, container.ListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("label", label)),
+		})
+		if err != nil {
+			return err
+		}
+		for _, ct := range containers {
+			// The replicas of the services are removed along with them.
+			if _, isTask := ct.Labels["com.docker.swarm.task.id"]; isTask {
+				continue
+			}
+			appID := ct.Labels[label]
+			if !d.isDeletedApp(ctx, knownApps, appID) {
+				continue
+			}
+			slog.Info("Reconciled application: removing a container of a deleted application", "app_id", appID, "container_id", ct.ID)
+			if err := d.retireContainer(ct.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return d.removeOrphanedImages(ctx, knownApps)
+}
+
+// isDeletedApp reports whether an application doesn't exist anymore. The ones missing from knownApps are looked up
+// in the database, and added to it if they exist, e.g. when they were created during the reconciliation.
+// When the lookup fails, the application is assumed to exist, so that nothing of it is removed by mistake.
+func (d *DockerDriver) isDeletedApp(ctx context.Context, knownApps map[string]bool, appID string) bool {
+	if knownApps[appID] {
+		return false
+	}
+
+	exists, err := d.AppsRepo.Exists(ctx, appID)
+	if err != nil {
+		slog.Warn("Failed to look up application", "error", err, "app_id", appID)
+		return false
+	}
+	if exists {
+		knownApps[appID] = true
+	}
+	return !exists
+}
+
+// removeOrphanedImages removes the local copies of the images of the applications that don't exist anymore.
+// Their images are named after them in the registry (see drivers.ImageReference).
+func (d *DockerDriver) removeOrphanedImages(ctx context.Context, knownApps map[string]bool) error {
+	registryHost := os.Getenv("REGISTRY_HOST")
+	if registryHost == "" {
+		return nil
+	}
+
+	images, err := d.Client.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, img := range images {
+		for _, tag := range img.RepoTags {
+			name, found := strings.CutPrefix(tag, registryHost+"/")
+			if !found {
+				continue
+			}
+			appID, _, _ := strings.Cut(name, ":")
+			if !d.isDeletedApp(ctx, knownApps, appID) {
+				continue
+			}
+
+			slog.Info("Reconciled application: removing an image of a deleted application", "app_id", appID, "image", tag)
+			if _, err := d.Client.ImageRemove(ctx, tag, image.RemoveOptions{PruneChildren: true}); err != nil {
+				slog.Warn("Failed to remove image", "error", err, "image", tag)
+			}
+		}
+	}
+
+	return nil
+}
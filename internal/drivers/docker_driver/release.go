@@ -0,0 +1,37 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// releaseTimeout is how long a release command may run before the release is considered failed.
+const releaseTimeout = 30 * time.Minute
+
+// runReleaseCommand runs the release command of a deployment (e.g. database migrations) in a one-off
+// container from its image, with the application's environment. The output is saved on the deployment.
+// It returns false if the command exited with a non-zero code.
+func (driver *DockerDriver) runReleaseCommand(app models.Application, depl *models.Deployment) (bool, error) {
+	driver.releases.Store(depl.ID, struct{}{})
+	defer driver.releases.Delete(depl.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	labels := logLabels(app, *depl, models.LogEntrySourceRelease)
+	labels["deployment_id"] = depl.ID
+	labels["release"] = "true"
+
+	exitCode, output, err := driver.runOneOff(ctx, app, *depl, depl.ID+"-release", depl.ReleaseCommand, labels)
+	if err != nil {
+		return false, err
+	}
+	if exitCode != 0 {
+		output += fmt.Sprintf("\nRelease command exited with code %d.\n", exitCode)
+	}
+	depl.ReleaseOutput = output
+
+	return exitCode == 0, nil
+}
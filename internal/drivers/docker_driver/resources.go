@@ -0,0 +1,29 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// containerResources turns computing specs into the resource limits of a container.
+func containerResources(specs models.ComputingSpecs) container.Resources {
+	return container.Resources{
+		NanoCPUs:   specs.NanoCPUs,
+		CPUShares:  specs.CPUShares,
+		Memory:     specs.Memory,
+		MemorySwap: specs.MemorySwap,
+	}
+}
+
+// serviceResources turns computing specs into the resource limits of the tasks of a swarm service.
+// Swarm has no notion of CPU shares, so only the quotas apply.
+func serviceResources(specs models.ComputingSpecs) *swarm.ResourceRequirements {
+	return &swarm.ResourceRequirements{
+		Limits: &swarm.Limit{
+			NanoCPUs:    specs.NanoCPUs,
+			MemoryBytes: specs.Memory,
+		},
+	}
+}
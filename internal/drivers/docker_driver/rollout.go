@@ -0,0 +1,154 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	// rolloutTimeout is how long the replicas of a new deployment have to become healthy before the rollout is aborted.
+	rolloutTimeout = 5 * time.Minute
+
+	// rolloutGracePeriod is how long a replica without health check has to keep running to be considered healthy.
+	rolloutGracePeriod = 10 * time.Second
+)
+
+// listApplicationContainers lists the standalone containers of an application, from the most recent one.
+// They predate swarm services, so the replicas of the application's service aren't part of them.
+func (d *DockerDriver) listApplicationContainers(appID string) ([]types.Container, error) {
+	containers, err := d.Client.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "application_id="+appID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	standalone := []types.Container{}
+	for _, ct := range containers {
+		if _, isTask := ct.Labels["com.docker.swarm.task.id"]; !isTask {
+			standalone = append(standalone, ct)
+		}
+	}
+
+	sort.Slice(standalone, func(i, j int) bool {
+		return standalone[i].Created > standalone[j].Created
+	})
+
+	return standalone, nil
+}
+
+// findApplicationContainer returns the ID of the most recent running standalone container of an application.
+func (d *DockerDriver) findApplicationContainer(app models.Application) (string, error) {
+	containers, err := d.listApplicationContainers(app.ID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, ct := range containers {
+		if ct.State == "running" {
+			return ct.ID, nil
+		}
+	}
+
+	// Applications deployed before blue/green rollouts run in a container named after them.
+	if d.ContainerExists(app.ID) {
+		return app.ID, nil
+	}
+
+	return "", nil
+}
+
+// retireApplicationContainers removes the standalone containers an application ran in before swarm services.
+func (d *DockerDriver) retireApplicationContainers(app models.Application) error {
+	containers, err := d.listApplicationContainers(app.ID)
+	if err != nil {
+		return err
+	}
+	for _, ct := range containers {
+		if err := d.retireContainer(ct.ID); err != nil {
+			return err
+		}
+	}
+
+	if d.ContainerExists(app.ID) {
+		return d.retireContainer(app.ID)
+	}
+
+	return nil
+}
+
+// completeRollout waits for all the replicas of the application's services to run a new deployment.
+// If the rollout fails, the services are brought back to the previous deployment, which keeps serving the traffic.
+// Once it succeeds, the services of the process types the deployment doesn't declare anymore are removed.
+func (d *DockerDriver) completeRollout(app models.Application, depl models.Deployment) {
+	defer d.rollouts.Delete(depl.ID)
+
+	depl.Application = &app
+
+	err := d.waitForRollout(app, depl, app.ID)
+	for _, name := range depl.BackgroundProcessTypes() {
+		if err != nil {
+			break
+		}
+		err = d.waitForRollout(app, depl, processServiceName(app, name))
+	}
+
+	// A superseded rollout is left to the newer deployment, which the services are already being updated to.
+	if errors.Is(err, errRolloutSuperseded) {
+		slog.Info("Rollout superseded", "app_id", app.ID, "deployment_id", depl.ID)
+		if err := d.deployments.UpdateStatus(&depl, models.DeploymentStatusSuperseded); err != nil && !errors.Is(err, drivers.ErrDeploymentCanceled) {
+			slog.Error("Failed to update deployment status", "error", err, "deployment_id", depl.ID)
+		}
+		return
+	}
+
+	if err != nil {
+		slog.Warn("Rollout failed", "error", err, "app_id", app.ID, "deployment_id", depl.ID)
+		d.publishHealthEvent(app, depl, models.HealthCheckStatusFailed, "has failed: %s.", err)
+
+		if err := d.abortRollout(app); err != nil {
+			slog.Error("Failed to abort rollout", "error", err, "app_id", app.ID)
+		}
+		if err := d.deployments.UpdateStatus(&depl, models.DeploymentStatusDeployFailed); err != nil && !errors.Is(err, drivers.ErrDeploymentCanceled) {
+			slog.Error("Failed to update deployment status", "error", err, "deployment_id", depl.ID)
+		}
+		return
+	}
+
+	d.publishHealthEvent(app, depl, models.HealthCheckStatusPassing, "is now passing.")
+
+	if err := d.removeProcessServices(app, depl.Processes); err != nil {
+		slog.Error("Failed to remove the services of undeclared process types", "error", err, "app_id", app.ID)
+	}
+
+	if err := d.retireApplicationContainers(app); err != nil {
+		slog.Error("Failed to remove standalone containers", "error", err, "app_id", app.ID)
+	}
+
+	if err := d.deployments.UpdateStatus(&depl, models.DeploymentStatusSuccess); err != nil {
+		slog.Error("Failed to update deployment status", "error", err, "deployment_id", depl.ID)
+	}
+}
+
+// retireContainer removes a container on purpose, so that its death
+// isn't mistaken for the failure of the deployment it runs.
+func (d *DockerDriver) retireContainer(containerName string) error {
+	info, err := d.Client.ContainerInspect(context.Background(), containerName)
+	if err != nil {
+		return nil
+	}
+
+	d.retiredContainers.Store(info.ID, struct{}{})
+
+	return d.Client.ContainerRemove(context.Background(), info.ID, container.RemoveOptions{Force: true})
+}
@@ -0,0 +1,100 @@
+package dockerDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// traefikLabels returns the Traefik labels routing the traffic of an application's domains to its replicas.
+// Each verified certificate gets its own router, so that Traefik requests an ACME certificate for each custom domain.
+func traefikLabels(app models.Application, certs []models.Certificate) map[string]string {
+	labels := map[string]string{
+		"traefik.enable":         "true",
+		"traefik.docker.network": orgNetworkName(app.OrganizationID),
+
+		"traefik.http.routers." + app.ID + ".rule":             "Host(`" + app.Slug + "." + os.Getenv("WILDCARD_TRAEFIK_DOMAIN") + "`)",
+		"traefik.http.routers." + app.ID + ".entrypoints":      "websecure",
+		"traefik.http.routers." + app.ID + ".tls":              "true",
+		"traefik.http.routers." + app.ID + ".tls.certresolver": "myresolver",
+		"traefik.http.routers." + app.ID + ".service":          app.ID,
+
+		// Traefik merges the replicas sharing the same service into a single load balancer.
+		"traefik.http.services." + app.ID + ".loadbalancer.server.port": app.GetEnvVar("PORT", "3000"),
+	}
+
+	for _, cert := range certs {
+		if cert.Status != models.CertificateStatusVerified {
+			continue
+		}
+
+		hostnames := cert.Hostnames()
+		rules := make([]string, len(hostnames))
+		for i, hostname := range hostnames {
+			rules[i] = "Host(`" + hostname + "`)"
+		}
+
+		router := "traefik.http.routers." + app.ID + "-" + cert.ID
+		labels[router+".rule"] = strings.Join(rules, " || ")
+		labels[router+".entrypoints"] = "websecure"
+		labels[router+".tls"] = "true"
+		labels[router+".tls.certresolver"] = "myresolver"
+		labels[router+".tls.domains[0].main"] = cert.Domain
+		if len(hostnames) > 1 {
+			labels[router+".tls.domains[0].sans"] = strings.Join(hostnames[1:], ",")
+		}
+		labels[router+".service"] = app.ID
+	}
+
+	return labels
+}
+
+// refreshRouting updates the Traefik labels of the application's replicas, so that the traffic of
+// newly verified domains is routed to them, and the one of deleted domains isn't anymore.
+// Swarm rolls the change out one replica at a time, like a new deployment. The services deployed before the
+// organizations got their private networks are moved to them along.
+func (d *DockerDriver) refreshRouting(app models.Application) error {
+	svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), app.ID, types.ServiceInspectOptions{})
+	if err != nil {
+		// Applications that haven't been deployed yet get their routing on their first deployment.
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	certs, err := d.CertsRepo.FindAllVerifiedFromApp(context.Background(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := d.ensureOrgNetwork(app.OrganizationID); err != nil {
+		return err
+	}
+
+	labels := map[string]string{}
+	for key, value := range svc.Spec.TaskTemplate.ContainerSpec.Labels {
+		if !strings.HasPrefix(key, "traefik.") {
+			labels[key] = value
+		}
+	}
+	for key, value := range traefikLabels(app, certs) {
+		labels[key] = value
+	}
+	svc.Spec.TaskTemplate.ContainerSpec.Labels = labels
+	svc.Spec.TaskTemplate.Networks = serviceNetworks(app)
+
+	_, err = d.Client.ServiceUpdate(context.Background(), svc.ID, svc.Version, svc.Spec, types.ServiceUpdateOptions{
+		EncodedRegistryAuth: d.RegistryAuth,
+	})
+	return err
+}
+
+// ActivateCertificate starts routing the traffic of a verified domain to the application.
+func (d *DockerDriver) ActivateCertificate(app models.Application, cert models.Certificate) error {
+	return d.refreshRouting(app)
+}
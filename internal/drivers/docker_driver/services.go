@@ -0,0 +1,325 @@
+package dockerDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// servicesNetwork is the overlay network the application services used to be attached to, before each
+	// organization got its own private network (see orgNetworkName). Traefik stays connected to it, for the services
+	// that haven't been redeployed since.
+	servicesNetwork = "citadel_apps"
+
+	// traefikContainerName is the name of the Traefik container routing the traffic to the applications.
+	traefikContainerName = "traefik"
+)
+
+// errRolloutSuperseded is returned when a newer deployment is rolled out before the current one completes.
+var errRolloutSuperseded = errors.New("superseded by a newer deployment")
+
+// ensureServicesNetwork creates the former overlay network of the application services, and connects Traefik to it.
+func (d *DockerDriver) ensureServicesNetwork() error {
+	ctx := context.Background()
+
+	if _, err := d.Client.NetworkInspect(ctx, servicesNetwork, types.NetworkInspectOptions{}); err != nil {
+		if !client.IsErrNotFound(err) {
+			return err
+		}
+		if _, err := d.Client.NetworkCreate(ctx, servicesNetwork, types.NetworkCreate{
+			Driver:     "overlay",
+			Attachable: true,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return d.connectTraefik(ctx, servicesNetwork)
+}
+
+// serviceSpec returns the specification of the swarm service running a deployment of an application.
+// Updates are rolled out one replica at a time, each new replica starting before an old one is stopped,
+// and are rolled back by swarm if the new replicas fail. The volumes attached to the application are mounted in them,
+// and they are reachable at the internal hostname of the application on the private network of its organization.
+func serviceSpec(app models.Application, depl models.Deployment, specs models.ComputingSpecs, certs []models.Certificate, volumes []models.Volume) swarm.ServiceSpec {
+	replicas := uint64(app.GetReplicas())
+
+	labels := traefikLabels(app, certs)
+	for key, value := range logLabels(app, depl, models.LogEntrySourceApp) {
+		labels[key] = value
+	}
+	labels[logLabelProcess] = models.ProcessTypeWeb
+	labels["application_id"] = app.ID
+	labels["deployment_id"] = depl.ID
+
+	// Swarm rolls the update back if a new replica fails before reaching the healthy threshold of the health check,
+	// which waitForRollout waits for too.
+	monitor := rolloutGracePeriod
+	if app.HasHealthCheck() {
+		hc := app.GetHealthCheck()
+		monitor = hc.Interval * time.Duration(hc.HealthyThreshold)
+	}
+
+	return swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   app.ID,
+			Labels: map[string]string{"application_id": app.ID},
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:       drivers.ImageReference(app, depl),
+				Command:     drivers.ProcessCommand(depl.WebCommand()),
+				Env:         applicationEnv(app),
+				Healthcheck: serviceHealthcheck(app),
+				Labels:      labels,
+				Mounts:      volumeMounts(volumes),
+			},
+			Resources:     serviceResources(specs),
+			RestartPolicy: &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionAny},
+			Networks:      serviceNetworks(app),
+		},
+		Mode: swarm.ServiceMode{
+			Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+		},
+		UpdateConfig: &swarm.UpdateConfig{
+			Parallelism:   1,
+			FailureAction: swarm.UpdateFailureActionRollback,
+			Monitor:       monitor,
+			Order:         swarm.UpdateOrderStartFirst,
+		},
+		RollbackConfig: &swarm.UpdateConfig{
+			Parallelism:   1,
+			FailureAction: swarm.UpdateFailureActionPause,
+			Monitor:       monitor,
+			Order:         swarm.UpdateOrderStartFirst,
+		},
+	}
+}
+
+// deployService creates the swarm services of an application, or rolls out a new deployment to them.
+func (d *DockerDriver) deployService(app models.Application, depl models.Deployment) error {
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return err
+	}
+
+	certs, err := d.CertsRepo.FindAllVerifiedFromApp(context.Background(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := d.VolumesRepo.FindAllFromApplication(context.Background(), app.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := d.ensureOrgNetwork(app.OrganizationID); err != nil {
+		return err
+	}
+
+	if err := d.applyServiceSpec(serviceSpec(app, depl, specs, certs, volumes)); err != nil {
+		return err
+	}
+
+	return d.deployProcessServices(app, depl)
+}
+
+// applyServiceSpec creates a swarm service, or updates it to the given specification. The number of replicas
+// of an existing service is managed through ScaleApplication and ScaleProcessType, so the current one is kept.
+func (d *DockerDriver) applyServiceSpec(spec swarm.ServiceSpec) error {
+	svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), spec.Name, types.ServiceInspectOptions{})
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			return err
+		}
+
+		_, err := d.Client.ServiceCreate(context.Background(), spec, types.ServiceCreateOptions{
+			EncodedRegistryAuth: d.RegistryAuth,
+		})
+		return err
+	}
+
+	spec.Mode = svc.Spec.Mode
+
+	_, err = d.Client.ServiceUpdate(context.Background(), svc.ID, svc.Version, spec, types.ServiceUpdateOptions{
+		EncodedRegistryAuth: d.RegistryAuth,
+	})
+	return err
+}
+
+// ScaleApplication sets the number of replicas of the application's service to the one of the application.
+// Applications that haven't been deployed yet get their replicas on their first deployment.
+func (d *DockerDriver) ScaleApplication(app models.Application) error {
+	svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), app.ID, types.ServiceInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	replicas := uint64(app.GetReplicas())
+	svc.Spec.Mode = swarm.ServiceMode{
+		Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+	}
+
+	_, err = d.Client.ServiceUpdate(context.Background(), svc.ID, svc.Version, svc.Spec, types.ServiceUpdateOptions{})
+	return err
+}
+
+// removeService removes the swarm services of an application, if any.
+func (d *DockerDriver) removeService(app models.Application) error {
+	err := d.Client.ServiceRemove(context.Background(), app.ID)
+	if err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	return d.removeProcessServices(app, nil)
+}
+
+// waitForRollout waits for all the replicas of one of the application's services to run the given deployment.
+// It fails as soon as swarm gives up on the update, or once the rollout timeout is reached.
+func (d *DockerDriver) waitForRollout(app models.Application, depl models.Deployment, serviceName string) error {
+	deadline := time.Now().Add(rolloutTimeout)
+	lastReady := 0
+
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+
+		if d.deployments.IsCanceled(depl) {
+			return drivers.ErrDeploymentCanceled
+		}
+
+		svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), serviceName, types.ServiceInspectOptions{})
+		if err != nil {
+			return fmt.Errorf("service is gone: %w", err)
+		}
+
+		if status := svc.UpdateStatus; status != nil {
+			switch status.State {
+			case swarm.UpdateStatePaused, swarm.UpdateStateRollbackStarted, swarm.UpdateStateRollbackPaused, swarm.UpdateStateRollbackCompleted:
+				return fmt.Errorf("rolling update failed: %s", status.Message)
+			}
+		}
+
+		if svc.Spec.TaskTemplate.ContainerSpec.Labels["deployment_id"] != depl.ID {
+			return errRolloutSuperseded
+		}
+
+		desired := 0
+		if svc.Spec.Mode.Replicated != nil && svc.Spec.Mode.Replicated.Replicas != nil {
+			desired = int(*svc.Spec.Mode.Replicated.Replicas)
+		}
+
+		// Only the web replicas are health checked. They have to stay healthy for as many intervals
+		// as the healthy threshold of the health check before counting as ready.
+		healthyFor := time.Duration(0)
+		if serviceName == app.ID && app.HasHealthCheck() {
+			hc := app.GetHealthCheck()
+			healthyFor = hc.Interval * time.Duration(hc.HealthyThreshold-1)
+		}
+
+		ready, err := d.countRunningTasks(svc.ID, depl.ID, healthyFor)
+		if err != nil {
+			return err
+		}
+		if ready != lastReady && serviceName == app.ID {
+			d.publishHealthEvent(app, depl, models.HealthCheckStatusPending, "is passing on %d/%d replicas.", ready, desired)
+			lastReady = ready
+		}
+
+		updating := svc.UpdateStatus != nil && svc.UpdateStatus.State == swarm.UpdateStateUpdating
+		if ready >= desired && !updating {
+			return nil
+		}
+	}
+
+	return errors.New("timed out waiting for the replicas to become healthy")
+}
+
+// countRunningTasks counts the replicas of a service running a given deployment, for at least a given duration.
+// Swarm only reports the replicas with a health check as running once they are healthy, and as failed
+// once they are unhealthy, so the duration is the one since they last became healthy.
+func (d *DockerDriver) countRunningTasks(serviceID string, deploymentID string, runningFor time.Duration) (int, error) {
+	tasks, err := d.Client.TaskList(context.Background(), types.TaskListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("service", serviceID),
+			filters.Arg("desired-state", "running"),
+		),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, task := range tasks {
+		if task.Spec.ContainerSpec == nil || task.Spec.ContainerSpec.Labels["deployment_id"] != deploymentID {
+			continue
+		}
+		if task.Status.State == swarm.TaskStateRunning && time.Since(task.Status.Timestamp) >= runningFor {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// abortRollout brings the application's services back to their previous deployment,
+// or removes the ones that never ran any other.
+func (d *DockerDriver) abortRollout(app models.Application) error {
+	processServices, err := d.listProcessServices(app)
+	if err != nil {
+		return err
+	}
+
+	serviceNames := []string{app.ID}
+	for _, svc := range processServices {
+		serviceNames = append(serviceNames, svc.Spec.Name)
+	}
+
+	for _, serviceName := range serviceNames {
+		if err := d.abortServiceRollout(serviceName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// abortServiceRollout brings a service back to its previous specification, or removes it if it has none.
+func (d *DockerDriver) abortServiceRollout(serviceName string) error {
+	svc, _, err := d.Client.ServiceInspectWithRaw(context.Background(), serviceName, types.ServiceInspectOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Swarm is already rolling back on its own.
+	if svc.UpdateStatus != nil && strings.HasPrefix(string(svc.UpdateStatus.State), "rollback") {
+		return nil
+	}
+
+	if svc.PreviousSpec == nil {
+		err := d.Client.ServiceRemove(context.Background(), svc.ID)
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	_, err = d.Client.ServiceUpdate(context.Background(), svc.ID, svc.Version, svc.Spec, types.ServiceUpdateOptions{
+		Rollback: "previous",
+	})
+	return err
+}
@@ -0,0 +1,314 @@
+package dockerDriver
+
+import (
+	"bytes"
+	"citadel/internal/models"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+const (
+	// labelVolumeID is the label of the Docker volumes backing the volumes.
+	labelVolumeID = "volume_id"
+
+	// volumeHelperImage is the image of the containers reading and writing the content of the volumes.
+	volumeHelperImage = "alpine:3.20"
+
+	// volumeHelperPath is where the volumes are mounted in the helper containers.
+	volumeHelperPath = "/volume"
+
+	// volumeStopTimeout is how long the containers a volume is mounted in are waited for to stop before it is restored.
+	volumeStopTimeout = 2 * time.Minute
+)
+
+// volumeName returns the name of the Docker volume backing a volume.
+func volumeName(v models.Volume) string {
+	return "citadel-volume-" + v.ID
+}
+
+// volumeMounts returns the mounts of the volumes attached to an application, in its containers.
+// Docker volumes are local to the node they're created on, as are the replicas of the services using them.
+func volumeMounts(volumes []models.Volume) []mount.Mount {
+	var mounts []mount.Mount
+	for _, v := range volumes {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: volumeName(v),
+			Target: v.MountPath,
+		})
+	}
+	return mounts
+}
+
+// CreateVolume creates the Docker volume backing a volume. Docker doesn't enforce its size,
+// which is only reported against its usage.
+func (d *DockerDriver) CreateVolume(v models.Volume) error {
+	_, err := d.Client.VolumeCreate(context.Background(), volume.CreateOptions{
+		Name:   volumeName(v),
+		Labels: map[string]string{labelVolumeID: v.ID},
+	})
+	return err
+}
+
+// DeleteVolume removes the Docker volume backing a volume, which fails while containers still use it
+// (e.g. until the application it was attached to is redeployed).
+func (d *DockerDriver) DeleteVolume(v models.Volume) error {
+	err := d.Client.VolumeRemove(context.Background(), volumeName(v), false)
+	if errdefs.IsConflict(err) {
+		return fmt.Errorf("the volume is still mounted in containers, redeploy its former application first: %w", err)
+	}
+	if err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// GetVolumeUsage returns the disk space used by the Docker volume backing a volume, in bytes.
+func (d *DockerDriver) GetVolumeUsage(v models.Volume) (int64, error) {
+	usage, err := d.Client.DiskUsage(context.Background(), types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, vol := range usage.Volumes {
+		if vol.Name == volumeName(v) && vol.UsageData != nil && vol.UsageData.Size >= 0 {
+			return vol.UsageData.Size, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// SnapshotVolume uploads a gzipped tarball of the content of a volume to the platform's S3 bucket.
+// The content is read through a helper container, which doesn't need to run for that.
+func (d *DockerDriver) SnapshotVolume(v models.Volume, snapshot models.VolumeSnapshot) (int64, error) {
+	ctx := context.Background()
+
+	helperID, err := d.createVolumeHelper(ctx, v, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer d.removeVolumeHelper(helperID)
+
+	// The tarball holds the content of the volume at its root.
+	content, _, err := d.Client.CopyFromContainer(ctx, helperID, volumeHelperPath+"/.")
+	if err != nil {
+		return 0, err
+	}
+	defer content.Close()
+
+	var tarball bytes.Buffer
+	if err := gzipTo(&tarball, content); err != nil {
+		return 0, err
+	}
+
+	if err := d.drive.Use("s3").Put(snapshot.ObjectKey(v), tarball.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return int64(tarball.Len()), nil
+}
+
+// RestoreVolume replaces the content of a volume with the one of a snapshot. The services the volume is mounted in
+// are scaled down to zero meanwhile. The volume is emptied first, by a helper container, and the tarball
+// of the snapshot is then extracted in it.
+func (d *DockerDriver) RestoreVolume(v models.Volume, snapshot models.VolumeSnapshot) error {
+	ctx := context.Background()
+
+	tarball, err := d.drive.Use("s3").Get(snapshot.ObjectKey(v))
+	if err != nil {
+		return err
+	}
+
+	restart, err := d.stopVolumeServices(ctx, v)
+	defer restart()
+	if err != nil {
+		return err
+	}
+
+	helperID, err := d.createVolumeHelper(ctx, v, []string{"find", volumeHelperPath, "-mindepth", "1", "-delete"})
+	if err != nil {
+		return err
+	}
+	defer d.removeVolumeHelper(helperID)
+
+	if err := d.Client.ContainerStart(ctx, helperID, container.StartOptions{}); err != nil {
+		return err
+	}
+	statusCh, errCh := d.Client.ContainerWait(ctx, helperID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return err
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("failed to empty the volume, exit code %d", status.StatusCode)
+		}
+	}
+
+	// Docker decompresses the gzipped tarballs it's given.
+	return d.Client.CopyToContainer(ctx, helperID, volumeHelperPath, bytes.NewReader(tarball), types.CopyToContainerOptions{})
+}
+
+// DeleteVolumeSnapshot removes the tarball of a snapshot from the platform's S3 bucket.
+func (d *DockerDriver) DeleteVolumeSnapshot(v models.Volume, snapshot models.VolumeSnapshot) error {
+	return d.drive.Use("s3").Delete(snapshot.ObjectKey(v))
+}
+
+// stopVolumeServices scales the services a volume is mounted in down to zero, and waits for their containers
+// to be gone. It returns a function scaling them back up to their replicas, to be called even when it fails.
+func (d *DockerDriver) stopVolumeServices(ctx context.Context, v models.Volume) (func(), error) {
+	services, err := d.Client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return func() {}, err
+	}
+
+	replicas := map[string]uint64{}
+	for _, svc := range services {
+		if svc.Spec.Mode.Replicated == nil || svc.Spec.Mode.Replicated.Replicas == nil || !mountsVolume(svc, v) {
+			continue
+		}
+		replicas[svc.ID] = *svc.Spec.Mode.Replicated.Replicas
+	}
+
+	restart := func() {
+		for serviceID, count := range replicas {
+			if err := d.scaleService(context.Background(), serviceID, count); err != nil {
+				slog.Error("Failed to scale service back up after restoring volume", "error", err, "service_id", serviceID, "volume_id", v.ID)
+			}
+		}
+	}
+
+	for serviceID := range replicas {
+		if err := d.scaleService(ctx, serviceID, 0); err != nil {
+			return restart, err
+		}
+	}
+
+	deadline := time.Now().Add(volumeStopTimeout)
+	for {
+		containers, err := d.Client.ContainerList(ctx, container.ListOptions{
+			Filters: filters.NewArgs(filters.Arg("volume", volumeName(v))),
+		})
+		if err != nil {
+			return restart, err
+		}
+		if len(containers) == 0 {
+			return restart, nil
+		}
+		if time.Now().After(deadline) {
+			return restart, fmt.Errorf("the volume is still mounted in %d running containers", len(containers))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// mountsVolume reports whether the containers of a service mount a volume.
+func mountsVolume(svc swarm.Service, v models.Volume) bool {
+	if svc.Spec.TaskTemplate.ContainerSpec == nil {
+		return false
+	}
+	for _, m := range svc.Spec.TaskTemplate.ContainerSpec.Mounts {
+		if m.Type == mount.TypeVolume && m.Source == volumeName(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleService sets the number of replicas of a service.
+func (d *DockerDriver) scaleService(ctx context.Context, serviceID string, replicas uint64) error {
+	svc, _, err := d.Client.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+
+	svc.Spec.Mode = swarm.ServiceMode{
+		Replicated: &swarm.ReplicatedService{Replicas: &replicas},
+	}
+	_, err = d.Client.ServiceUpdate(ctx, svc.ID, svc.Version, svc.Spec, types.ServiceUpdateOptions{})
+	return err
+}
+
+// createVolumeHelper creates a container with a volume mounted at volumeHelperPath, which runs the given command
+// once started. It isn't started, nor connected to any network.
+func (d *DockerDriver) createVolumeHelper(ctx context.Context, v models.Volume, cmd []string) (string, error) {
+	if err := d.ensureVolumeHelperImage(ctx); err != nil {
+		return "", err
+	}
+
+	ct, err := d.Client.ContainerCreate(
+		ctx,
+		&container.Config{
+			Image:  volumeHelperImage,
+			Cmd:    cmd,
+			Labels: map[string]string{labelVolumeID: v.ID, "traefik.enable": "false"},
+		},
+		&container.HostConfig{
+			NetworkMode: "none",
+			Mounts: []mount.Mount{{
+				Type:   mount.TypeVolume,
+				Source: volumeName(v),
+				Target: volumeHelperPath,
+			}},
+		},
+		nil,
+		nil,
+		"",
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return ct.ID, nil
+}
+
+// removeVolumeHelper removes a helper container. As it isn't labelled with any deployment,
+// its exit is ignored by the events handlers.
+func (d *DockerDriver) removeVolumeHelper(id string) {
+	err := d.Client.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true})
+	if err != nil && !client.IsErrNotFound(err) {
+		slog.Warn("Failed to remove volume helper container", "error", err, "container_id", id)
+	}
+}
+
+// ensureVolumeHelperImage pulls the image of the helper containers, unless it's already there.
+// It's a public image, so it's pulled without the credentials of the registry.
+func (d *DockerDriver) ensureVolumeHelperImage(ctx context.Context) error {
+	if _, _, err := d.Client.ImageInspectWithRaw(ctx, volumeHelperImage); err == nil {
+		return nil
+	}
+
+	reader, err := d.Client.ImagePull(ctx, volumeHelperImage, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+// gzipTo writes the gzipped content of a reader to a writer.
+func gzipTo(w io.Writer, r io.Reader) error {
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, r); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"citadel/internal/models"
+	"context"
 
 	caesar "github.com/caesar-rocks/core"
 )
@@ -14,12 +15,37 @@ type Driver interface {
 
 	CreateCertificate(app models.Application, cert models.Certificate) ([]models.DnsEntry, error)
 	CheckDnsConfig(app models.Application, cert models.Certificate) (bool, error)
+	ActivateCertificate(app models.Application, cert models.Certificate) error
 	DeleteCertificate(app models.Application, cert models.Certificate) error
 
 	IgniteBuilder(app models.Application, depl models.Deployment) error
 	IgniteApplication(app models.Application, depl models.Deployment) error
+	ScaleApplication(app models.Application) error
 
-	StreamLogs(ctx *caesar.Context, app models.Application) error
+	// ScaleProcessType sets the number of instances and the computing specs of a process type of an application
+	// other than the web one. The process types that haven't been deployed yet get them on their first deployment.
+	ScaleProcessType(app models.Application, process models.ProcessType) error
+
+	// CancelDeployment stops the build, the release or the rollout of a deployment, and marks it as canceled.
+	// The application keeps running its previous deployment.
+	CancelDeployment(app models.Application, depl models.Deployment) error
+
+	// StreamLogs streams logs to the client as server-sent "log" events, whose data is a JSON models.LogEvent,
+	// until it disconnects or, for the logs that don't go on (e.g. the ones of a finished build), until
+	// an "end" event, whose data is the outcome of the build (see BuildOutcomeSucceeded and the like).
+	StreamLogs(ctx *caesar.Context, app models.Application, opts StreamLogsOptions) error
+
+	// Exec runs a command in a running instance of an application, and returns its exit code.
+	Exec(ctx context.Context, app models.Application, opts ExecOptions) (int, error)
+
+	// RunJob runs a command in a one-off instance of an application, from the image of a deployment and with
+	// the application's environment, and waits for it to exit. When the timeout elapses, the instance is killed,
+	// and ErrJobTimedOut is returned along with the output so far.
+	RunJob(app models.Application, depl models.Deployment, opts JobOptions) (JobResult, error)
+
+	// Metrics returns the resource usage of an application or a database over a period of time, in chronological order.
+	// The samples are aggregated into periods, as long as needed to keep their number reasonable for graphs.
+	Metrics(ctx context.Context, query MetricsQuery) ([]models.MetricSample, error)
 
 	// Database-related methods
 	CreateDatabase(db models.Database) error
@@ -29,4 +55,16 @@ type Driver interface {
 	CreateStorageBucket(bucket models.StorageBucket) (host string, keyId string, secretKey string, region string, err error)
 	GetFilesAndTotalSize(bucket models.StorageBucket) (totalSize float64, files []models.StorageFile, err error)
 	DeleteStorageBucket(bucket models.StorageBucket) error
+
+	// Volume-related methods
+	CreateVolume(volume models.Volume) error
+	DeleteVolume(volume models.Volume) error
+	// GetVolumeUsage returns the disk space used by a volume, in bytes.
+	GetVolumeUsage(volume models.Volume) (int64, error)
+	// SnapshotVolume copies the content of a volume, and returns the size of the copy in bytes.
+	SnapshotVolume(volume models.Volume, snapshot models.VolumeSnapshot) (int64, error)
+	// RestoreVolume replaces the content of a volume with the one of a snapshot. The instances the volume
+	// is mounted in are stopped meanwhile, so that it isn't written to, and started again afterwards.
+	RestoreVolume(volume models.Volume, snapshot models.VolumeSnapshot) error
+	DeleteVolumeSnapshot(volume models.Volume, snapshot models.VolumeSnapshot) error
 }
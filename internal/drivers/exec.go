@@ -0,0 +1,32 @@
+package drivers
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNoRunningInstance is returned when running a command in an application that has no running instance.
+var ErrNoRunningInstance = errors.New("no running instance")
+
+// TerminalSize is the size of a terminal, in characters.
+type TerminalSize struct {
+	Cols uint
+	Rows uint
+}
+
+// ExecOptions describe a command to run in a running instance of an application.
+type ExecOptions struct {
+	Cmd []string
+
+	// Tty allocates a terminal to the command. Its output is then merged into Stdout.
+	Tty  bool
+	Size TerminalSize
+
+	// Resize receives the new sizes of the terminal.
+	Resize <-chan TerminalSize
+
+	// Stdin is the standard input of the command, if any.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
@@ -0,0 +1,130 @@
+package fakeDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// IgniteBuilder simulates the build of a deployment, followed by its release and its rollout.
+func (d *FakeDriver) IgniteBuilder(app models.Application, depl models.Deployment) error {
+	d.record(app, depl, models.DeploymentStatusBuilding)
+
+	go func() {
+		if err := d.deploy(app, &depl); err != nil && !errors.Is(err, drivers.ErrDeploymentCanceled) {
+			slog.Error("Error while deploying", "error", err, "deployment_id", depl.ID)
+		}
+	}()
+
+	return nil
+}
+
+// IgniteApplication simulates the rollout of a deployment that has already been built, e.g. on a rollback.
+func (d *FakeDriver) IgniteApplication(app models.Application, depl models.Deployment) error {
+	d.record(app, depl, models.DeploymentStatusDeploying)
+
+	go func() {
+		if err := d.rollout(app, &depl); err != nil && !errors.Is(err, drivers.ErrDeploymentCanceled) {
+			slog.Error("Error while deploying", "error", err, "deployment_id", depl.ID)
+		}
+	}()
+
+	return nil
+}
+
+// CancelDeployment marks a deployment as canceled, which stops it at the end of its current phase.
+// The application keeps running its previous deployment.
+func (d *FakeDriver) CancelDeployment(app models.Application, depl models.Deployment) error {
+	if err := d.deployments.Cancel(&depl); err != nil {
+		return err
+	}
+
+	d.record(app, depl, models.DeploymentStatusCanceled)
+	return nil
+}
+
+// deploy builds a deployment, runs its release command, if any, and rolls it out.
+func (d *FakeDriver) deploy(app models.Application, depl *models.Deployment) error {
+	d.appendLog(*depl, models.LogEntrySourceBuilder, fmt.Sprintf("Building image %s:%s...", app.ID, depl.ID))
+	d.wait()
+
+	if d.fails(models.DeploymentStatusBuilding) {
+		d.appendLog(*depl, models.LogEntrySourceBuilder, "Build failed.")
+		return d.updateDeploymentStatus(app, depl, models.DeploymentStatusBuildFailed)
+	}
+	d.appendLog(*depl, models.LogEntrySourceBuilder, "Image built.")
+	depl.ImageTag = depl.ID
+
+	if depl.ReleaseCommand != "" {
+		if err := d.updateDeploymentStatus(app, depl, models.DeploymentStatusReleasing); err != nil {
+			return err
+		}
+
+		d.appendLog(*depl, models.LogEntrySourceRelease, "Running release command: "+depl.ReleaseCommand)
+		d.wait()
+
+		if d.fails(models.DeploymentStatusReleasing) {
+			return d.updateDeploymentStatus(app, depl, models.DeploymentStatusReleaseFailed)
+		}
+	}
+
+	if err := d.updateDeploymentStatus(app, depl, models.DeploymentStatusDeploying); err != nil {
+		return err
+	}
+
+	return d.rollout(app, depl)
+}
+
+// rollout starts the instances of a deployment, which then replace the ones of the running deployment.
+// A failing rollout leaves the running deployment untouched.
+func (d *FakeDriver) rollout(app models.Application, depl *models.Deployment) error {
+	d.appendLog(*depl, models.LogEntrySourceApp, fmt.Sprintf("Starting %d instance(s)...", app.GetReplicas()))
+	d.wait()
+
+	if d.deployments.IsCanceled(*depl) {
+		return drivers.ErrDeploymentCanceled
+	}
+	if d.fails(models.DeploymentStatusDeploying) {
+		return d.updateDeploymentStatus(app, depl, models.DeploymentStatusDeployFailed)
+	}
+
+	d.mu.Lock()
+	state := d.appState(app)
+	state.RunningDeploymentID = depl.ID
+	state.Replicas = app.GetReplicas()
+	d.mu.Unlock()
+
+	d.appendLog(*depl, models.LogEntrySourceApp, "Listening on port "+app.GetEnvVar("PORT", "3000"))
+
+	return d.updateDeploymentStatus(app, depl, models.DeploymentStatusSuccess)
+}
+
+// updateDeploymentStatus persists the new status of a deployment, and records the transition.
+// Canceled deployments keep their status, and drivers.ErrDeploymentCanceled is returned.
+func (d *FakeDriver) updateDeploymentStatus(app models.Application, depl *models.Deployment, status models.DeploymentStatus) error {
+	if err := d.deployments.UpdateStatus(depl, status); err != nil {
+		return err
+	}
+
+	d.record(app, *depl, status)
+	return nil
+}
+
+// wait lets a phase of a deployment last for the configured step duration.
+func (d *FakeDriver) wait() {
+	d.mu.Lock()
+	duration := d.stepDuration
+	d.mu.Unlock()
+
+	time.Sleep(duration)
+}
+
+// fails reports whether a phase of the deployments is set to fail (see SetFailure).
+func (d *FakeDriver) fails(phase models.DeploymentStatus) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.failures[phase]
+}
@@ -0,0 +1,293 @@
+package fakeDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultStepDuration is how long each phase of a deployment lasts, unless SetStepDuration says otherwise.
+const defaultStepDuration = 50 * time.Millisecond
+
+// The addresses the custom domains are asked to point to, taken from the ranges reserved for documentation.
+const (
+	fakeIPv4 = "203.0.113.10"
+	fakeIPv6 = "2001:db8::10"
+)
+
+// FakeDriver runs nothing: it keeps the applications, certificates, databases, storage buckets and volumes in memory,
+// and walks the deployments through the statuses a real driver would, so that Citadel can be run and tested
+// end to end without Docker or Kubernetes. The status transitions are stored like the ones of the other drivers,
+// and reported to the subscribers (see Subscribe).
+type FakeDriver struct {
+	DeplsRepo *repositories.DeploymentsRepository
+
+	mu           sync.Mutex
+	stepDuration time.Duration
+	dnsVerified  bool
+	failures     map[models.DeploymentStatus]bool
+
+	apps      map[string]*AppState
+	databases map[string]models.Database
+	buckets   map[string]*bucketState
+	volumes   map[string]*volumeState
+
+	// deployments walks the deployments through their statuses.
+	deployments *drivers.DeploymentTracker
+
+	// logs holds the lines of the builds and of the instances of each deployment.
+	logs map[logKey][]models.LogEvent
+
+	// jobs holds the one-off commands run, oldest first.
+	jobs []drivers.JobOptions
+
+	// metrics holds the samples of resource usage recorded, oldest first.
+	metrics []models.MetricSample
+
+	transitions []Transition
+	subscribers map[chan Transition]struct{}
+}
+
+// AppState is what the driver knows of an application.
+type AppState struct {
+	App models.Application
+
+	// RunningDeploymentID is the ID of the deployment the instances of the application run, if any.
+	RunningDeploymentID string
+	Replicas            int
+
+	// ProcessReplicas holds the number of instances of the process types other than the web one, by name.
+	ProcessReplicas map[string]int
+
+	// Certificates holds the IDs of the activated certificates of the application.
+	Certificates []string
+}
+
+// Transition is a change of status of a deployment, made by the driver.
+type Transition struct {
+	ApplicationID string
+	DeploymentID  string
+	Status        models.DeploymentStatus
+	At            time.Time
+}
+
+// New creates a fake driver. Custom domains are reported as properly configured, and every phase succeeds.
+func New(deplsRepo *repositories.DeploymentsRepository, processTypesRepo *repositories.ProcessTypesRepository) *FakeDriver {
+	return &FakeDriver{
+		DeplsRepo:    deplsRepo,
+		stepDuration: defaultStepDuration,
+		dnsVerified:  true,
+		failures:     map[models.DeploymentStatus]bool{},
+		apps:         map[string]*AppState{},
+		databases:    map[string]models.Database{},
+		buckets:      map[string]*bucketState{},
+		volumes:      map[string]*volumeState{},
+		deployments:  drivers.NewDeploymentTracker(deplsRepo, nil, processTypesRepo),
+		logs:         map[logKey][]models.LogEvent{},
+		subscribers:  map[chan Transition]struct{}{},
+	}
+}
+
+func (d *FakeDriver) Init() error {
+	return nil
+}
+
+// SetStepDuration sets how long the build, the release and the rollout of the next deployments last.
+func (d *FakeDriver) SetStepDuration(duration time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stepDuration = duration
+}
+
+// SetDnsVerified sets whether the custom domains are reported as pointing to Citadel.
+func (d *FakeDriver) SetDnsVerified(verified bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dnsVerified = verified
+}
+
+// SetFailure makes the given phase (models.DeploymentStatusBuilding, Releasing or Deploying) of the next
+// deployments fail, or succeed again.
+func (d *FakeDriver) SetFailure(phase models.DeploymentStatus, fail bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failures[phase] = fail
+}
+
+// App returns the state of an application, unless it hasn't been created or has been deleted.
+func (d *FakeDriver) App(appID string) (AppState, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.apps[appID]
+	if !ok {
+		return AppState{}, false
+	}
+	copied := *state
+	copied.Certificates = append([]string{}, state.Certificates...)
+	return copied, true
+}
+
+// Transitions returns the status transitions made so far, oldest first.
+func (d *FakeDriver) Transitions() []Transition {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Transition{}, d.transitions...)
+}
+
+// Subscribe returns a channel receiving the next status transitions, and a function to stop receiving them.
+func (d *FakeDriver) Subscribe() (<-chan Transition, func()) {
+	ch := make(chan Transition, 64)
+
+	d.mu.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.mu.Unlock()
+
+	return ch, func() {
+		d.mu.Lock()
+		delete(d.subscribers, ch)
+		d.mu.Unlock()
+	}
+}
+
+// WaitForStatus waits for the driver to move a deployment to a given status, including if it already did.
+func (d *FakeDriver) WaitForStatus(ctx context.Context, deplID string, status models.DeploymentStatus) error {
+	transitions, unsubscribe := d.Subscribe()
+	defer unsubscribe()
+
+	for _, transition := range d.Transitions() {
+		if transition.DeploymentID == deplID && transition.Status == status {
+			return nil
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case transition := <-transitions:
+			if transition.DeploymentID == deplID && transition.Status == status {
+				return nil
+			}
+		}
+	}
+}
+
+// record stores a status transition, and reports it to the subscribers.
+// Subscribers that don't keep up miss the transitions, rather than holding up the deployments.
+func (d *FakeDriver) record(app models.Application, depl models.Deployment, status models.DeploymentStatus) {
+	transition := Transition{ApplicationID: app.ID, DeploymentID: depl.ID, Status: status, At: time.Now()}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.transitions = append(d.transitions, transition)
+	for ch := range d.subscribers {
+		select {
+		case ch <- transition:
+		default:
+		}
+	}
+}
+
+func (d *FakeDriver) CreateApplication(app models.Application) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.apps[app.ID]; !ok {
+		d.apps[app.ID] = &AppState{App: app, Replicas: app.GetReplicas()}
+	}
+	return nil
+}
+
+func (d *FakeDriver) DeleteApplication(app models.Application) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.apps, app.ID)
+	return nil
+}
+
+// ScaleApplication sets the number of replicas of the application to its new one.
+func (d *FakeDriver) ScaleApplication(app models.Application) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.appState(app).Replicas = app.GetReplicas()
+	return nil
+}
+
+// ScaleProcessType sets the number of instances of a process type of the application to its new one.
+func (d *FakeDriver) ScaleProcessType(app models.Application, process models.ProcessType) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.appState(app)
+	if state.ProcessReplicas == nil {
+		state.ProcessReplicas = map[string]int{}
+	}
+	state.ProcessReplicas[process.Name] = process.GetReplicas()
+	return nil
+}
+
+// appState returns the state of an application, which is created on the fly for the applications
+// created before the driver started. The caller must hold the lock.
+func (d *FakeDriver) appState(app models.Application) *AppState {
+	state, ok := d.apps[app.ID]
+	if !ok {
+		state = &AppState{Replicas: app.GetReplicas()}
+		d.apps[app.ID] = state
+	}
+	state.App = app
+	return state
+}
+
+func (d *FakeDriver) CreateCertificate(app models.Application, cert models.Certificate) ([]models.DnsEntry, error) {
+	entries := []models.DnsEntry{}
+	for _, hostname := range cert.Hostnames() {
+		entries = append(entries,
+			models.DnsEntry{Hostname: hostname, Type: "A", Value: fakeIPv4},
+			models.DnsEntry{Hostname: hostname, Type: "AAAA", Value: fakeIPv6},
+		)
+	}
+	return entries, nil
+}
+
+// CheckDnsConfig reports the domains as configured, unless SetDnsVerified says otherwise.
+func (d *FakeDriver) CheckDnsConfig(app models.Application, cert models.Certificate) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dnsVerified, nil
+}
+
+func (d *FakeDriver) ActivateCertificate(app models.Application, cert models.Certificate) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.appState(app)
+	for _, id := range state.Certificates {
+		if id == cert.ID {
+			return nil
+		}
+	}
+	state.Certificates = append(state.Certificates, cert.ID)
+	return nil
+}
+
+func (d *FakeDriver) DeleteCertificate(app models.Application, cert models.Certificate) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.appState(app)
+	certs := state.Certificates[:0]
+	for _, id := range state.Certificates {
+		if id != cert.ID {
+			certs = append(certs, id)
+		}
+	}
+	state.Certificates = certs
+	return nil
+}
@@ -0,0 +1,22 @@
+package fakeDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+)
+
+// RunJob echoes the command to run, as if it had run successfully in a one-off instance of the application.
+func (d *FakeDriver) RunJob(app models.Application, depl models.Deployment, opts drivers.JobOptions) (drivers.JobResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.jobs = append(d.jobs, opts)
+	return drivers.JobResult{ExitCode: 0, Output: opts.Cmd + "\n"}, nil
+}
+
+// Jobs returns the one-off commands run so far, oldest first.
+func (d *FakeDriver) Jobs() []drivers.JobOptions {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]drivers.JobOptions{}, d.jobs...)
+}
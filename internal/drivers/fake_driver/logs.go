@@ -0,0 +1,126 @@
+package fakeDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	caesar "github.com/caesar-rocks/core"
+)
+
+// buildPollInterval is how often a deployment is checked while its build logs wait for its build to end.
+const buildPollInterval = 100 * time.Millisecond
+
+// logKey identifies the lines of a deployment coming from a given source.
+type logKey struct {
+	deploymentID string
+	source       models.LogEntrySource
+}
+
+// appendLog stores a line of the output of a deployment.
+func (d *FakeDriver) appendLog(depl models.Deployment, source models.LogEntrySource, message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := logKey{depl.ID, source}
+	d.logs[key] = append(d.logs[key], models.NewLogEvent(time.Now(), models.LogEntryStreamStdout, depl.ID, message))
+}
+
+// Logs returns the lines of the output of a deployment coming from a given source.
+func (d *FakeDriver) Logs(deplID string, source models.LogEntrySource) []models.LogEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]models.LogEvent{}, d.logs[logKey{deplID, source}]...)
+}
+
+// StreamLogs sends the lines of the build of a deployment once it ends, followed by its outcome.
+// The lines of the instances of an application are sent until the client disconnects,
+// and the ones of the deployments that are no longer running up to an "end" event.
+func (d *FakeDriver) StreamLogs(ctx *caesar.Context, app models.Application, opts drivers.StreamLogsOptions) error {
+	if opts.Scope == drivers.LogsScopeBuilder {
+		depl, err := d.waitForBuild(ctx.Context(), *opts.Deployment)
+		if err != nil {
+			return err
+		}
+		if err := d.sendLogs(ctx, depl.ID, models.LogEntrySourceBuilder, opts); err != nil {
+			return err
+		}
+		return drivers.SendEndEvent(ctx, buildOutcome(depl))
+	}
+
+	state, _ := d.App(app.ID)
+	deplID := state.RunningDeploymentID
+	if opts.Deployment != nil {
+		deplID = opts.Deployment.ID
+	}
+
+	if deplID != "" {
+		if err := d.sendLogs(ctx, deplID, models.LogEntrySourceApp, opts); err != nil {
+			return err
+		}
+		if deplID != state.RunningDeploymentID {
+			return drivers.SendEndEvent(ctx, "")
+		}
+	}
+
+	<-ctx.Context().Done()
+	return nil
+}
+
+// waitForBuild waits for a deployment to leave the queue and to be built, and returns it as it is by then.
+func (d *FakeDriver) waitForBuild(ctx context.Context, depl models.Deployment) (models.Deployment, error) {
+	for depl.Status == models.DeploymentStatusQueued || depl.Status == models.DeploymentStatusBuilding {
+		select {
+		case <-ctx.Done():
+			return depl, ctx.Err()
+		case <-time.After(buildPollInterval):
+		}
+
+		latest, err := d.DeplsRepo.FindOneBy(ctx, "id", depl.ID)
+		if err != nil {
+			return depl, err
+		}
+		depl = *latest
+	}
+	return depl, nil
+}
+
+// buildOutcome returns the outcome of a build that ended.
+func buildOutcome(depl models.Deployment) string {
+	switch depl.Status {
+	case models.DeploymentStatusBuildFailed:
+		return drivers.BuildOutcomeFailed
+	case models.DeploymentStatusCanceled:
+		return drivers.BuildOutcomeCanceled
+	case models.DeploymentStatusSuperseded:
+		return drivers.BuildOutcomeSuperseded
+	}
+	return drivers.BuildOutcomeSucceeded
+}
+
+func (d *FakeDriver) sendLogs(ctx *caesar.Context, deplID string, source models.LogEntrySource, opts drivers.StreamLogsOptions) error {
+	for _, event := range d.Logs(deplID, source) {
+		if err := drivers.SendLogEvent(ctx, event, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exec echoes the command to run, as if it had run successfully in an instance of the application.
+func (d *FakeDriver) Exec(ctx context.Context, app models.Application, opts drivers.ExecOptions) (int, error) {
+	state, ok := d.App(app.ID)
+	if !ok || state.RunningDeploymentID == "" {
+		return 0, drivers.ErrNoRunningInstance
+	}
+
+	if opts.Stdout != nil {
+		if _, err := fmt.Fprintln(opts.Stdout, strings.Join(opts.Cmd, " ")); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
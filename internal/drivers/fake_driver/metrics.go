@@ -0,0 +1,37 @@
+package fakeDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+)
+
+// Metrics returns the samples recorded for the resource within the period, as they were recorded.
+func (d *FakeDriver) Metrics(ctx context.Context, query drivers.MetricsQuery) ([]models.MetricSample, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	samples := []models.MetricSample{}
+	for _, sample := range d.metrics {
+		if sample.ResourceType != query.ResourceType || sample.ResourceID != query.ResourceID {
+			continue
+		}
+		if query.Process != "" && sample.Process != query.Process {
+			continue
+		}
+		if sample.Timestamp.Before(query.Since) || !sample.Timestamp.Before(query.Until) {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// RecordMetricSample records a sample of the resource usage of an application or a database, as if it had been collected.
+// The samples must be recorded in chronological order.
+func (d *FakeDriver) RecordMetricSample(sample models.MetricSample) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.metrics = append(d.metrics, sample)
+}
@@ -0,0 +1,108 @@
+package fakeDriver
+
+import (
+	"citadel/internal/models"
+	"citadel/util"
+	"errors"
+	"sort"
+	"time"
+)
+
+// The endpoint and region handed out with the credentials of the storage buckets.
+const (
+	fakeStorageHost   = "storage.citadel.test"
+	fakeStorageRegion = "us-east-1"
+)
+
+// bucketState holds the files of a storage bucket, by name.
+type bucketState struct {
+	files map[string]models.StorageFile
+}
+
+// CreateDatabase keeps track of a database, by slug, as the other drivers name their containers.
+func (d *FakeDriver) CreateDatabase(db models.Database) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.databases[db.Slug]; exists {
+		return errors.New("database already exists")
+	}
+	d.databases[db.Slug] = db
+	return nil
+}
+
+func (d *FakeDriver) DeleteDatabase(db models.Database) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.databases, db.Slug)
+	return nil
+}
+
+// HasDatabase reports whether a database, given by slug, has been created and not deleted since.
+func (d *FakeDriver) HasDatabase(slug string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, exists := d.databases[slug]
+	return exists
+}
+
+func (d *FakeDriver) CreateStorageBucket(bucket models.StorageBucket) (host string, keyId string, secretKey string, region string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.buckets[bucket.Slug]; exists {
+		return "", "", "", "", errors.New("bucket already exists")
+	}
+
+	secretKey, err = util.GenerateSecretKey()
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	d.buckets[bucket.Slug] = &bucketState{files: map[string]models.StorageFile{}}
+	return fakeStorageHost, bucket.Slug, secretKey, fakeStorageRegion, nil
+}
+
+func (d *FakeDriver) GetFilesAndTotalSize(bucket models.StorageBucket) (totalSize float64, files []models.StorageFile, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, exists := d.buckets[bucket.Slug]
+	if !exists {
+		return 0, nil, errors.New("bucket not found")
+	}
+
+	files = []models.StorageFile{}
+	for _, file := range state.files {
+		files = append(files, file)
+		totalSize += file.Size
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return totalSize, files, nil
+}
+
+func (d *FakeDriver) DeleteStorageBucket(bucket models.StorageBucket) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.buckets, bucket.Slug)
+	return nil
+}
+
+// PutFile stores a file in a storage bucket, given by slug, as if it had been uploaded by an application.
+func (d *FakeDriver) PutFile(bucketSlug string, name string, size float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, exists := d.buckets[bucketSlug]
+	if !exists {
+		return errors.New("bucket not found")
+	}
+
+	state.files[name] = models.StorageFile{Name: name, Size: size, UpdatedAt: time.Now(), Type: "application/octet-stream"}
+	return nil
+}
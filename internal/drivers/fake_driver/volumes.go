@@ -0,0 +1,98 @@
+package fakeDriver
+
+import (
+	"citadel/internal/models"
+	"errors"
+)
+
+// volumeState holds the disk space used by a volume, in bytes, and the one of its snapshots, by ID.
+type volumeState struct {
+	usage     int64
+	snapshots map[string]int64
+}
+
+// CreateVolume keeps track of a volume, by ID, as the other drivers name their volumes.
+func (d *FakeDriver) CreateVolume(volume models.Volume) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.volumes[volume.ID]; exists {
+		return errors.New("volume already exists")
+	}
+	d.volumes[volume.ID] = &volumeState{snapshots: map[string]int64{}}
+	return nil
+}
+
+func (d *FakeDriver) DeleteVolume(volume models.Volume) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.volumes, volume.ID)
+	return nil
+}
+
+func (d *FakeDriver) GetVolumeUsage(volume models.Volume) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, exists := d.volumes[volume.ID]
+	if !exists {
+		return 0, errors.New("volume not found")
+	}
+	return state.usage, nil
+}
+
+// SnapshotVolume records the disk space used by the volume, which is the size of the snapshot.
+func (d *FakeDriver) SnapshotVolume(volume models.Volume, snapshot models.VolumeSnapshot) (int64, error) {
+	d.wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, exists := d.volumes[volume.ID]
+	if !exists {
+		return 0, errors.New("volume not found")
+	}
+	state.snapshots[snapshot.ID] = state.usage
+	return state.usage, nil
+}
+
+// RestoreVolume sets the disk space used by the volume back to the one recorded by the snapshot.
+func (d *FakeDriver) RestoreVolume(volume models.Volume, snapshot models.VolumeSnapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, exists := d.volumes[volume.ID]
+	if !exists {
+		return errors.New("volume not found")
+	}
+	usage, exists := state.snapshots[snapshot.ID]
+	if !exists {
+		return errors.New("snapshot not found")
+	}
+	state.usage = usage
+	return nil
+}
+
+func (d *FakeDriver) DeleteVolumeSnapshot(volume models.Volume, snapshot models.VolumeSnapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if state, exists := d.volumes[volume.ID]; exists {
+		delete(state.snapshots, snapshot.ID)
+	}
+	return nil
+}
+
+// SetVolumeUsage sets the disk space used by a volume, given by ID, as if an application had written to it.
+func (d *FakeDriver) SetVolumeUsage(volumeID string, usage int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, exists := d.volumes[volumeID]
+	if !exists {
+		return errors.New("volume not found")
+	}
+	state.usage = usage
+	return nil
+}
@@ -0,0 +1,63 @@
+package drivers
+
+import (
+	"citadel/internal/models"
+	"sync"
+)
+
+// HealthEventsBroker fans out the health check events of the applications to the clients streaming their logs.
+type HealthEventsBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan models.HealthCheckEvent]struct{}
+
+	// last holds the most recent event of each application, replayed to new subscribers,
+	// so that clients connecting right after a check completed still get its outcome.
+	last map[string]models.HealthCheckEvent
+}
+
+// Subscribe returns a channel receiving the health check events of an application,
+// and a function to call once done with it.
+func (b *HealthEventsBroker) Subscribe(appID string) (<-chan models.HealthCheckEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers == nil {
+		b.subscribers = make(map[string]map[chan models.HealthCheckEvent]struct{})
+	}
+	if b.subscribers[appID] == nil {
+		b.subscribers[appID] = make(map[chan models.HealthCheckEvent]struct{})
+	}
+
+	ch := make(chan models.HealthCheckEvent, 16)
+	b.subscribers[appID][ch] = struct{}{}
+	if event, ok := b.last[appID]; ok {
+		ch <- event
+	}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[appID], ch)
+		if len(b.subscribers[appID]) == 0 {
+			delete(b.subscribers, appID)
+		}
+	}
+}
+
+// Publish sends an event to the subscribers of an application. Slow subscribers miss events rather than block the checks.
+func (b *HealthEventsBroker) Publish(appID string, event models.HealthCheckEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last == nil {
+		b.last = make(map[string]models.HealthCheckEvent)
+	}
+	b.last[appID] = event
+
+	for ch := range b.subscribers[appID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
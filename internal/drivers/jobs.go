@@ -0,0 +1,27 @@
+package drivers
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrJobTimedOut is returned when a one-off command runs longer than its timeout. It is killed then.
+var ErrJobTimedOut = errors.New("job timed out")
+
+// JobOptions describe a command to run in a one-off instance of an application.
+type JobOptions struct {
+	// Name identifies the instance among the other one-off instances of the application (e.g. the ID of a cron job run).
+	Name string
+
+	// Cmd is run by a shell.
+	Cmd     string
+	Timeout time.Duration
+}
+
+// JobResult is the outcome of a one-off command.
+type JobResult struct {
+	ExitCode int
+
+	// Output is the trailing output of the command, stdout and stderr combined.
+	Output string
+}
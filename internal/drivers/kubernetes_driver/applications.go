@@ -0,0 +1,359 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// rolloutTimeout is how long the pods of a new deployment have to become ready before the rollout is aborted.
+	rolloutTimeout = 5 * time.Minute
+
+	// rolloutGracePeriod is how long a pod without health check has to keep running to be considered ready.
+	rolloutGracePeriod = 10 * time.Second
+
+	// revisionAnnotation is set by Kubernetes on the ReplicaSets of a Deployment, to tell their revisions apart.
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// The resources of the applications are labelled with the application and deployment they belong to.
+// The pods of the application share the "application_id" label, which selects them.
+const (
+	labelApplicationID = "application_id"
+	labelDeploymentID  = "deployment_id"
+)
+
+// applicationName returns the name of the Deployment, Service and Ingress of an application.
+// Service names must start with a letter, which the IDs don't always do.
+func applicationName(app models.Application) string {
+	return "app-" + app.ID
+}
+
+// imagePullSecrets returns the secret the images are pulled from the registry with, if any.
+func imagePullSecrets() []corev1.LocalObjectReference {
+	if secret := os.Getenv("KUBERNETES_IMAGE_PULL_SECRET"); secret != "" {
+		return []corev1.LocalObjectReference{{Name: secret}}
+	}
+	return nil
+}
+
+// applicationEnv returns the environment variables of an application.
+func applicationEnv(app models.Application) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for key, value := range app.GetEnv() {
+		env = append(env, corev1.EnvVar{Name: key, Value: value})
+	}
+	sort.Slice(env, func(i, j int) bool {
+		return env[i].Name < env[j].Name
+	})
+	return env
+}
+
+// applicationPort returns the port the application listens on.
+func applicationPort(app models.Application) int32 {
+	port, err := strconv.Atoi(app.GetEnvVar("PORT", "3000"))
+	if err != nil {
+		return 3000
+	}
+	return int32(port)
+}
+
+// readinessProbe turns the HTTP health check of an application into the readiness probe of its pods,
+// which Kubernetes waits for before routing them traffic and retiring the previous ones.
+func readinessProbe(app models.Application) *corev1.Probe {
+	if !app.HasHealthCheck() {
+		return nil
+	}
+
+	hc := app.GetHealthCheck()
+	port, err := strconv.Atoi(hc.Port)
+	if err != nil {
+		port = int(applicationPort(app))
+	}
+
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: hc.Path,
+				Port: intstr.FromInt(port),
+			},
+		},
+		PeriodSeconds:    int32(hc.Interval.Seconds()),
+		TimeoutSeconds:   int32(hc.Timeout.Seconds()),
+		SuccessThreshold: int32(hc.HealthyThreshold),
+		FailureThreshold: int32(hc.UnhealthyThreshold),
+	}
+}
+
+// deploymentSpec returns the Deployment running a deployment of an application. Updates are rolled out
+// one pod at a time, each new pod becoming ready before an old one is stopped. The volumes attached to
+// the application are mounted in its pods.
+func deploymentSpec(app models.Application, depl models.Deployment, specs models.ComputingSpecs, volumes []models.Volume) *appsv1.Deployment {
+	replicas := int32(app.GetReplicas())
+	maxSurge := intstr.FromInt(1)
+	maxUnavailable := intstr.FromInt(0)
+	progressDeadline := int32(rolloutTimeout.Seconds())
+
+	minReady := int32(0)
+	if !app.HasHealthCheck() {
+		minReady = int32(rolloutGracePeriod.Seconds())
+	}
+
+	labels := logLabels(app, depl, models.LogEntrySourceApp)
+	labels[logLabelProcess] = models.ProcessTypeWeb
+	labels[labelApplicationID] = app.ID
+	labels[labelDeploymentID] = depl.ID
+
+	podVolumes, mounts := podVolumes(volumes)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   applicationName(app),
+			Labels: map[string]string{labelApplicationID: app.ID},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{labelApplicationID: app.ID},
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxSurge:       &maxSurge,
+					MaxUnavailable: &maxUnavailable,
+				},
+			},
+			MinReadySeconds:         minReady,
+			ProgressDeadlineSeconds: &progressDeadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:           "app",
+						Image:          drivers.ImageReference(app, depl),
+						Command:        drivers.ProcessCommand(depl.WebCommand()),
+						Env:            applicationEnv(app),
+						Ports:          []corev1.ContainerPort{{ContainerPort: applicationPort(app)}},
+						ReadinessProbe: readinessProbe(app),
+						Resources:      resourceRequirements(specs),
+						VolumeMounts:   mounts,
+					}},
+					Volumes:          podVolumes,
+					ImagePullSecrets: imagePullSecrets(),
+				},
+			},
+		},
+	}
+}
+
+// serviceSpec returns the Service load balancing the traffic of an application across its pods.
+func serviceSpec(app models.Application) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   applicationName(app),
+			Labels: map[string]string{labelApplicationID: app.ID},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{labelApplicationID: app.ID},
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(int(applicationPort(app))),
+			}},
+		},
+	}
+}
+
+// deployApplication creates the Deployment, Service and Ingress of an application,
+// or rolls out a new deployment to them.
+func (d *KubernetesDriver) deployApplication(app models.Application, depl models.Deployment) error {
+	ctx := context.Background()
+
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return err
+	}
+
+	volumes, err := d.VolumesRepo.FindAllFromApplication(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	deployment := deploymentSpec(app, depl, specs, volumes)
+	deployments := d.Client.AppsV1().Deployments(d.Namespace)
+
+	current, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := deployments.Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		// The number of replicas is managed through ScaleApplication, so the current one is kept.
+		deployment.Spec.Replicas = current.Spec.Replicas
+		deployment.ResourceVersion = current.ResourceVersion
+		if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	if err := d.applyService(app); err != nil {
+		return err
+	}
+
+	return d.refreshRouting(app)
+}
+
+// applyService creates the Service of an application, or updates the port it targets.
+func (d *KubernetesDriver) applyService(app models.Application) error {
+	ctx := context.Background()
+	service := serviceSpec(app)
+	services := d.Client.CoreV1().Services(d.Namespace)
+
+	current, err := services.Get(ctx, service.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = services.Create(ctx, service, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	current.Spec.Selector = service.Spec.Selector
+	current.Spec.Ports = service.Spec.Ports
+	_, err = services.Update(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+// ScaleApplication sets the number of replicas of the application's Deployment to the one of the application.
+// Applications that haven't been deployed yet get their replicas on their first deployment.
+func (d *KubernetesDriver) ScaleApplication(app models.Application) error {
+	ctx := context.Background()
+	deployments := d.Client.AppsV1().Deployments(d.Namespace)
+
+	scale, err := deployments.GetScale(ctx, applicationName(app), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	scale.Spec.Replicas = int32(app.GetReplicas())
+	_, err = deployments.UpdateScale(ctx, applicationName(app), scale, metav1.UpdateOptions{})
+	return err
+}
+
+// isRunningDeployment reports whether a deployment is the one the application's Deployment runs.
+func (d *KubernetesDriver) isRunningDeployment(app models.Application, depl models.Deployment) bool {
+	deployment, err := d.Client.AppsV1().Deployments(d.Namespace).Get(context.Background(), applicationName(app), metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	return deployment.Spec.Template.Labels[labelDeploymentID] == depl.ID
+}
+
+// abortRollout brings the application's Deployment back to the pod template of its previous revision,
+// like "kubectl rollout undo", or removes it if it never ran any other deployment.
+func (d *KubernetesDriver) abortRollout(app models.Application, depl models.Deployment) error {
+	ctx := context.Background()
+	deployments := d.Client.AppsV1().Deployments(d.Namespace)
+
+	deployment, err := deployments.Get(ctx, applicationName(app), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// A newer deployment has been rolled out in the meantime.
+	if deployment.Spec.Template.Labels[labelDeploymentID] != depl.ID {
+		return nil
+	}
+
+	replicaSets, err := d.Client.AppsV1().ReplicaSets(d.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelApplicationID + "=" + app.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var previous *appsv1.ReplicaSet
+	previousRevision := -1
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if rs.Spec.Template.Labels[labelDeploymentID] == depl.ID {
+			continue
+		}
+		revision, err := strconv.Atoi(rs.Annotations[revisionAnnotation])
+		if err != nil {
+			continue
+		}
+		if revision > previousRevision {
+			previous, previousRevision = rs, revision
+		}
+	}
+
+	if previous == nil {
+		return d.removeApplicationWorkload(app)
+	}
+
+	template := previous.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	deployment.Spec.Template = *template
+
+	_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+	return err
+}
+
+// removeApplicationWorkload removes the Deployment of an application, if any.
+func (d *KubernetesDriver) removeApplicationWorkload(app models.Application) error {
+	err := d.Client.AppsV1().Deployments(d.Namespace).Delete(context.Background(), applicationName(app), metav1.DeleteOptions{
+		PropagationPolicy: ptr(metav1.DeletePropagationBackground),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// removeApplication removes the Deployments, Service, Ingress and certificates of an application.
+func (d *KubernetesDriver) removeApplication(app models.Application) error {
+	ctx := context.Background()
+
+	if err := d.removeApplicationWorkload(app); err != nil {
+		return err
+	}
+	if err := d.removeProcessWorkloads(app, nil); err != nil {
+		return err
+	}
+
+	err := d.Client.CoreV1().Services(d.Namespace).Delete(ctx, applicationName(app), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = d.Client.NetworkingV1().Ingresses(d.Namespace).Delete(ctx, applicationName(app), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return d.removeCertificates(app)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,104 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"os"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// finishedJobsTTL is how long the Jobs of the builds and release commands are kept once finished,
+// in seconds. Their output is stored by the log collector in the meantime.
+const finishedJobsTTL = 3600
+
+// builderJobName returns the name of the Job building a deployment.
+func builderJobName(depl models.Deployment) string {
+	return "build-" + depl.ID
+}
+
+// IgniteBuilder starts the Job building the image of a deployment. Its outcome is picked up by the informers (see handleJob).
+func (d *KubernetesDriver) IgniteBuilder(app models.Application, depl models.Deployment) error {
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return err
+	}
+
+	labels := logLabels(app, depl, models.LogEntrySourceBuilder)
+	labels[labelDeploymentID] = depl.ID
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   builderJobName(depl),
+			Labels: labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr(int32(0)),
+			TTLSecondsAfterFinished: ptr(int32(finishedJobsTTL)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					HostNetwork:   true,
+					Containers: []corev1.Container{{
+						Name:      "builder",
+						Image:     os.Getenv("BUILDER_IMAGE"),
+						Env:       builderEnv(app.ID, depl.ID),
+						Resources: resourceRequirements(specs),
+						SecurityContext: &corev1.SecurityContext{
+							Privileged: ptr(true),
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	_, err = d.Client.BatchV1().Jobs(d.Namespace).Create(context.Background(), job, metav1.CreateOptions{})
+	return err
+}
+
+func builderEnv(appID string, deplID string) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		// Each build is tagged with its deployment ID, so that previous releases can be rolled back to.
+		{Name: "IMAGE_NAME", Value: appID + ":" + deplID},
+		{Name: "FILE_NAME", Value: deplID},
+		{Name: "REGISTRY_HOST", Value: os.Getenv("REGISTRY_HOST")},
+		{Name: "REGISTRY_TOKEN", Value: os.Getenv("REGISTRY_TOKEN")},
+		{Name: "S3_ENDPOINT", Value: os.Getenv("S3_ENDPOINT")},
+		{Name: "S3_ACCESS_KEY_ID", Value: os.Getenv("S3_KEY")},
+		{Name: "S3_SECRET_ACCESS_KEY", Value: os.Getenv("S3_SECRET")},
+		{Name: "S3_BUCKET_NAME", Value: os.Getenv("S3_BUCKET")},
+		{Name: "S3_REGION", Value: os.Getenv("S3_REGION")},
+	}
+}
+
+// deleteJob removes a Job along with its pods, if it is still there.
+func (d *KubernetesDriver) deleteJob(name string) error {
+	err := d.Client.BatchV1().Jobs(d.Namespace).Delete(context.Background(), name, metav1.DeleteOptions{
+		PropagationPolicy: ptr(metav1.DeletePropagationBackground),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// jobOutcome tells whether a Job is finished, and whether it succeeded.
+func jobOutcome(job *batchv1.Job) (finished bool, succeeded bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, true
+		case batchv1.JobFailed:
+			return true, false
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,28 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+)
+
+// CancelDeployment stops the build, the release or the rollout of a deployment. The Jobs of the builder
+// and of the release command are removed, and the rollouts are undone.
+func (d *KubernetesDriver) CancelDeployment(app models.Application, depl models.Deployment) error {
+	phase := depl.Status
+
+	depl.Application = &app
+	if err := d.deployments.Cancel(&depl); err != nil {
+		return err
+	}
+
+	switch phase {
+	case models.DeploymentStatusBuilding:
+		return d.deleteJob(builderJobName(depl))
+	case models.DeploymentStatusReleasing:
+		return d.deleteJob(releaseJobName(depl))
+	case models.DeploymentStatusDeploying:
+		d.rollouts.Delete(depl.ID)
+		return d.abortRollout(app, depl)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// labelDatabaseID is the label of the resources of the databases.
+	labelDatabaseID = "database_id"
+
+	// databaseStorageSize is the size of the volume of each database.
+	databaseStorageSize = "10Gi"
+)
+
+// databaseName returns the name of the StatefulSet and Service of a database.
+func databaseName(db models.Database) string {
+	return "citadel-" + db.Slug
+}
+
+// CreateDatabase runs a database in a StatefulSet, with its data on a persistent volume,
+// reachable from the applications of the cluster through its Service.
+func (d *KubernetesDriver) CreateDatabase(db models.Database) error {
+	ctx := context.Background()
+
+	specs, err := db.GetComputingSpecs()
+	if err != nil {
+		return err
+	}
+
+	container, dataPath := databaseContainer(db)
+	container.Resources = resourceRequirements(specs)
+	// The root of the volumes may hold a lost+found directory, which the databases refuse to initialize.
+	container.VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: dataPath, SubPath: string(db.DBMS)}}
+
+	labels := map[string]string{labelDatabaseID: db.ID}
+	replicas := int32(1)
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   databaseName(db),
+			Labels: labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: databaseName(db),
+			Replicas:    &replicas,
+			Selector:    &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "data",
+					Labels: labels,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse(databaseStorageSize),
+						},
+					},
+				},
+			}},
+		},
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   databaseName(db),
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Port: container.Ports[0].ContainerPort}},
+		},
+	}
+
+	if _, err := d.Client.CoreV1().Services(d.Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	_, err = d.Client.AppsV1().StatefulSets(d.Namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	return err
+}
+
+// DeleteDatabase removes the StatefulSet and Service of a database, along with its volume.
+func (d *KubernetesDriver) DeleteDatabase(db models.Database) error {
+	ctx := context.Background()
+
+	err := d.Client.AppsV1().StatefulSets(d.Namespace).Delete(ctx, databaseName(db), metav1.DeleteOptions{
+		PropagationPolicy: ptr(metav1.DeletePropagationForeground),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = d.Client.CoreV1().Services(d.Namespace).Delete(ctx, databaseName(db), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// The volumes of a StatefulSet outlive it.
+	return d.Client.CoreV1().PersistentVolumeClaims(d.Namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: labelDatabaseID + "=" + db.ID,
+	})
+}
+
+// databaseContainer returns the container running a database, and the path of its data.
+func databaseContainer(db models.Database) (corev1.Container, string) {
+	switch db.DBMS {
+	case models.Postgres:
+		return corev1.Container{
+			Name:  "postgres",
+			Image: "postgres:13-alpine",
+			Env: []corev1.EnvVar{
+				{Name: "POSTGRES_DB", Value: db.Name},
+				{Name: "POSTGRES_USER", Value: db.Username},
+				{Name: "POSTGRES_PASSWORD", Value: db.Password},
+			},
+			Ports: []corev1.ContainerPort{{ContainerPort: 5432}},
+		}, "/var/lib/postgresql/data"
+	case models.MySQL:
+		return corev1.Container{
+			Name:  "mysql",
+			Image: "mysql:8.3.0",
+			Env: []corev1.EnvVar{
+				{Name: "MYSQL_DATABASE", Value: db.Name},
+				{Name: "MYSQL_USER", Value: db.Username},
+				{Name: "MYSQL_PASSWORD", Value: db.Password},
+				{Name: "MYSQL_RANDOM_ROOT_PASSWORD", Value: "yes"},
+			},
+			Ports: []corev1.ContainerPort{{ContainerPort: 3306}},
+		}, "/var/lib/mysql"
+	default:
+		return corev1.Container{
+			Name:  "redis",
+			Image: "redis:6-alpine",
+			Args:  []string{"redis-server", "--requirepass", "$(REDIS_PASSWORD)"},
+			Env: []corev1.EnvVar{
+				{Name: "REDIS_PASSWORD", Value: db.Password},
+			},
+			Ports: []corev1.ContainerPort{{ContainerPort: 6379}},
+		}, "/data"
+	}
+}
@@ -0,0 +1,54 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"errors"
+	"log/slog"
+)
+
+// handleBuildSuccess releases and rolls out a freshly built deployment. The release phase may take a while,
+// and must not hold up the handling of other events.
+func (d *KubernetesDriver) handleBuildSuccess(depl *models.Deployment) {
+	depl.ImageTag = depl.ID
+
+	go func() {
+		if err := d.releaseAndIgnite(depl); err != nil && !errors.Is(err, drivers.ErrDeploymentCanceled) {
+			slog.Error("Error while deploying", "error", err, "deployment_id", depl.ID)
+		}
+	}()
+}
+
+// releaseAndIgnite runs the release command of a freshly built deployment, if any, and rolls it out
+// once it succeeds. A failing release command leaves the running deployment untouched.
+func (d *KubernetesDriver) releaseAndIgnite(depl *models.Deployment) error {
+	if depl.ReleaseCommand != "" {
+		if err := d.deployments.UpdateStatus(depl, models.DeploymentStatusReleasing); err != nil {
+			return err
+		}
+
+		ok, err := d.runReleaseCommand(*depl.Application, depl)
+		if d.deployments.IsCanceled(*depl) {
+			return drivers.ErrDeploymentCanceled
+		}
+		if err != nil || !ok {
+			if err := d.deployments.UpdateStatus(depl, models.DeploymentStatusReleaseFailed); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	if err := d.deployments.UpdateStatus(depl, models.DeploymentStatusDeploying); err != nil {
+		return err
+	}
+
+	if err := d.IgniteApplication(*depl.Application, *depl); err != nil {
+		if err := d.deployments.UpdateStatus(depl, models.DeploymentStatusDeployFailed); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// Exec runs a command in the most recent running pod of an application, with the exec subresource.
+func (d *KubernetesDriver) Exec(ctx context.Context, app models.Application, opts drivers.ExecOptions) (int, error) {
+	pod, err := d.findRunningPod(ctx, app)
+	if err != nil {
+		return -1, err
+	}
+	if pod == nil {
+		return -1, drivers.ErrNoRunningInstance
+	}
+
+	req := d.Client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(d.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: pod.Spec.Containers[0].Name,
+			Command:   opts.Cmd,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    true,
+			Stderr:    !opts.Tty,
+			TTY:       opts.Tty,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(d.RestConfig, "POST", req.URL())
+	if err != nil {
+		return -1, err
+	}
+
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:  opts.Stdin,
+		Stdout: opts.Stdout,
+		Tty:    opts.Tty,
+	}
+	if !opts.Tty {
+		streamOpts.Stderr = opts.Stderr
+	}
+	if opts.Tty {
+		streamOpts.TerminalSizeQueue = newTerminalSizeQueue(ctx, opts.Size, opts.Resize)
+	}
+
+	err = executor.StreamWithContext(ctx, streamOpts)
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+
+	var exitErr exec.CodeExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	return 0, nil
+}
+
+// terminalSizeQueue hands the sizes of the terminal over to the executor, starting with its initial one.
+type terminalSizeQueue struct {
+	ctx     context.Context
+	initial *drivers.TerminalSize
+	resize  <-chan drivers.TerminalSize
+}
+
+func newTerminalSizeQueue(ctx context.Context, size drivers.TerminalSize, resize <-chan drivers.TerminalSize) *terminalSizeQueue {
+	queue := &terminalSizeQueue{ctx: ctx, resize: resize}
+	if size.Cols > 0 && size.Rows > 0 {
+		queue.initial = &size
+	}
+	return queue
+}
+
+// Next returns the next size of the terminal, or nil once the command is over.
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	if q.initial != nil {
+		size := q.initial
+		q.initial = nil
+		return &remotecommand.TerminalSize{Width: uint16(size.Cols), Height: uint16(size.Rows)}
+	}
+
+	select {
+	case size, ok := <-q.resize:
+		if !ok {
+			return nil
+		}
+		return &remotecommand.TerminalSize{Width: uint16(size.Cols), Height: uint16(size.Rows)}
+	case <-q.ctx.Done():
+		return nil
+	}
+}
+
+// findRunningPod returns the most recent running pod of an application, if any.
+func (d *KubernetesDriver) findRunningPod(ctx context.Context, app models.Application) (*corev1.Pod, error) {
+	pods, err := d.Client.CoreV1().Pods(d.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelApplicationID + "=" + app.ID,
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if latest == nil || pod.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = pod
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,191 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// informersResync is how often the informers replay the state of the resources,
+// so that the transitions that failed to be handled are retried.
+const informersResync = time.Minute
+
+// startInformers watches the Jobs, Deployments and pods of the namespace, to pick up the outcome of the builds
+// and rollouts, and the pods whose logs are to be collected. The informers list the resources first,
+// so that the transitions that happened while Citadel wasn't running are handled too.
+func (d *KubernetesDriver) startInformers() error {
+	factory := informers.NewSharedInformerFactoryWithOptions(d.Client, informersResync, informers.WithNamespace(d.Namespace))
+
+	handlers := map[cache.SharedIndexInformer]func(obj any) error{
+		factory.Batch().V1().Jobs().Informer(): func(obj any) error {
+			return d.handleJob(obj.(*batchv1.Job))
+		},
+		factory.Apps().V1().Deployments().Informer(): func(obj any) error {
+			return d.handleRollout(obj.(*appsv1.Deployment))
+		},
+		factory.Core().V1().Pods().Informer(): func(obj any) error {
+			d.handlePod(obj.(*corev1.Pod))
+			return nil
+		},
+	}
+
+	for informer, handle := range handlers {
+		handle := handle
+		onEvent := func(obj any) {
+			if err := handle(obj); err != nil {
+				slog.Error("Error while handling event", "error", err)
+			}
+		}
+		if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    onEvent,
+			UpdateFunc: func(_, obj any) { onEvent(obj) },
+		}); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan struct{})
+	factory.Start(stop)
+	for informerType, synced := range factory.WaitForCacheSync(stop) {
+		if !synced {
+			return fmt.Errorf("failed to sync the informer of %v", informerType)
+		}
+	}
+
+	return nil
+}
+
+// handleJob handles the outcome of a builder Job: a successful build is released and rolled out.
+func (d *KubernetesDriver) handleJob(job *batchv1.Job) error {
+	if job.Labels[logLabelSource] != string(models.LogEntrySourceBuilder) {
+		return nil
+	}
+
+	finished, succeeded := jobOutcome(job)
+	if !finished {
+		return nil
+	}
+
+	// The informers may replay a Job before the deployment leaves the Building status.
+	if _, handled := d.handledBuilds.LoadOrStore(job.Name, struct{}{}); handled {
+		return nil
+	}
+
+	depl, err := d.DeplsRepo.FindOneByIdWithRelatedAppAndCerts(context.Background(), job.Labels[labelDeploymentID])
+	if err != nil {
+		d.handledBuilds.Delete(job.Name)
+		return err
+	}
+
+	// The builders of canceled deployments are removed, and the finished builds were handled already.
+	if depl.Status != models.DeploymentStatusBuilding {
+		return nil
+	}
+
+	if !succeeded {
+		return d.deployments.UpdateStatus(depl, models.DeploymentStatusBuildFailed)
+	}
+
+	d.handleBuildSuccess(depl)
+	return nil
+}
+
+// handleRollout follows the rollout of a deployment to the Deployment of its application. Once all its pods
+// are ready, the deployment succeeds. If they don't get ready in time, the Deployment is brought back to the
+// previous deployment, which keeps serving the traffic.
+func (d *KubernetesDriver) handleRollout(deployment *appsv1.Deployment) error {
+	deplID := deployment.Spec.Template.Labels[labelDeploymentID]
+	if deplID == "" {
+		return nil
+	}
+
+	// The status doesn't reflect the latest pod template yet.
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return nil
+	}
+
+	depl, err := d.DeplsRepo.FindOneByIdWithRelatedAppAndCerts(context.Background(), deplID)
+	if err != nil {
+		return err
+	}
+	if depl.Status != models.DeploymentStatusDeploying {
+		d.rollouts.Delete(depl.ID)
+		return nil
+	}
+	app := *depl.Application
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			d.rollouts.Delete(depl.ID)
+			slog.Warn("Rollout failed", "error", condition.Message, "app_id", app.ID, "deployment_id", depl.ID)
+			d.publishHealthEvent(app, *depl, models.HealthCheckStatusFailed, "has failed: %s.", condition.Message)
+
+			if err := d.abortRollout(app, *depl); err != nil {
+				slog.Error("Failed to abort rollout", "error", err, "app_id", app.ID)
+			}
+			return d.deployments.UpdateStatus(depl, models.DeploymentStatusDeployFailed)
+		}
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	// The old pods stay available until the new ones are ready.
+	status := deployment.Status
+	ready := max(status.AvailableReplicas-(status.Replicas-status.UpdatedReplicas), 0)
+	if last, _ := d.rollouts.Swap(depl.ID, ready); last != ready {
+		d.publishHealthEvent(app, *depl, models.HealthCheckStatusPending, "is passing on %d/%d replicas.", ready, desired)
+	}
+
+	if status.UpdatedReplicas < desired || status.Replicas != desired || status.AvailableReplicas < desired {
+		return nil
+	}
+
+	d.rollouts.Delete(depl.ID)
+	d.publishHealthEvent(app, *depl, models.HealthCheckStatusPassing, "is now passing.")
+
+	// The other process types don't serve any traffic, so they follow once the web one succeeded.
+	if err := d.deployProcessWorkloads(app, *depl); err != nil {
+		slog.Error("Failed to deploy the process types", "error", err, "app_id", app.ID, "deployment_id", depl.ID)
+	}
+
+	return d.deployments.UpdateStatus(depl, models.DeploymentStatusSuccess)
+}
+
+// handlePod starts collecting the logs of the pods labelled for it once their container started.
+func (d *KubernetesDriver) handlePod(pod *corev1.Pod) {
+	if _, collected := pod.Labels[logLabelSource]; !collected {
+		return
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil || status.State.Terminated != nil {
+			go d.collectLogs(*pod)
+			return
+		}
+	}
+}
+
+// publishHealthEvent reports the progress of the health check of a new deployment, if the application has one.
+func (d *KubernetesDriver) publishHealthEvent(app models.Application, depl models.Deployment, status models.HealthCheckStatus, format string, args ...any) {
+	if !app.HasHealthCheck() {
+		return
+	}
+
+	d.healthEvents.Publish(app.ID, models.HealthCheckEvent{
+		DeploymentID: depl.ID,
+		Status:       status,
+		Message:      "Health check on " + app.HealthCheckPath + " " + fmt.Sprintf(format, args...),
+		Timestamp:    time.Now(),
+	})
+}
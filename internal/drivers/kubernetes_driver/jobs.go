@@ -0,0 +1,114 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// jobWaitMargin is how long a one-off Job is waited for beyond its deadline, for Kubernetes to mark it as failed.
+const jobWaitMargin = time.Minute
+
+// oneOffJobName returns the name of the Job running a one-off command of an application.
+func oneOffJobName(app models.Application, opts drivers.JobOptions) string {
+	return "job-" + app.ID + "-" + opts.Name
+}
+
+// RunJob runs a command in a Job from the image of a deployment, with the application's environment and volumes.
+// The pods of the Job don't carry the application's label, so that its Service doesn't route traffic to them.
+func (d *KubernetesDriver) RunJob(app models.Application, depl models.Deployment, opts drivers.JobOptions) (drivers.JobResult, error) {
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return drivers.JobResult{}, err
+	}
+
+	volumes, err := d.VolumesRepo.FindAllFromApplication(context.Background(), app.ID)
+	if err != nil {
+		return drivers.JobResult{}, err
+	}
+	podVolumes, mounts := podVolumes(volumes)
+
+	name := oneOffJobName(app, opts)
+	if err := d.deleteJob(name); err != nil {
+		return drivers.JobResult{}, err
+	}
+
+	labels := map[string]string{"job": opts.Name}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr(int32(0)),
+			ActiveDeadlineSeconds: ptr(int64(opts.Timeout.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:         "job",
+						Image:        drivers.ImageReference(app, depl),
+						Command:      []string{"/bin/sh", "-c", opts.Cmd},
+						Env:          applicationEnv(app),
+						Resources:    resourceRequirements(specs),
+						VolumeMounts: mounts,
+					}},
+					Volumes:          podVolumes,
+					ImagePullSecrets: imagePullSecrets(),
+				},
+			},
+		},
+	}
+
+	if _, err := d.Client.BatchV1().Jobs(d.Namespace).Create(context.Background(), job, metav1.CreateOptions{}); err != nil {
+		return drivers.JobResult{}, err
+	}
+	defer d.deleteJob(name)
+
+	if _, err := d.waitForJob(name, opts.Timeout+jobWaitMargin); err != nil {
+		return drivers.JobResult{}, err
+	}
+
+	output, err := d.readJobOutput(name)
+	if err != nil {
+		return drivers.JobResult{}, err
+	}
+	result := drivers.JobResult{ExitCode: -1, Output: output}
+
+	finished, err := d.Client.BatchV1().Jobs(d.Namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return result, err
+	}
+	if jobDeadlineExceeded(finished) {
+		return result, drivers.ErrJobTimedOut
+	}
+
+	pod, err := d.findJobPod(context.Background(), name)
+	if err != nil || pod == nil {
+		return result, err
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			result.ExitCode = int(status.State.Terminated.ExitCode)
+		}
+	}
+
+	return result, nil
+}
+
+// jobDeadlineExceeded tells whether a Job failed because it ran longer than its deadline.
+func jobDeadlineExceeded(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue && condition.Reason == batchv1.JobReasonDeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"log"
+	"os"
+	"sync"
+
+	caesar "github.com/caesar-rocks/core"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// defaultNamespace is the namespace the resources of the applications are created in,
+// unless KUBERNETES_NAMESPACE says otherwise.
+const defaultNamespace = "citadel"
+
+// KubernetesDriver runs the applications as Deployments exposed by Services and Ingresses, their builds and
+// release commands as Jobs, and the databases as StatefulSets. The volumes are PersistentVolumeClaims. The certificates are issued by cert-manager.
+type KubernetesDriver struct {
+	Client           kubernetes.Interface
+	Dynamic          dynamic.Interface
+	RestConfig       *rest.Config
+	Namespace        string
+	AppsRepo         *repositories.ApplicationsRepository
+	DeplsRepo        *repositories.DeploymentsRepository
+	CertsRepo        *repositories.CertificatesRepository
+	LogsRepo         *repositories.LogEntriesRepository
+	ProcessTypesRepo *repositories.ProcessTypesRepository
+	VolumesRepo      *repositories.VolumesRepository
+	ipv4             string
+	ipv6             string
+
+	// deployments walks the deployments through their statuses, and stores their logs.
+	deployments *drivers.DeploymentTracker
+
+	// handledBuilds holds the names of the builder Jobs whose outcome has been handled.
+	handledBuilds sync.Map
+
+	// rollouts holds the number of ready replicas last reported for each deployment being rolled out.
+	rollouts sync.Map
+
+	healthEvents drivers.HealthEventsBroker
+
+	// collectedPods holds the UIDs of the pods whose logs are being collected.
+	collectedPods sync.Map
+	logEntries    chan models.LogEntry
+}
+
+// New connects to the cluster Citadel runs in, or to the one of the KUBECONFIG file when it runs outside of it.
+func New(appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.DeploymentsRepository, certsRepo *repositories.CertificatesRepository, logsRepo *repositories.LogEntriesRepository, processTypesRepo *repositories.ProcessTypesRepository, volumesRepo *repositories.VolumesRepository) *KubernetesDriver {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		config, err = clientcmd.BuildConfigFromFlags("", os.Getenv("KUBECONFIG"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	namespace := os.Getenv("KUBERNETES_NAMESPACE")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return NewWithClients(client, dynamicClient, config, namespace, appsRepo, deplsRepo, certsRepo, logsRepo, processTypesRepo, volumesRepo)
+}
+
+// NewWithClients creates a driver from existing clients, e.g. the fake ones of client-go.
+func NewWithClients(client kubernetes.Interface, dynamicClient dynamic.Interface, config *rest.Config, namespace string, appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.DeploymentsRepository, certsRepo *repositories.CertificatesRepository, logsRepo *repositories.LogEntriesRepository, processTypesRepo *repositories.ProcessTypesRepository, volumesRepo *repositories.VolumesRepository) *KubernetesDriver {
+	return &KubernetesDriver{
+		Client:           client,
+		Dynamic:          dynamicClient,
+		RestConfig:       config,
+		Namespace:        namespace,
+		AppsRepo:         appsRepo,
+		DeplsRepo:        deplsRepo,
+		CertsRepo:        certsRepo,
+		LogsRepo:         logsRepo,
+		deployments:      drivers.NewDeploymentTracker(deplsRepo, logsRepo, processTypesRepo),
+		ProcessTypesRepo: processTypesRepo,
+		VolumesRepo:      volumesRepo,
+	}
+}
+
+func (d *KubernetesDriver) Init() error {
+	if err := d.ensureNamespace(); err != nil {
+		return err
+	}
+	d.startLogCollector()
+	if err := d.startInformers(); err != nil {
+		return err
+	}
+	return d.setIPs()
+}
+
+func (d *KubernetesDriver) CreateApplication(app models.Application) error {
+	return nil
+}
+
+func (d *KubernetesDriver) DeleteApplication(app models.Application) error {
+	return d.removeApplication(app)
+}
+
+// IgniteApplication rolls out a deployment to the Deployment of the application. The previous pods keep
+// serving the traffic until the new ones are ready, and the outcome is picked up by the informers (see handleRollout).
+// The Deployments of the other process types are rolled out once it succeeds.
+func (d *KubernetesDriver) IgniteApplication(app models.Application, depl models.Deployment) error {
+	return d.deployApplication(app, depl)
+}
+
+// StreamLogs streams the logs of the pods of all the process types of an application, along with
+// the health check events of its new deployments, until the client disconnects.
+// The logs of builds and of the deployments that are no longer running are sent up to their end (see streamBuildLogs).
+func (d *KubernetesDriver) StreamLogs(ctx *caesar.Context, app models.Application, opts drivers.StreamLogsOptions) error {
+	if opts.Scope == drivers.LogsScopeBuilder {
+		return d.streamBuildLogs(ctx, *opts.Deployment, opts)
+	}
+	if opts.Deployment != nil && !d.isRunningDeployment(app, *opts.Deployment) {
+		if err := d.deployments.ReplayLogs(ctx, *opts.Deployment, models.LogEntrySourceApp, opts); err != nil {
+			return err
+		}
+		return drivers.SendEndEvent(ctx, "")
+	}
+
+	healthEvents, unsubscribe := d.healthEvents.Subscribe(app.ID)
+	defer unsubscribe()
+
+	closed := ctx.Context().Done()
+	logEvents := make(chan models.LogEvent)
+
+	if err := d.followApplicationLogs(ctx.Context(), app, logEvents); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case event := <-logEvents:
+			if err := drivers.SendLogEvent(ctx, event, opts); err != nil {
+				return err
+			}
+		case event := <-healthEvents:
+			if err := drivers.SendHealthEvent(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ensureNamespace creates the namespace of the applications, if needed.
+func (d *KubernetesDriver) ensureNamespace() error {
+	_, err := d.Client.CoreV1().Namespaces().Get(context.Background(), d.Namespace, metav1.GetOptions{})
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	_, err = d.Client.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: d.Namespace},
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
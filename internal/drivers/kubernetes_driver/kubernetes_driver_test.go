@@ -0,0 +1,267 @@
+package kubernetesDriver
+
+import (
+	"citadel/database"
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/caesar-rocks/orm"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+const testNamespace = "citadel-test"
+
+// newTestDriver creates a driver talking to a fake cluster, whose data is stored in a SQLite database of its own.
+func newTestDriver(t *testing.T) *KubernetesDriver {
+	t.Helper()
+
+	db := orm.NewDatabase(&orm.DatabaseConfig{
+		DBMS: orm.DBMS("sqlite"),
+		DSN:  filepath.Join(t.TempDir(), "citadel.sqlite"),
+	})
+	db.Migrate(database.GetMigrations())
+
+	return NewWithClients(
+		fake.NewSimpleClientset(),
+		dynamicFake.NewSimpleDynamicClient(runtime.NewScheme()),
+		&rest.Config{},
+		testNamespace,
+		repositories.NewApplicationsRepository(db),
+		repositories.NewDeploymentsRepository(db),
+		repositories.NewCertificatesRepository(db),
+		repositories.NewLogEntriesRepository(db),
+		repositories.NewProcessTypesRepository(db),
+		repositories.NewVolumesRepository(db),
+	)
+}
+
+// createDeployment stores an application along with a deployment of it, in the given status.
+func createDeployment(t *testing.T, d *KubernetesDriver, status models.DeploymentStatus) (models.Application, models.Deployment) {
+	t.Helper()
+	ctx := context.Background()
+
+	app := models.Application{Name: "api", Slug: "api", CpuConfig: "shared-cpu-1x", RamConfig: "256MB", Replicas: 1}
+	if err := d.AppsRepo.Create(ctx, &app); err != nil {
+		t.Fatalf("failed to create the application: %v", err)
+	}
+
+	depl := models.Deployment{ApplicationID: app.ID, Status: status, Origin: models.DeploymentOriginCli}
+	if err := d.DeplsRepo.Create(ctx, &depl); err != nil {
+		t.Fatalf("failed to create the deployment: %v", err)
+	}
+	depl.Application = &app
+
+	return app, depl
+}
+
+// storedStatus returns the status of a deployment, as stored.
+func storedStatus(t *testing.T, d *KubernetesDriver, depl models.Deployment) models.DeploymentStatus {
+	t.Helper()
+
+	stored, err := d.DeplsRepo.FindOneBy(context.Background(), "id", depl.ID)
+	if err != nil {
+		t.Fatalf("failed to find the deployment: %v", err)
+	}
+	return stored.Status
+}
+
+// finishJob marks a Job as complete, or as failed.
+func finishJob(t *testing.T, d *KubernetesDriver, name string, succeeded bool) *batchv1.Job {
+	t.Helper()
+	ctx := context.Background()
+
+	job, err := d.Client.BatchV1().Jobs(d.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to find the Job %s: %v", name, err)
+	}
+
+	condition := batchv1.JobComplete
+	if !succeeded {
+		condition = batchv1.JobFailed
+	}
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: condition, Status: corev1.ConditionTrue})
+
+	job, err = d.Client.BatchV1().Jobs(d.Namespace).UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("failed to update the Job %s: %v", name, err)
+	}
+	return job
+}
+
+func TestIgniteBuilderStartsABuilderJob(t *testing.T) {
+	t.Setenv("BUILDER_IMAGE", "builder")
+	d := newTestDriver(t)
+	app, depl := createDeployment(t, d, models.DeploymentStatusBuilding)
+
+	if err := d.IgniteBuilder(app, depl); err != nil {
+		t.Fatalf("IgniteBuilder: %v", err)
+	}
+
+	job, err := d.Client.BatchV1().Jobs(testNamespace).Get(context.Background(), builderJobName(depl), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("the builder Job wasn't created: %v", err)
+	}
+	if got := job.Labels[labelDeploymentID]; got != depl.ID {
+		t.Errorf("the builder Job is labelled with deployment %q, want %q", got, depl.ID)
+	}
+	if got := job.Labels[logLabelSource]; got != string(models.LogEntrySourceBuilder) {
+		t.Errorf("the output of the builder Job is collected as %q, want %q", got, models.LogEntrySourceBuilder)
+	}
+
+	container := job.Spec.Template.Spec.Containers[0]
+	if container.Image != "builder" {
+		t.Errorf("the builder runs %q, want the BUILDER_IMAGE", container.Image)
+	}
+	for _, env := range container.Env {
+		if env.Name == "IMAGE_NAME" && env.Value != app.ID+":"+depl.ID {
+			t.Errorf("the image is built as %q, want it tagged with the deployment", env.Value)
+		}
+	}
+}
+
+func TestHandleJobFailsTheDeploymentsWhoseBuildFailed(t *testing.T) {
+	d := newTestDriver(t)
+	app, depl := createDeployment(t, d, models.DeploymentStatusBuilding)
+
+	if err := d.IgniteBuilder(app, depl); err != nil {
+		t.Fatalf("IgniteBuilder: %v", err)
+	}
+	job := finishJob(t, d, builderJobName(depl), false)
+
+	if err := d.handleJob(job); err != nil {
+		t.Fatalf("handleJob: %v", err)
+	}
+	if got := storedStatus(t, d, depl); got != models.DeploymentStatusBuildFailed {
+		t.Errorf("the deployment is %q, want %q", got, models.DeploymentStatusBuildFailed)
+	}
+}
+
+func TestCancelDeploymentRemovesTheBuilderAndKeepsTheDeploymentCanceled(t *testing.T) {
+	d := newTestDriver(t)
+	app, depl := createDeployment(t, d, models.DeploymentStatusBuilding)
+
+	if err := d.IgniteBuilder(app, depl); err != nil {
+		t.Fatalf("IgniteBuilder: %v", err)
+	}
+	job := finishJob(t, d, builderJobName(depl), false)
+
+	if err := d.CancelDeployment(app, depl); err != nil {
+		t.Fatalf("CancelDeployment: %v", err)
+	}
+
+	_, err := d.Client.BatchV1().Jobs(testNamespace).Get(context.Background(), builderJobName(depl), metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("the builder Job is still there (err = %v)", err)
+	}
+
+	// The outcome of the build may still be picked up by the informers.
+	if err := d.handleJob(job); err != nil {
+		t.Fatalf("handleJob: %v", err)
+	}
+	if err := d.deployments.UpdateStatus(&depl, models.DeploymentStatusReleasing); !errors.Is(err, drivers.ErrDeploymentCanceled) {
+		t.Errorf("carrying on with the canceled deployment returned %v, want %v", err, drivers.ErrDeploymentCanceled)
+	}
+	if got := storedStatus(t, d, depl); got != models.DeploymentStatusCanceled {
+		t.Errorf("the deployment is %q, want %q", got, models.DeploymentStatusCanceled)
+	}
+}
+
+func TestHandleRolloutSucceedsOnceTheReplicasAreReady(t *testing.T) {
+	d := newTestDriver(t)
+	app, depl := createDeployment(t, d, models.DeploymentStatusDeploying)
+	depl.ImageTag = depl.ID
+
+	if err := d.IgniteApplication(app, depl); err != nil {
+		t.Fatalf("IgniteApplication: %v", err)
+	}
+
+	ctx := context.Background()
+	deployment, err := d.Client.AppsV1().Deployments(testNamespace).Get(ctx, applicationName(app), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("the Deployment wasn't created: %v", err)
+	}
+	if got, want := deployment.Spec.Template.Spec.Containers[0].Image, drivers.ImageReference(app, depl); got != want {
+		t.Errorf("the Deployment runs %q, want %q", got, want)
+	}
+	if _, err := d.Client.CoreV1().Services(testNamespace).Get(ctx, applicationName(app), metav1.GetOptions{}); err != nil {
+		t.Errorf("the Service wasn't created: %v", err)
+	}
+	if _, err := d.Client.NetworkingV1().Ingresses(testNamespace).Get(ctx, applicationName(app), metav1.GetOptions{}); err != nil {
+		t.Errorf("the Ingress wasn't created: %v", err)
+	}
+
+	// The pods of the new deployment are created, but aren't ready yet.
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: deployment.Generation, Replicas: 1, UpdatedReplicas: 1}
+	if err := d.handleRollout(deployment); err != nil {
+		t.Fatalf("handleRollout: %v", err)
+	}
+	if got := storedStatus(t, d, depl); got != models.DeploymentStatusDeploying {
+		t.Fatalf("the deployment is %q before its replicas are ready, want %q", got, models.DeploymentStatusDeploying)
+	}
+
+	deployment.Status.AvailableReplicas = 1
+	if err := d.handleRollout(deployment); err != nil {
+		t.Fatalf("handleRollout: %v", err)
+	}
+	if got := storedStatus(t, d, depl); got != models.DeploymentStatusSuccess {
+		t.Errorf("the deployment is %q once its replicas are ready, want %q", got, models.DeploymentStatusSuccess)
+	}
+}
+
+func TestVolumesAreBackedByPersistentVolumeClaims(t *testing.T) {
+	d := newTestDriver(t)
+	volume := models.Volume{ID: "vol1", Size: 5}
+
+	if err := d.CreateVolume(volume); err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+
+	claims := d.Client.CoreV1().PersistentVolumeClaims(testNamespace)
+	claim, err := claims.Get(context.Background(), volumeClaimName(volume), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("the PersistentVolumeClaim wasn't created: %v", err)
+	}
+	if got := claim.Spec.Resources.Requests[corev1.ResourceStorage]; got.String() != "5Gi" {
+		t.Errorf("the PersistentVolumeClaim requests %s, want 5Gi", got.String())
+	}
+
+	if err := d.DeleteVolume(volume); err != nil {
+		t.Fatalf("DeleteVolume: %v", err)
+	}
+	if _, err := claims.Get(context.Background(), volumeClaimName(volume), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("the PersistentVolumeClaim is still there (err = %v)", err)
+	}
+
+	// Deleting it again is a no-op.
+	if err := d.DeleteVolume(volume); err != nil {
+		t.Errorf("DeleteVolume of a deleted volume: %v", err)
+	}
+}
+
+func TestStorageBucketsAreUnsupported(t *testing.T) {
+	d := newTestDriver(t)
+	bucket := models.StorageBucket{ID: "bucket1"}
+
+	if _, _, _, _, err := d.CreateStorageBucket(bucket); !errors.Is(err, errStorageUnsupported) {
+		t.Errorf("CreateStorageBucket returned %v, want %v", err, errStorageUnsupported)
+	}
+	if _, _, err := d.GetFilesAndTotalSize(bucket); !errors.Is(err, errStorageUnsupported) {
+		t.Errorf("GetFilesAndTotalSize returned %v, want %v", err, errStorageUnsupported)
+	}
+	if err := d.DeleteStorageBucket(bucket); !errors.Is(err, errStorageUnsupported) {
+		t.Errorf("DeleteStorageBucket returned %v, want %v", err, errStorageUnsupported)
+	}
+}
@@ -0,0 +1,231 @@
+package kubernetesDriver
+
+import (
+	"bufio"
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	caesar "github.com/caesar-rocks/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The pods whose output is collected are labelled with the application and deployment it belongs to,
+// and the ones of the applications with their process type.
+const (
+	logLabelSource        = "logs.source"
+	logLabelApplicationID = "logs.application_id"
+	logLabelDeploymentID  = "logs.deployment_id"
+	logLabelProcess       = "logs.process"
+)
+
+// logLabels returns the labels telling the log collector where the output of a pod comes from.
+func logLabels(app models.Application, depl models.Deployment, source models.LogEntrySource) map[string]string {
+	return map[string]string{
+		logLabelSource:        string(source),
+		logLabelApplicationID: app.ID,
+		logLabelDeploymentID:  depl.ID,
+	}
+}
+
+// startLogCollector stores the log entries collected from the pods, which are picked up as they start (see handlePod).
+func (d *KubernetesDriver) startLogCollector() {
+	d.logEntries = make(chan models.LogEntry, 4*drivers.LogBatchSize)
+	go d.deployments.StoreLogEntries(d.logEntries)
+}
+
+// collectLogs follows the output of a pod until it stops. When the collection resumes
+// (e.g. after a restart of Citadel), it starts right after the last entry stored for the pod.
+// Kubernetes merges the stdout and stderr of the containers, so all the entries are stored as stdout.
+func (d *KubernetesDriver) collectLogs(pod corev1.Pod) {
+	if _, collecting := d.collectedPods.LoadOrStore(pod.UID, struct{}{}); collecting {
+		return
+	}
+	defer d.collectedPods.Delete(pod.UID)
+
+	opts := &corev1.PodLogOptions{Follow: true, Timestamps: true}
+
+	latest, err := d.LogsRepo.FindLatestTimestampOfInstance(context.Background(), pod.Name)
+	if err != nil {
+		slog.Error("Failed to resume log collection", "error", err, "pod", pod.Name)
+		return
+	}
+	// SinceTime is rounded down to the second, so the lines stored already are skipped.
+	if !latest.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: latest}
+	}
+
+	reader, err := d.Client.CoreV1().Pods(d.Namespace).GetLogs(pod.Name, opts).Stream(context.Background())
+	if err != nil {
+		slog.Error("Failed to collect logs", "error", err, "pod", pod.Name)
+		return
+	}
+	defer reader.Close()
+
+	err = scanLogLines(reader, func(timestamp time.Time, message string) {
+		if !timestamp.After(latest) {
+			return
+		}
+		d.logEntries <- models.LogEntry{
+			ApplicationID: pod.Labels[logLabelApplicationID],
+			DeploymentID:  pod.Labels[logLabelDeploymentID],
+			Source:        models.LogEntrySource(pod.Labels[logLabelSource]),
+			Process:       pod.Labels[logLabelProcess],
+			Stream:        models.LogEntryStreamStdout,
+			Instance:      pod.Name,
+			Message:       message,
+			Timestamp:     timestamp,
+		}
+	})
+	if err != nil {
+		slog.Error("Log collection interrupted", "error", err, "pod", pod.Name)
+	}
+}
+
+// scanLogLines calls onLine with the timestamp and the message of each line of the timestamped logs of a pod.
+// The lines are truncated, and stripped of the characters that can't be stored or displayed.
+func scanLogLines(reader io.Reader, onLine func(timestamp time.Time, message string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		timestamp, message := time.Now(), line
+		if prefix, rest, found := strings.Cut(line, " "); found {
+			if t, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+				timestamp, message = t, rest
+			}
+		}
+		if len(message) > drivers.MaxLogLineSize {
+			message = message[:drivers.MaxLogLineSize]
+		}
+		onLine(timestamp, strings.ToValidUTF8(strings.ReplaceAll(message, "\x00", ""), ""))
+	}
+
+	return scanner.Err()
+}
+
+// followApplicationLogs sends the events of the lines of the running pods of all the process types
+// of an application, starting with their last lines, until the client is gone.
+func (d *KubernetesDriver) followApplicationLogs(ctx context.Context, app models.Application, events chan<- models.LogEvent) error {
+	pods := []corev1.Pod{}
+	for _, selector := range []string{labelApplicationID + "=" + app.ID, labelProcessApplicationID + "=" + app.ID} {
+		list, err := d.Client.CoreV1().Pods(d.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return err
+		}
+		pods = append(pods, list.Items...)
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		reader, err := d.Client.CoreV1().Pods(d.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Follow:     true,
+			Timestamps: true,
+			TailLines:  ptr(int64(50)),
+		}).Stream(ctx)
+		if err != nil {
+			return err
+		}
+
+		go func(pod corev1.Pod) {
+			defer reader.Close()
+			scanLogLines(reader, func(timestamp time.Time, message string) {
+				event := models.NewLogEvent(timestamp, models.LogEntryStreamStdout, pod.Labels[logLabelDeploymentID], message)
+				event.Process = pod.Labels[logLabelProcess]
+				if event.Process == "" {
+					event.Process = models.ProcessTypeWeb
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+				}
+			})
+		}(pod)
+	}
+
+	return nil
+}
+
+// streamBuildLogs streams the output of the build of a deployment from its start, following it until the
+// builder exits. The output of the builders that are gone is replayed from the stored logs.
+func (d *KubernetesDriver) streamBuildLogs(ctx *caesar.Context, depl models.Deployment, opts drivers.StreamLogsOptions) error {
+	depl, pod, err := d.waitForBuilder(ctx, depl, opts)
+	if err != nil {
+		return err
+	}
+	switch depl.Status {
+	case models.DeploymentStatusSuperseded:
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeSuperseded)
+	case models.DeploymentStatusCanceled:
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeCanceled)
+	}
+
+	if pod == nil {
+		if err := d.deployments.ReplayLogs(ctx, depl, models.LogEntrySourceBuilder, opts); err != nil {
+			return err
+		}
+		return drivers.SendEndEvent(ctx, drivers.StoredBuildOutcome(depl))
+	}
+
+	reader, err := d.Client.CoreV1().Pods(d.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Follow:     true,
+		Timestamps: true,
+	}).Stream(ctx.Context())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var sendErr error
+	err = scanLogLines(reader, func(timestamp time.Time, message string) {
+		if sendErr == nil {
+			sendErr = drivers.SendLogEvent(ctx, models.NewLogEvent(timestamp, models.LogEntryStreamStdout, depl.ID, message), opts)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if d.deployments.IsCanceled(depl) {
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeCanceled)
+	}
+
+	job, err := d.Client.BatchV1().Jobs(d.Namespace).Get(ctx.Context(), builderJobName(depl), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if _, succeeded := jobOutcome(job); !succeeded {
+		return drivers.SendEndEvent(ctx, drivers.BuildOutcomeFailed)
+	}
+
+	return drivers.SendEndEvent(ctx, drivers.BuildOutcomeSucceeded)
+}
+
+// waitForBuilder waits for the container of the builder of a deployment to start, if it is being built
+// (see drivers.DeploymentTracker.WaitForBuilder). It returns the deployment as it is by then,
+// along with the pod of its builder, unless it is gone.
+func (d *KubernetesDriver) waitForBuilder(ctx *caesar.Context, depl models.Deployment, opts drivers.StreamLogsOptions) (models.Deployment, *corev1.Pod, error) {
+	depl, err := d.deployments.WaitForBuilder(ctx, depl, opts, func(depl models.Deployment) (bool, error) {
+		pod, err := d.findJobPod(ctx.Context(), builderJobName(depl))
+		return pod != nil && pod.Status.Phase != corev1.PodPending, err
+	})
+	if err != nil {
+		return depl, nil, err
+	}
+
+	pod, err := d.findJobPod(ctx.Context(), builderJobName(depl))
+	return depl, pod, err
+}
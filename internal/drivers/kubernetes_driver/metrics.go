@@ -0,0 +1,15 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"errors"
+)
+
+// errMetricsUnsupported is returned by Metrics, as the resource usage of the pods is left to the cluster's own monitoring.
+var errMetricsUnsupported = errors.New("metrics aren't supported by the Kubernetes driver")
+
+func (d *KubernetesDriver) Metrics(ctx context.Context, query drivers.MetricsQuery) ([]models.MetricSample, error) {
+	return nil, errMetricsUnsupported
+}
@@ -0,0 +1,181 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The Deployments of the process types other than the web one, and their pods, are labelled with the application
+// they belong to and the name of their process type. They don't carry the "application_id" label, so that
+// the Service of the application doesn't route any traffic to them, and their rollouts aren't followed.
+const (
+	labelProcessApplicationID = "process_application_id"
+	labelProcess              = "process"
+)
+
+// processWorkloadName returns the name of the Deployment running a process type of an application.
+func processWorkloadName(app models.Application, name string) string {
+	return applicationName(app) + "-" + name
+}
+
+// processDeploymentSpec returns the Deployment running a process type of a deployment.
+// The volumes attached to the application are mounted in its pods too.
+func processDeploymentSpec(app models.Application, depl models.Deployment, process models.ProcessType, specs models.ComputingSpecs, volumes []models.Volume) *appsv1.Deployment {
+	replicas := int32(process.GetReplicas())
+	selector := map[string]string{
+		labelProcessApplicationID: app.ID,
+		labelProcess:              process.Name,
+	}
+
+	labels := logLabels(app, depl, models.LogEntrySourceApp)
+	labels[logLabelProcess] = process.Name
+	for key, value := range selector {
+		labels[key] = value
+	}
+
+	podVolumes, mounts := podVolumes(volumes)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   processWorkloadName(app, process.Name),
+			Labels: selector,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas:        &replicas,
+			Selector:        &metav1.LabelSelector{MatchLabels: selector},
+			MinReadySeconds: int32(rolloutGracePeriod.Seconds()),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:         "app",
+						Image:        drivers.ImageReference(app, depl),
+						Command:      drivers.ProcessCommand(depl.Processes[process.Name]),
+						Env:          applicationEnv(app),
+						Resources:    resourceRequirements(specs),
+						VolumeMounts: mounts,
+					}},
+					Volumes:          podVolumes,
+					ImagePullSecrets: imagePullSecrets(),
+				},
+			},
+		},
+	}
+}
+
+// deployProcessWorkloads creates the Deployments of the process types declared by a deployment besides
+// the web one, or rolls the deployment out to them, and removes the ones of the process types it doesn't
+// declare anymore.
+func (d *KubernetesDriver) deployProcessWorkloads(app models.Application, depl models.Deployment) error {
+	ctx := context.Background()
+	deployments := d.Client.AppsV1().Deployments(d.Namespace)
+
+	processes, err := d.ProcessTypesRepo.FindAllFromApplication(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := d.VolumesRepo.FindAllFromApplication(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range depl.BackgroundProcessTypes() {
+		// The process types are declared once a deployment declaring them succeeds, so the ones
+		// new to this deployment get a single instance until then.
+		process := models.ProcessType{Name: name, Replicas: 1}
+		for _, p := range processes {
+			if p.Name == name {
+				process = p
+			}
+		}
+
+		specs, err := process.GetComputingSpecs(app)
+		if err != nil {
+			return err
+		}
+		deployment := processDeploymentSpec(app, depl, process, specs, volumes)
+
+		current, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			if _, err := deployments.Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			// The number of replicas is managed through ScaleProcessType, so the current one is kept.
+			deployment.Spec.Replicas = current.Spec.Replicas
+			deployment.ResourceVersion = current.ResourceVersion
+			if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return d.removeProcessWorkloads(app, depl.Processes)
+}
+
+// removeProcessWorkloads removes the Deployments of the process types of an application that aren't part of
+// the declared ones, i.e. all of them if none is.
+func (d *KubernetesDriver) removeProcessWorkloads(app models.Application, declared map[string]string) error {
+	ctx := context.Background()
+	deployments := d.Client.AppsV1().Deployments(d.Namespace)
+
+	list, err := deployments.List(ctx, metav1.ListOptions{
+		LabelSelector: labelProcessApplicationID + "=" + app.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, deployment := range list.Items {
+		if _, ok := declared[deployment.Labels[labelProcess]]; ok {
+			continue
+		}
+		err := deployments.Delete(ctx, deployment.Name, metav1.DeleteOptions{
+			PropagationPolicy: ptr(metav1.DeletePropagationBackground),
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScaleProcessType sets the number of replicas and the resources of the Deployment of a process type to its own.
+// The process types that haven't been deployed yet get them on their first deployment.
+func (d *KubernetesDriver) ScaleProcessType(app models.Application, process models.ProcessType) error {
+	ctx := context.Background()
+	deployments := d.Client.AppsV1().Deployments(d.Namespace)
+
+	deployment, err := deployments.Get(ctx, processWorkloadName(app, process.Name), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	specs, err := process.GetComputingSpecs(app)
+	if err != nil {
+		return err
+	}
+
+	replicas := int32(process.GetReplicas())
+	deployment.Spec.Replicas = &replicas
+	for i := range deployment.Spec.Template.Spec.Containers {
+		deployment.Spec.Template.Spec.Containers[i].Resources = resourceRequirements(specs)
+	}
+
+	_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+	return err
+}
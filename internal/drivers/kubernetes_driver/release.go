@@ -0,0 +1,166 @@
+package kubernetesDriver
+
+import (
+	"bytes"
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// releaseTimeout is how long a release command may run before the release is considered failed.
+	releaseTimeout = 30 * time.Minute
+
+	// maxOutputSize is the number of trailing bytes of the output of the Jobs that is kept
+	// (e.g. the one of the release command, on the deployment).
+	maxOutputSize = 64 * 1024
+)
+
+// releaseJobName returns the name of the Job running the release command of a deployment.
+func releaseJobName(depl models.Deployment) string {
+	return "release-" + depl.ID
+}
+
+// runReleaseCommand runs the release command of a deployment (e.g. database migrations) in a Job
+// from its image, with the application's environment and volumes. The output is saved on the deployment.
+// It returns false if the command exited with a non-zero code.
+func (d *KubernetesDriver) runReleaseCommand(app models.Application, depl *models.Deployment) (bool, error) {
+	specs, err := app.GetComputingSpecs()
+	if err != nil {
+		return false, err
+	}
+
+	volumes, err := d.VolumesRepo.FindAllFromApplication(context.Background(), app.ID)
+	if err != nil {
+		return false, err
+	}
+	podVolumes, mounts := podVolumes(volumes)
+
+	name := releaseJobName(*depl)
+	if err := d.deleteJob(name); err != nil {
+		return false, err
+	}
+
+	labels := logLabels(app, *depl, models.LogEntrySourceRelease)
+	labels[labelDeploymentID] = depl.ID
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr(int32(0)),
+			ActiveDeadlineSeconds: ptr(int64(releaseTimeout.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:         "release",
+						Image:        drivers.ImageReference(app, *depl),
+						Command:      []string{"/bin/sh", "-c", depl.ReleaseCommand},
+						Env:          applicationEnv(app),
+						Resources:    resourceRequirements(specs),
+						VolumeMounts: mounts,
+					}},
+					Volumes:          podVolumes,
+					ImagePullSecrets: imagePullSecrets(),
+				},
+			},
+		},
+	}
+
+	if _, err := d.Client.BatchV1().Jobs(d.Namespace).Create(context.Background(), job, metav1.CreateOptions{}); err != nil {
+		return false, err
+	}
+	defer d.deleteJob(name)
+
+	succeeded, err := d.waitForJob(name, releaseTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := d.readJobOutput(name)
+	if err != nil {
+		return false, err
+	}
+	if !succeeded {
+		output += "\nRelease command failed.\n"
+	}
+	depl.ReleaseOutput = output
+
+	return succeeded, nil
+}
+
+// waitForJob waits for a Job to finish, and tells whether it succeeded.
+func (d *KubernetesDriver) waitForJob(name string, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		job, err := d.Client.BatchV1().Jobs(d.Namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("job is gone: %w", err)
+		}
+		if finished, succeeded := jobOutcome(job); finished {
+			return succeeded, nil
+		}
+		time.Sleep(time.Second)
+	}
+
+	return false, errors.New("timed out waiting for the job to finish")
+}
+
+// findJobPod returns the most recent pod of a Job, if any.
+func (d *KubernetesDriver) findJobPod(ctx context.Context, jobName string) (*corev1.Pod, error) {
+	pods, err := d.Client.CoreV1().Pods(d.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: batchv1.JobNameLabel + "=" + jobName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if latest == nil || pod.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = pod
+		}
+	}
+
+	return latest, nil
+}
+
+// readJobOutput returns the trailing output of the pod of a Job.
+func (d *KubernetesDriver) readJobOutput(jobName string) (string, error) {
+	pod, err := d.findJobPod(context.Background(), jobName)
+	if err != nil || pod == nil {
+		return "", err
+	}
+
+	reader, err := d.Client.CoreV1().Pods(d.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).Stream(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(buf, reader); err != nil {
+		return "", err
+	}
+
+	output := buf.Bytes()
+	if len(output) > maxOutputSize {
+		output = output[len(output)-maxOutputSize:]
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,21 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// resourceRequirements turns computing specs into the resource limits of a container. Kubernetes has no notion
+// of CPU shares, so only the quotas apply, and the pods are scheduled as if they used all of them.
+func resourceRequirements(specs models.ComputingSpecs) corev1.ResourceRequirements {
+	limits := corev1.ResourceList{}
+	if specs.NanoCPUs > 0 {
+		limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(specs.NanoCPUs/1e6, resource.DecimalSI)
+	}
+	if specs.Memory > 0 {
+		limits[corev1.ResourceMemory] = *resource.NewQuantity(specs.Memory, resource.BinarySI)
+	}
+	return corev1.ResourceRequirements{Limits: limits}
+}
@@ -0,0 +1,299 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// certificatesResource is the resource of the cert-manager Certificates.
+var certificatesResource = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// defaultClusterIssuer is the cert-manager ClusterIssuer of the certificates of the custom domains,
+// unless KUBERNETES_CLUSTER_ISSUER says otherwise.
+const defaultClusterIssuer = "letsencrypt"
+
+// certificateName returns the name of the cert-manager Certificate of a domain, and of the secret it is stored in.
+func certificateName(cert models.Certificate) string {
+	return "cert-" + cert.ID
+}
+
+// ingressSpec returns the Ingress routing the traffic of an application's domains to its Service.
+// The default domain is served with the wildcard certificate, if any, and each verified custom domain
+// with the certificate cert-manager issued for it.
+func ingressSpec(app models.Application, certs []models.Certificate) *networkingv1.Ingress {
+	backend := networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{
+			Name: applicationName(app),
+			Port: networkingv1.ServiceBackendPort{Number: 80},
+		},
+	}
+	rule := func(host string) networkingv1.IngressRule {
+		return networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: ptr(networkingv1.PathTypePrefix),
+						Backend:  backend,
+					}},
+				},
+			},
+		}
+	}
+
+	defaultHost := app.Slug + "." + os.Getenv("WILDCARD_TRAEFIK_DOMAIN")
+	spec := networkingv1.IngressSpec{
+		Rules: []networkingv1.IngressRule{rule(defaultHost)},
+	}
+	if class := os.Getenv("KUBERNETES_INGRESS_CLASS"); class != "" {
+		spec.IngressClassName = &class
+	}
+	if secret := os.Getenv("KUBERNETES_WILDCARD_TLS_SECRET"); secret != "" {
+		spec.TLS = append(spec.TLS, networkingv1.IngressTLS{Hosts: []string{defaultHost}, SecretName: secret})
+	}
+
+	for _, cert := range certs {
+		if cert.Status != models.CertificateStatusVerified {
+			continue
+		}
+
+		hostnames := cert.Hostnames()
+		for _, hostname := range hostnames {
+			spec.Rules = append(spec.Rules, rule(hostname))
+		}
+		spec.TLS = append(spec.TLS, networkingv1.IngressTLS{Hosts: hostnames, SecretName: certificateName(cert)})
+	}
+
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   applicationName(app),
+			Labels: map[string]string{labelApplicationID: app.ID},
+		},
+		Spec: spec,
+	}
+}
+
+// refreshRouting updates the Ingress of the application, so that the traffic of newly verified domains
+// is routed to it, and the one of deleted domains isn't anymore. Applications that haven't been deployed yet
+// get their Ingress on their first deployment.
+func (d *KubernetesDriver) refreshRouting(app models.Application) error {
+	ctx := context.Background()
+
+	if _, err := d.Client.CoreV1().Services(d.Namespace).Get(ctx, applicationName(app), metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	certs, err := d.CertsRepo.FindAllVerifiedFromApp(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	ingress := ingressSpec(app, certs)
+	ingresses := d.Client.NetworkingV1().Ingresses(d.Namespace)
+
+	current, err := ingresses.Get(ctx, ingress.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = ingresses.Create(ctx, ingress, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	current.Spec = ingress.Spec
+	_, err = ingresses.Update(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+func (d *KubernetesDriver) CreateCertificate(app models.Application, cert models.Certificate) ([]models.DnsEntry, error) {
+	entries := []models.DnsEntry{}
+	for _, hostname := range cert.Hostnames() {
+		if d.ipv4 != "" {
+			entries = append(entries, models.DnsEntry{Hostname: hostname, Type: "A", Value: d.ipv4})
+		}
+		if d.ipv6 != "" {
+			entries = append(entries, models.DnsEntry{Hostname: hostname, Type: "AAAA", Value: d.ipv6})
+		}
+	}
+	return entries, nil
+}
+
+func (d *KubernetesDriver) CheckDnsConfig(app models.Application, cert models.Certificate) (bool, error) {
+	if d.ipv4 == "" && d.ipv6 == "" {
+		return false, errors.New("the addresses of the ingress controller are unknown")
+	}
+
+	for _, hostname := range cert.Hostnames() {
+		records, err := net.LookupIP(hostname)
+		if err != nil {
+			return false, err
+		}
+
+		ipv4Found := d.ipv4 == ""
+		ipv6Found := d.ipv6 == ""
+
+		for _, ip := range records {
+			if ip.To4() != nil {
+				if ip.String() == d.ipv4 {
+					ipv4Found = true
+				}
+			} else {
+				if ip.String() == d.ipv6 {
+					ipv6Found = true
+				}
+			}
+		}
+
+		if !ipv4Found || !ipv6Found {
+			return false, errors.New("no IPv4 or IPv6 address found for " + hostname)
+		}
+	}
+
+	return true, nil
+}
+
+// ActivateCertificate has cert-manager issue a certificate for a verified domain, and starts routing its traffic
+// to the application. The Ingress serves the certificate as soon as cert-manager stores it in its secret.
+func (d *KubernetesDriver) ActivateCertificate(app models.Application, cert models.Certificate) error {
+	issuer := os.Getenv("KUBERNETES_CLUSTER_ISSUER")
+	if issuer == "" {
+		issuer = defaultClusterIssuer
+	}
+
+	hostnames := make([]any, 0)
+	for _, hostname := range cert.Hostnames() {
+		hostnames = append(hostnames, hostname)
+	}
+
+	certificate := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]any{
+			"name":   certificateName(cert),
+			"labels": map[string]any{labelApplicationID: app.ID},
+		},
+		"spec": map[string]any{
+			"secretName": certificateName(cert),
+			"dnsNames":   hostnames,
+			"issuerRef": map[string]any{
+				"name": issuer,
+				"kind": "ClusterIssuer",
+			},
+		},
+	}}
+
+	ctx := context.Background()
+	certificates := d.Dynamic.Resource(certificatesResource).Namespace(d.Namespace)
+
+	current, err := certificates.Get(ctx, certificateName(cert), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := certificates.Create(ctx, certificate, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		certificate.SetResourceVersion(current.GetResourceVersion())
+		if _, err := certificates.Update(ctx, certificate, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return d.refreshRouting(app)
+}
+
+// DeleteCertificate stops routing the traffic of a deleted domain to the application,
+// and removes its certificate.
+func (d *KubernetesDriver) DeleteCertificate(app models.Application, cert models.Certificate) error {
+	if err := d.refreshRouting(app); err != nil {
+		return err
+	}
+
+	err := d.Dynamic.Resource(certificatesResource).Namespace(d.Namespace).Delete(context.Background(), certificateName(cert), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// cert-manager leaves the secrets of the deleted certificates behind.
+	err = d.Client.CoreV1().Secrets(d.Namespace).Delete(context.Background(), certificateName(cert), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// removeCertificates removes the cert-manager Certificates of an application, along with their secrets.
+func (d *KubernetesDriver) removeCertificates(app models.Application) error {
+	ctx := context.Background()
+	selector := metav1.ListOptions{LabelSelector: labelApplicationID + "=" + app.ID}
+	certificates := d.Dynamic.Resource(certificatesResource).Namespace(d.Namespace)
+
+	list, err := certificates.List(ctx, selector)
+	if err != nil {
+		// The cluster may not have cert-manager, in which case there is nothing to remove.
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, certificate := range list.Items {
+		if err := certificates.Delete(ctx, certificate.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		err := d.Client.CoreV1().Secrets(d.Namespace).Delete(ctx, certificate.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setIPs finds the public addresses of the ingress controller, which the custom domains must point to.
+// It is read from the status of its LoadBalancer Service, named by KUBERNETES_INGRESS_SERVICE ("namespace/name").
+func (d *KubernetesDriver) setIPs() error {
+	namespace, name, found := strings.Cut(os.Getenv("KUBERNETES_INGRESS_SERVICE"), "/")
+	if !found {
+		slog.Warn("KUBERNETES_INGRESS_SERVICE is not set, custom domains can't be verified")
+		return nil
+	}
+
+	service, err := d.Client.CoreV1().Services(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		ip := net.ParseIP(ingress.IP)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			if d.ipv4 == "" {
+				d.ipv4 = ip.String()
+			}
+		} else if d.ipv6 == "" {
+			d.ipv6 = ip.String()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+	"errors"
+)
+
+// errStorageUnsupported is returned by the storage-related methods, as the Kubernetes driver doesn't run MinIO.
+var errStorageUnsupported = errors.New("storage buckets aren't supported by the Kubernetes driver")
+
+func (d *KubernetesDriver) CreateStorageBucket(bucket models.StorageBucket) (host string, keyId string, secretKey string, region string, err error) {
+	return "", "", "", "", errStorageUnsupported
+}
+
+func (d *KubernetesDriver) GetFilesAndTotalSize(bucket models.StorageBucket) (float64, []models.StorageFile, error) {
+	return 0, nil, errStorageUnsupported
+}
+
+func (d *KubernetesDriver) DeleteStorageBucket(bucket models.StorageBucket) error {
+	return errStorageUnsupported
+}
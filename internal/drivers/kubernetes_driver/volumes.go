@@ -0,0 +1,148 @@
+package kubernetesDriver
+
+import (
+	"citadel/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// labelVolumeID is the label of the PersistentVolumeClaims backing the volumes.
+const labelVolumeID = "volume_id"
+
+// errVolumeSnapshotsUnsupported is returned by the snapshot-related methods, as the Kubernetes driver
+// doesn't have access to the platform's S3 bucket.
+var errVolumeSnapshotsUnsupported = errors.New("volume snapshots aren't supported by the Kubernetes driver")
+
+// volumeClaimName returns the name of the PersistentVolumeClaim backing a volume.
+func volumeClaimName(v models.Volume) string {
+	return "citadel-volume-" + v.ID
+}
+
+// podVolumes returns the volumes of the pods of an application, and their mounts in its containers.
+// The claims are ReadWriteOnce, so the pods using them are scheduled on the node they're attached to.
+func podVolumes(volumes []models.Volume) ([]corev1.Volume, []corev1.VolumeMount) {
+	var podVolumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for _, v := range volumes {
+		name := "volume-" + v.ID
+		podVolumes = append(podVolumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: volumeClaimName(v)},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: v.MountPath})
+	}
+	return podVolumes, mounts
+}
+
+// CreateVolume creates the PersistentVolumeClaim backing a volume, of its size.
+func (d *KubernetesDriver) CreateVolume(v models.Volume) error {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   volumeClaimName(v),
+			Labels: map[string]string{labelVolumeID: v.ID},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(strconv.Itoa(v.Size) + "Gi"),
+				},
+			},
+		},
+	}
+
+	_, err := d.Client.CoreV1().PersistentVolumeClaims(d.Namespace).Create(context.Background(), claim, metav1.CreateOptions{})
+	return err
+}
+
+// DeleteVolume removes the PersistentVolumeClaim backing a volume. Kubernetes only deletes it
+// once no pod uses it anymore.
+func (d *KubernetesDriver) DeleteVolume(v models.Volume) error {
+	err := d.Client.CoreV1().PersistentVolumeClaims(d.Namespace).Delete(context.Background(), volumeClaimName(v), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// statsSummary is the part of the summary of the kubelet stats holding the usage of the volumes of the pods.
+type statsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			UsedBytes *int64 `json:"usedBytes"`
+			PVCRef    *struct {
+				Name string `json:"name"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// GetVolumeUsage returns the disk space used by a volume, in bytes, as reported by the kubelet of a node
+// running a pod that mounts it. It is 0 when no pod mounts it.
+func (d *KubernetesDriver) GetVolumeUsage(v models.Volume) (int64, error) {
+	ctx := context.Background()
+	claimName := volumeClaimName(v)
+
+	pods, err := d.Client.CoreV1().Pods(d.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || !mountsClaim(pod, claimName) {
+			continue
+		}
+
+		raw, err := d.Client.CoreV1().RESTClient().Get().
+			AbsPath("/api/v1/nodes", pod.Spec.NodeName, "proxy/stats/summary").
+			DoRaw(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		var summary statsSummary
+		if err := json.Unmarshal(raw, &summary); err != nil {
+			return 0, err
+		}
+		for _, p := range summary.Pods {
+			for _, vol := range p.Volumes {
+				if vol.PVCRef != nil && vol.PVCRef.Name == claimName && vol.UsedBytes != nil {
+					return *vol.UsedBytes, nil
+				}
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// mountsClaim tells whether a pod mounts a PersistentVolumeClaim.
+func mountsClaim(pod corev1.Pod, claimName string) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == claimName {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *KubernetesDriver) SnapshotVolume(v models.Volume, snapshot models.VolumeSnapshot) (int64, error) {
+	return 0, errVolumeSnapshotsUnsupported
+}
+
+func (d *KubernetesDriver) RestoreVolume(v models.Volume, snapshot models.VolumeSnapshot) error {
+	return errVolumeSnapshotsUnsupported
+}
+
+func (d *KubernetesDriver) DeleteVolumeSnapshot(v models.Volume, snapshot models.VolumeSnapshot) error {
+	return nil
+}
@@ -0,0 +1,113 @@
+package drivers
+
+import (
+	"citadel/internal/models"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	caesar "github.com/caesar-rocks/core"
+)
+
+const (
+	// LogBatchSize is the number of log entries stored at once.
+	LogBatchSize = 500
+
+	// logFlushInterval is how long log entries may wait before being stored.
+	logFlushInterval = time.Second
+
+	// MaxLogLineSize is the number of bytes of a line of logs that are kept.
+	MaxLogLineSize = 16 * 1024
+)
+
+// LogsScope tells which containers of an application the logs are streamed from.
+type LogsScope string
+
+const (
+	// LogsScopeApp streams the logs of the application's instances.
+	LogsScopeApp LogsScope = "app"
+
+	// LogsScopeBuilder streams the output of the build of a deployment.
+	LogsScopeBuilder LogsScope = "builder"
+)
+
+// StreamLogsOptions describe the logs to stream.
+type StreamLogsOptions struct {
+	Scope LogsScope
+
+	// Deployment is the deployment whose logs are streamed. The logs of the builder scope require one,
+	// and the ones of the app scope default to the running deployment.
+	// The logs of the deployments that are no longer running are replayed from the stored logs.
+	Deployment *models.Deployment
+
+	// PlainText sends the message of each line as it is, rather than as a JSON models.LogEvent.
+	PlainText bool
+}
+
+// The outcomes of builds, sent as the data of the "end" event of their logs (see Driver.StreamLogs).
+const (
+	BuildOutcomeSucceeded = "succeeded"
+	BuildOutcomeFailed    = "failed"
+	BuildOutcomeCanceled  = "canceled"
+
+	// BuildOutcomeSuperseded is the outcome of the deployments that never got built,
+	// as a newer deployment of their application was queued after them.
+	BuildOutcomeSuperseded = "superseded"
+)
+
+// SendLogEvent sends a line of logs as a JSON models.LogEvent, or its message alone if plain text is requested.
+func SendLogEvent(ctx *caesar.Context, event models.LogEvent, opts StreamLogsOptions) error {
+	if opts.PlainText {
+		return ctx.SendSSE("log", event.Message)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return ctx.SendSSE("log", string(data))
+}
+
+// SendHealthEvent sends the outcome of a health check as a JSON models.HealthCheckEvent.
+func SendHealthEvent(ctx *caesar.Context, event models.HealthCheckEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return ctx.SendSSE("health", string(data))
+}
+
+// SendEndEvent tells the client that the logs it streams won't go on, along with the outcome of the build, if any.
+func SendEndEvent(ctx *caesar.Context, outcome string) error {
+	return ctx.SendSSE("end", outcome)
+}
+
+// StoreLogEntries stores the log entries collected by a driver by batches, forever.
+func (t *DeploymentTracker) StoreLogEntries(entries <-chan models.LogEntry) {
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.LogEntry, 0, LogBatchSize)
+	store := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.LogsRepo.CreateMany(context.Background(), batch); err != nil {
+			slog.Error("Failed to store log entries", "error", err, "count", len(batch))
+		}
+		batch = make([]models.LogEntry, 0, LogBatchSize)
+	}
+
+	for {
+		select {
+		case entry := <-entries:
+			batch = append(batch, entry)
+			if len(batch) >= LogBatchSize {
+				store()
+			}
+		case <-ticker.C:
+			store()
+		}
+	}
+}
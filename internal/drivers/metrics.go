@@ -0,0 +1,18 @@
+package drivers
+
+import (
+	"citadel/internal/models"
+	"time"
+)
+
+// MetricsQuery selects the resource usage of an application or a database over a period of time.
+type MetricsQuery struct {
+	ResourceType models.MetricResourceType
+	ResourceID   string
+
+	// Process restricts the samples of an application to one of its process types. All of them are summed up otherwise.
+	Process string
+
+	Since time.Time
+	Until time.Time
+}
@@ -1,7 +1,9 @@
 package ravelDriver
 
 import (
+	"citadel/internal/drivers"
 	"citadel/internal/models"
+	"context"
 
 	caesar "github.com/caesar-rocks/core"
 )
@@ -39,6 +41,11 @@ func (r *Ravel) CheckDnsConfig(app models.Application, cert models.Certificate)
 	return false, nil
 }
 
+// ActivateCertificate does nothing and returns nil
+func (r *Ravel) ActivateCertificate(app models.Application, cert models.Certificate) error {
+	return nil
+}
+
 // DeleteCertificate does nothing and returns nil
 func (r *Ravel) DeleteCertificate(app models.Application, cert models.Certificate) error {
 	return nil
@@ -54,11 +61,41 @@ func (r *Ravel) IgniteApplication(app models.Application, depl models.Deployment
 	return nil
 }
 
+// ScaleApplication does nothing and returns nil
+func (r *Ravel) ScaleApplication(app models.Application) error {
+	return nil
+}
+
+// ScaleProcessType does nothing and returns nil
+func (r *Ravel) ScaleProcessType(app models.Application, process models.ProcessType) error {
+	return nil
+}
+
+// CancelDeployment does nothing and returns nil
+func (r *Ravel) CancelDeployment(app models.Application, depl models.Deployment) error {
+	return nil
+}
+
 // StreamLogs does nothing and returns nil
-func (r *Ravel) StreamLogs(ctx *caesar.Context, app models.Application) error {
+func (r *Ravel) StreamLogs(ctx *caesar.Context, app models.Application, opts drivers.StreamLogsOptions) error {
 	return nil
 }
 
+// Exec does nothing and returns drivers.ErrNoRunningInstance
+func (r *Ravel) Exec(ctx context.Context, app models.Application, opts drivers.ExecOptions) (int, error) {
+	return 0, drivers.ErrNoRunningInstance
+}
+
+// RunJob does nothing and returns drivers.ErrNoRunningInstance
+func (r *Ravel) RunJob(app models.Application, depl models.Deployment, opts drivers.JobOptions) (drivers.JobResult, error) {
+	return drivers.JobResult{}, drivers.ErrNoRunningInstance
+}
+
+// Metrics does nothing and returns no samples and nil
+func (r *Ravel) Metrics(ctx context.Context, query drivers.MetricsQuery) ([]models.MetricSample, error) {
+	return []models.MetricSample{}, nil
+}
+
 // CreateDatabase does nothing and returns nil
 func (r *Ravel) CreateDatabase(db models.Database) error {
 	return nil
@@ -83,3 +120,33 @@ func (r *Ravel) GetFilesAndTotalSize(bucket models.StorageBucket) (totalSize flo
 func (r *Ravel) DeleteStorageBucket(bucket models.StorageBucket) error {
 	return nil
 }
+
+// CreateVolume does nothing and returns nil
+func (r *Ravel) CreateVolume(volume models.Volume) error {
+	return nil
+}
+
+// DeleteVolume does nothing and returns nil
+func (r *Ravel) DeleteVolume(volume models.Volume) error {
+	return nil
+}
+
+// GetVolumeUsage does nothing and returns 0 and nil
+func (r *Ravel) GetVolumeUsage(volume models.Volume) (int64, error) {
+	return 0, nil
+}
+
+// SnapshotVolume does nothing and returns 0 and nil
+func (r *Ravel) SnapshotVolume(volume models.Volume, snapshot models.VolumeSnapshot) (int64, error) {
+	return 0, nil
+}
+
+// RestoreVolume does nothing and returns nil
+func (r *Ravel) RestoreVolume(volume models.Volume, snapshot models.VolumeSnapshot) error {
+	return nil
+}
+
+// DeleteVolumeSnapshot does nothing and returns nil
+func (r *Ravel) DeleteVolumeSnapshot(volume models.Volume, snapshot models.VolumeSnapshot) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+package drivers
+
+import (
+	"citadel/internal/models"
+	"os"
+)
+
+// ImageReference returns the registry reference of the image run by a deployment.
+// Deployments built before images were tagged per deployment fall back to the latest image.
+func ImageReference(app models.Application, depl models.Deployment) string {
+	ref := os.Getenv("REGISTRY_HOST") + "/" + app.ID
+	if depl.ImageTag != "" {
+		ref += ":" + depl.ImageTag
+	}
+	return ref
+}
+
+// ProcessCommand returns the command of the containers of a process type, run by a shell like in a Procfile.
+// An empty command keeps the default one of the image.
+func ProcessCommand(command string) []string {
+	if command == "" {
+		return nil
+	}
+	return []string{"/bin/sh", "-c", command}
+}
@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"citadel/internal/models"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAppsAreOnlyReachableByTheMembersOfTheirOrganization(t *testing.T) {
+	h := New(t)
+
+	owner := h.SignUp("owner@citadel.test", "Owner", "password")
+	ownerOrgID := h.OrganizationOf(owner)
+	app := h.CreateApp(ownerOrgID, "webapp")
+	deplID := h.Deploy(ownerOrgID, app.Slug, Tarball(t, map[string]string{"Dockerfile": "FROM scratch\n"}))
+	h.WaitForDeploymentStatus(deplID, models.DeploymentStatusSuccess)
+
+	appPath := "/orgs/" + ownerOrgID + "/apps/" + app.Slug
+	if res := h.PostJSON(appPath+"/exec", map[string]string{"command": "whoami"}); res.StatusCode != http.StatusOK {
+		t.Fatalf("the owner failed to run a command: %s", res.Status)
+	}
+
+	// Signing up again signs the other user in instead.
+	intruder := h.SignUp("intruder@citadel.test", "Intruder", "password")
+	if h.OrganizationOf(intruder) == ownerOrgID {
+		t.Fatal("expected the users to have organizations of their own")
+	}
+
+	for _, req := range []*http.Request{
+		h.NewRequest(http.MethodGet, appPath, nil),
+		h.NewRequest(http.MethodGet, appPath+"/deployments/list", nil),
+		h.NewRequest(http.MethodGet, appPath+"/logs", nil),
+	} {
+		req.Header.Set("Accept", "application/json")
+		if res := h.Do(req); res.StatusCode != http.StatusNotFound {
+			t.Errorf("%s %s: expected %d, got %s", req.Method, req.URL.Path, http.StatusNotFound, res.Status)
+		}
+	}
+	if res := h.PostJSON(appPath+"/exec", map[string]string{"command": "whoami"}); res.StatusCode != http.StatusNotFound {
+		t.Errorf("the intruder got %s running a command, expected %d", res.Status, http.StatusNotFound)
+	}
+}
+
+func TestDeletingAnAppDeletesItsPreviews(t *testing.T) {
+	h := New(t)
+	ctx := context.Background()
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	app := h.CreateApp(orgID, "webapp")
+
+	preview := &models.Application{
+		OrganizationID:      orgID,
+		Name:                "webapp-pr-1",
+		CpuConfig:           app.CpuConfig,
+		RamConfig:           app.RamConfig,
+		ParentApplicationID: app.ID,
+		PullRequestNumber:   1,
+	}
+	if err := h.AppsRepo.Create(ctx, preview); err != nil {
+		t.Fatalf("failed to create the preview: %v", err)
+	}
+	if err := h.Driver.CreateApplication(*preview); err != nil {
+		t.Fatalf("failed to create the preview: %v", err)
+	}
+
+	if res := h.Delete("/orgs/" + orgID + "/apps/" + app.Slug); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to delete the application: %s", res.Status)
+	}
+
+	count, err := h.DB.NewSelect().Model((*models.Application)(nil)).Where("organization_id = ?", orgID).Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the application and its preview to be deleted, %d left", count)
+	}
+	if _, ok := h.Driver.App(preview.ID); ok {
+		t.Error("the preview wasn't deleted by the driver")
+	}
+}
@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"citadel/internal/models"
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// certificates returns the certificates of an application, as stored.
+func (h *Harness) certificates(appID string) []models.Certificate {
+	h.t.Helper()
+
+	var certs []models.Certificate
+	err := h.DB.NewSelect().Model(&certs).Where("application_id = ?", appID).Order("created_at ASC").Scan(context.Background())
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return certs
+}
+
+func TestCertificatesAreActivatedOnceTheirDomainPointsToCitadel(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	app := h.CreateApp(orgID, "webapp")
+	certsPath := "/orgs/" + orgID + "/apps/" + app.Slug + "/certs"
+
+	// The domain doesn't point to Citadel yet.
+	h.Driver.SetDnsVerified(false)
+	if res := h.PostForm(certsPath, url.Values{"domain": {"example.com"}, "www": {"true"}}); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to add the domain: %s", res.Status)
+	}
+
+	certs := h.certificates(app.ID)
+	if len(certs) != 1 {
+		t.Fatalf("expected 1 certificate, got %d", len(certs))
+	}
+	cert := certs[0]
+	if cert.Status != models.CertificateStatusPending {
+		t.Errorf("the certificate is %q, expected %q", cert.Status, models.CertificateStatusPending)
+	}
+	if len(cert.DnsEntries) != 4 {
+		t.Errorf("expected A and AAAA entries for the domain and its www subdomain, got %d entries", len(cert.DnsEntries))
+	}
+	if state, _ := h.Driver.App(app.ID); slices.Contains(state.Certificates, cert.ID) {
+		t.Error("the certificate was activated before its domain pointed to Citadel")
+	}
+
+	h.Driver.SetDnsVerified(true)
+	if res := h.PostForm(certsPath+"/"+cert.ID+"/check", nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to check the domain: %s", res.Status)
+	}
+	if cert = h.certificates(app.ID)[0]; cert.Status != models.CertificateStatusVerified {
+		t.Errorf("the certificate is %q, expected %q", cert.Status, models.CertificateStatusVerified)
+	}
+	if state, _ := h.Driver.App(app.ID); !slices.Contains(state.Certificates, cert.ID) {
+		t.Error("the certificate wasn't activated once its domain pointed to Citadel")
+	}
+
+	if res := h.Delete(certsPath + "/" + cert.ID); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to delete the domain: %s", res.Status)
+	}
+	if certs := h.certificates(app.ID); len(certs) != 0 {
+		t.Errorf("expected the certificate to be deleted, got %d", len(certs))
+	}
+	if state, _ := h.Driver.App(app.ID); slices.Contains(state.Certificates, cert.ID) {
+		t.Error("the deleted certificate is still active")
+	}
+}
@@ -0,0 +1,66 @@
+package e2e
+
+import (
+	"citadel/internal/models"
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestDatabasesAreCreatedAndDeleted(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	dbsPath := "/orgs/" + orgID + "/databases"
+
+	res := h.PostForm(dbsPath, url.Values{
+		"name":     {"main"},
+		"dbms":     {"postgres"},
+		"username": {"citadel"},
+		"password": {"secret"},
+	})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create the database: %s", res.Status)
+	}
+
+	var db models.Database
+	if err := h.DB.NewSelect().Model(&db).Where("organization_id = ?", orgID).Scan(context.Background()); err != nil {
+		t.Fatalf("the database wasn't stored: %v", err)
+	}
+	if db.CpuConfig != "shared-cpu-1x" || db.RamConfig != "512MB" {
+		t.Errorf("the database got %s/%s, expected the default computing specs", db.CpuConfig, db.RamConfig)
+	}
+	if !h.Driver.HasDatabase(db.Slug) {
+		t.Fatal("the database wasn't created by the driver")
+	}
+
+	if res := h.Delete(dbsPath + "/" + db.Slug); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to delete the database: %s", res.Status)
+	}
+	if h.Driver.HasDatabase(db.Slug) {
+		t.Error("the database wasn't deleted by the driver")
+	}
+	count, err := h.DB.NewSelect().Model((*models.Database)(nil)).Where("organization_id = ?", orgID).Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the database to be deleted, %d left", count)
+	}
+}
+
+func TestDatabasesOfAnUnknownEngineAreRejected(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	h.PostForm("/orgs/"+orgID+"/databases", url.Values{"name": {"main"}, "dbms": {"oracle"}})
+
+	count, err := h.DB.NewSelect().Model((*models.Database)(nil)).Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected no database to be created, got %d", count)
+	}
+}
@@ -0,0 +1,115 @@
+package e2e
+
+import (
+	"citadel/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDeploymentsReplaceTheRunningOneAndCanBeRolledBack(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	app := h.CreateApp(orgID, "webapp")
+	tarball := Tarball(t, map[string]string{"Dockerfile": "FROM scratch\n"})
+
+	first := h.Deploy(orgID, app.Slug, tarball)
+	h.WaitForDeploymentStatus(first, models.DeploymentStatusSuccess)
+	second := h.Deploy(orgID, app.Slug, tarball)
+	h.WaitForDeploymentStatus(second, models.DeploymentStatusSuccess)
+
+	if state, _ := h.Driver.App(app.ID); state.RunningDeploymentID != second {
+		t.Fatalf("the application runs %q, expected the latest deployment %q", state.RunningDeploymentID, second)
+	}
+
+	res := h.PostJSON("/orgs/"+orgID+"/apps/"+app.Slug+"/deployments/"+first+"/rollback", nil)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to roll back: %s", res.Status)
+	}
+	var rollback models.Deployment
+	if err := json.NewDecoder(res.Body).Decode(&rollback); err != nil {
+		t.Fatal(err)
+	}
+
+	depl := h.WaitForDeploymentStatus(rollback.ID, models.DeploymentStatusSuccess)
+	if depl.ImageTag != first {
+		t.Errorf("the rollback runs the image %q, expected the one of %q", depl.ImageTag, first)
+	}
+	if state, _ := h.Driver.App(app.ID); state.RunningDeploymentID != rollback.ID {
+		t.Errorf("the application runs %q, expected the rollback %q", state.RunningDeploymentID, rollback.ID)
+	}
+}
+
+func TestCanceledDeploymentsLeaveThePreviousOneRunning(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	app := h.CreateApp(orgID, "webapp")
+	tarball := Tarball(t, map[string]string{"Dockerfile": "FROM scratch\n"})
+
+	running := h.Deploy(orgID, app.Slug, tarball)
+	h.WaitForDeploymentStatus(running, models.DeploymentStatusSuccess)
+
+	h.Driver.SetStepDuration(300 * time.Millisecond)
+	canceled := h.Deploy(orgID, app.Slug, tarball)
+	h.WaitForDeploymentStatus(canceled, models.DeploymentStatusBuilding)
+
+	res := h.PostJSON("/orgs/"+orgID+"/apps/"+app.Slug+"/deployments/"+canceled+"/cancel", nil)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to cancel: %s", res.Status)
+	}
+	h.WaitForDeploymentStatus(canceled, models.DeploymentStatusCanceled)
+
+	// The build carries on until the end of its phase, which must not overwrite the canceled status.
+	time.Sleep(time.Second)
+	h.WaitForDeploymentStatus(canceled, models.DeploymentStatusCanceled)
+
+	if state, _ := h.Driver.App(app.ID); state.RunningDeploymentID != running {
+		t.Errorf("the application runs %q, expected the previous deployment %q", state.RunningDeploymentID, running)
+	}
+	if _, err := h.Storage.Get(canceled); err == nil {
+		t.Error("the tarball of the canceled deployment is still stored")
+	}
+
+	res = h.PostJSON("/orgs/"+orgID+"/apps/"+app.Slug+"/deployments/"+canceled+"/cancel", nil)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("canceling the deployment again: expected %d, got %s", http.StatusConflict, res.Status)
+	}
+}
+
+func TestProcessTypesAreDeclaredOnceTheDeploymentSucceeds(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	app := h.CreateApp(orgID, "webapp")
+	tarball := Tarball(t, map[string]string{
+		"Dockerfile": "FROM scratch\n",
+		"Procfile":   "web: ./server\nworker: ./worker\n",
+	})
+
+	processTypes := func() []string {
+		var names []string
+		err := h.DB.NewSelect().Model((*models.ProcessType)(nil)).Column("name").Where("application_id = ?", app.ID).Scan(context.Background(), &names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+
+	h.Driver.SetFailure(models.DeploymentStatusDeploying, true)
+	failed := h.Deploy(orgID, app.Slug, tarball)
+	h.WaitForDeploymentStatus(failed, models.DeploymentStatusDeployFailed)
+	if names := processTypes(); len(names) != 0 {
+		t.Errorf("the failed deployment declared %v, expected no process type", names)
+	}
+
+	h.Driver.SetFailure(models.DeploymentStatusDeploying, false)
+	succeeded := h.Deploy(orgID, app.Slug, tarball)
+	h.WaitForDeploymentStatus(succeeded, models.DeploymentStatusSuccess)
+	if names := processTypes(); len(names) != 1 || names[0] != "worker" {
+		t.Errorf("the successful deployment declared %v, expected [worker]", names)
+	}
+}
@@ -0,0 +1,175 @@
+package e2e
+
+import (
+	"citadel/config"
+	"citadel/database"
+	"citadel/internal/drivers"
+	fakeDriver "citadel/internal/drivers/fake_driver"
+	"citadel/internal/repositories"
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/caesar-rocks/core"
+	"github.com/caesar-rocks/drive"
+	"github.com/caesar-rocks/orm"
+	"go.uber.org/fx"
+)
+
+// startTimeout is how long the application has to start.
+const startTimeout = 30 * time.Second
+
+// statusErrorHandler replaces the error handler of the application, whose error pages are sent with a 200 status,
+// so that the tests can tell the failed requests from their status code.
+var statusErrorHandler = &core.ErrorHandler{Handle: func(ctx *core.Context, err error) {
+	code := core.RetrieveErrorCode(err)
+	http.Error(ctx.ResponseWriter, http.StatusText(code), code)
+}}
+
+// Harness runs Citadel, wired like in production (see config.ProvideApp), behind an HTTP test server.
+// The applications are run by the fake driver, the data is stored in a SQLite database of its own,
+// Redis is stood in for by miniredis, the tarballs are kept in memory, and the mails are captured.
+//
+// The harness sets environment variables and replaces the transport of http.DefaultClient,
+// so the tests using it must not run in parallel.
+type Harness struct {
+	t testing.TB
+
+	// URL is the base URL of the test server.
+	URL string
+
+	// Client sends the requests to the test server. It keeps the session cookies, and follows the redirects.
+	Client *http.Client
+
+	Driver  *fakeDriver.FakeDriver
+	DB      *orm.Database
+	Redis   *miniredis.Miniredis
+	Storage *MemoryFileSystem
+
+	AppsRepo  *repositories.ApplicationsRepository
+	DeplsRepo *repositories.DeploymentsRepository
+	UsersRepo *repositories.UsersRepository
+
+	mails *mailbox
+}
+
+// New starts Citadel for the duration of a test. Every harness gets a brand new database.
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	h := &Harness{
+		t:       t,
+		Redis:   miniredis.RunT(t),
+		Storage: NewMemoryFileSystem(),
+		mails:   interceptMails(t),
+	}
+
+	server := httptest.NewUnstartedServer(nil)
+	h.URL = "http://" + server.Listener.Addr().String()
+
+	for key, value := range map[string]string{
+		"APP_KEY":                 "e2e-app-key-0123456789abcdefghij",
+		"ADDR":                    server.Listener.Addr().String(),
+		"APP_URL":                 h.URL,
+		"DBMS":                    "sqlite",
+		"DSN":                     filepath.Join(t.TempDir(), "citadel.sqlite"),
+		"S3_KEY":                  "e2e",
+		"S3_SECRET":               "e2e",
+		"S3_REGION":               "us-east-1",
+		"S3_ENDPOINT":             "http://s3.citadel.test",
+		"S3_BUCKET":               "e2e",
+		"RESEND_KEY":              "re_e2e",
+		"REDIS_ADDR":              h.Redis.Addr(),
+		"REDIS_PASSWORD":          "",
+		"BUILDER_IMAGE":           "builder",
+		"REGISTRY_HOST":           "registry.citadel.test",
+		"WILDCARD_TRAEFIK_DOMAIN": "citadel.test",
+		"DRIVER":                  string(config.FakeDriver),
+
+		// The features relying on third-party services are turned off (see config.ProvideVexillum).
+		"GITHUB_OAUTH_KEY":  "",
+		"GITHUB_APP_ID":     "",
+		"STRIPE_SECRET_KEY": "",
+		"STRIPE_PUBLIC_KEY": "",
+		"DEBUG_DATABASE":    "",
+	} {
+		t.Setenv(key, value)
+	}
+
+	env := config.ProvideEnvironmentVariables()
+	app := config.ProvideApp(env)
+
+	var (
+		mux    *http.ServeMux
+		driver drivers.Driver
+	)
+	fxApp := fx.New(
+		fx.NopLogger,
+		fx.Provide(core.NewHTTPMux),
+		fx.Provide(app.Providers...),
+		fx.Replace(drive.NewDrive(map[string]drive.FileSystem{"s3": h.Storage})),
+		fx.Replace(statusErrorHandler),
+		fx.Invoke(func(db *orm.Database) {
+			db.Migrate(database.GetMigrations())
+		}),
+		// The routes are registered on the mux before the invokers add theirs (e.g. the static assets).
+		fx.Populate(&mux, &driver, &h.DB, &h.AppsRepo, &h.DeplsRepo, &h.UsersRepo),
+		fx.Invoke(app.Invokers...),
+	)
+	if err := fxApp.Err(); err != nil {
+		t.Fatalf("failed to wire the application: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	if err := fxApp.Start(ctx); err != nil {
+		t.Fatalf("failed to start the application: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+		defer cancel()
+		fxApp.Stop(ctx)
+	})
+
+	fake, ok := driver.(*fakeDriver.FakeDriver)
+	if !ok {
+		t.Fatalf("expected the fake driver, got %T", driver)
+	}
+	h.Driver = fake
+
+	server.Config.Handler = mux
+	server.Start()
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Client = &http.Client{Jar: plainHTTPJar{jar}, Timeout: 30 * time.Second}
+
+	return h
+}
+
+// plainHTTPJar keeps the cookies marked as secure, like the session one, for the plain HTTP test server.
+// The cookies that aren't being deleted are kept until the end of the test, as the Max-Age of the session one
+// is so large that the standard jar considers it expired.
+type plainHTTPJar struct {
+	http.CookieJar
+}
+
+func (j plainHTTPJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		cookie.Secure = false
+		if cookie.MaxAge > 0 {
+			cookie.MaxAge = 0
+			cookie.Expires = time.Time{}
+		}
+	}
+	j.CookieJar.SetCookies(u, cookies)
+}
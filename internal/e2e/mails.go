@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resendHost is the host of the Resend API, which the mailer sends the mails through (see config.ProvideMailer).
+const resendHost = "api.resend.com"
+
+// Mail is a mail sent by Citadel.
+type Mail struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Html    string   `json:"html"`
+	Text    string   `json:"text"`
+}
+
+// mailbox captures the requests sent to the Resend API. The other requests go through.
+type mailbox struct {
+	mu    sync.Mutex
+	mails []Mail
+	next  http.RoundTripper
+}
+
+// interceptMails has the requests made with http.DefaultClient, which the Resend client uses,
+// go through a mailbox until the end of the test.
+func interceptMails(t testing.TB) *mailbox {
+	previous := http.DefaultClient.Transport
+	next := previous
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	box := &mailbox{next: next}
+	http.DefaultClient.Transport = box
+	t.Cleanup(func() {
+		http.DefaultClient.Transport = previous
+	})
+
+	return box
+}
+
+func (box *mailbox) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != resendHost {
+		return box.next.RoundTrip(req)
+	}
+
+	var mail Mail
+	if req.Body != nil {
+		defer req.Body.Close()
+		if err := json.NewDecoder(req.Body).Decode(&mail); err != nil {
+			return nil, err
+		}
+	}
+
+	box.mu.Lock()
+	box.mails = append(box.mails, mail)
+	box.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"e2e"}`)),
+		Request:    req,
+	}, nil
+}
+
+// Mails returns the mails sent so far, oldest first.
+func (h *Harness) Mails() []Mail {
+	h.mails.mu.Lock()
+	defer h.mails.mu.Unlock()
+	return append([]Mail{}, h.mails.mails...)
+}
@@ -0,0 +1,60 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	caesarAuth "github.com/caesar-rocks/auth"
+)
+
+func TestForgottenPasswordsAreResetThroughTheLinkSentByMail(t *testing.T) {
+	h := New(t)
+
+	user := h.SignUp("owner@citadel.test", "Owner", "password")
+	sent := len(h.Mails())
+
+	if res := h.PostForm("/auth/forgot_password", url.Values{"email": {user.Email}}); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to ask for a new password: %s", res.Status)
+	}
+
+	mails := h.Mails()[sent:]
+	if len(mails) != 1 {
+		t.Fatalf("expected 1 mail, got %d", len(mails))
+	}
+	mail := mails[0]
+	if len(mail.To) != 1 || mail.To[0] != user.Email {
+		t.Errorf("the mail was sent to %v, expected %s", mail.To, user.Email)
+	}
+
+	link := regexp.MustCompile(regexp.QuoteMeta(h.URL) + `(/auth/reset_password/[\w.-]+)`).FindStringSubmatch(mail.Html)
+	if link == nil {
+		t.Fatalf("the mail has no link to reset the password:\n%s", mail.Html)
+	}
+
+	res := h.PostForm(link[1], url.Values{"password": {"new-password"}, "confirm_password": {"new-password"}})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to reset the password: %s", res.Status)
+	}
+
+	updated, err := h.UsersRepo.FindOneBy(context.Background(), "id", user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caesarAuth.CheckPasswordHash("new-password", updated.Password) {
+		t.Error("the password wasn't reset")
+	}
+}
+
+func TestNoMailIsSentForUnknownAddresses(t *testing.T) {
+	h := New(t)
+
+	if res := h.PostForm("/auth/forgot_password", url.Values{"email": {"nobody@citadel.test"}}); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to ask for a new password: %s", res.Status)
+	}
+	if mails := h.Mails(); len(mails) != 0 {
+		t.Errorf("expected no mail, got %d", len(mails))
+	}
+}
@@ -0,0 +1,204 @@
+package e2e
+
+import (
+	"bytes"
+	"citadel/internal/models"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// waitTimeout is how long the deployments have to reach the status waited for.
+const waitTimeout = 10 * time.Second
+
+// Get sends a GET request to a path of the test server.
+func (h *Harness) Get(path string) *http.Response {
+	return h.Do(h.NewRequest(http.MethodGet, path, nil))
+}
+
+// Delete sends a DELETE request to a path of the test server, like the delete buttons of the dashboard.
+func (h *Harness) Delete(path string) *http.Response {
+	return h.Do(h.NewRequest(http.MethodDelete, path, nil))
+}
+
+// PostForm sends a URL-encoded form to a path of the test server, like the forms of the dashboard.
+func (h *Harness) PostForm(path string, values url.Values) *http.Response {
+	req := h.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return h.Do(req)
+}
+
+// PostJSON sends a JSON body to a path of the test server, asking for a JSON response, like the CLI.
+func (h *Harness) PostJSON(path string, body any) *http.Response {
+	data, err := json.Marshal(body)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	req := h.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	return h.Do(req)
+}
+
+// PostMultipart sends a multipart form, with the given fields and files, to a path of the test server.
+func (h *Harness) PostMultipart(path string, fields map[string]string, files map[string][]byte) *http.Response {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			h.t.Fatal(err)
+		}
+	}
+	for name, data := range files {
+		part, err := writer.CreateFormFile(name, name)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		if _, err := part.Write(data); err != nil {
+			h.t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		h.t.Fatal(err)
+	}
+
+	req := h.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	return h.Do(req)
+}
+
+// NewRequest creates a request to a path of the test server.
+func (h *Harness) NewRequest(method string, path string, body io.Reader) *http.Request {
+	req, err := http.NewRequest(method, h.URL+path, body)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return req
+}
+
+// Do sends a request with the client of the harness. The body of the response is closed at the end of the test.
+func (h *Harness) Do(req *http.Request) *http.Response {
+	h.t.Helper()
+
+	res, err := h.Client.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	h.t.Cleanup(func() {
+		res.Body.Close()
+	})
+	return res
+}
+
+// ReadBody reads the body of a response.
+func (h *Harness) ReadBody(res *http.Response) string {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return string(data)
+}
+
+// SignUp creates a user through the sign up form, and keeps them signed in.
+func (h *Harness) SignUp(email string, fullName string, password string) *models.User {
+	h.t.Helper()
+
+	res := h.PostForm("/auth/sign_up", url.Values{
+		"email":     {email},
+		"full_name": {fullName},
+		"password":  {password},
+	})
+	if res.StatusCode != http.StatusOK {
+		h.t.Fatalf("failed to sign up: %s", res.Status)
+	}
+
+	user, err := h.UsersRepo.FindOneBy(context.Background(), "email", email)
+	if err != nil || user == nil {
+		h.t.Fatalf("failed to sign up: %v", err)
+	}
+	return user
+}
+
+// OrganizationOf returns the ID of the organization created along with a user when they signed up.
+func (h *Harness) OrganizationOf(user *models.User) string {
+	h.t.Helper()
+
+	var orgID string
+	err := h.DB.NewSelect().
+		Model((*models.OrganizationMember)(nil)).
+		Column("organization_id").
+		Where("user_id = ?", user.ID).
+		Limit(1).
+		Scan(context.Background(), &orgID)
+	if err != nil {
+		h.t.Fatalf("failed to find the organization of %s: %v", user.Email, err)
+	}
+	return orgID
+}
+
+// CreateApp creates an application through the dashboard form, with the smallest computing specs, and returns it.
+func (h *Harness) CreateApp(orgID string, name string) *models.Application {
+	h.t.Helper()
+
+	res := h.PostForm("/orgs/"+orgID+"/apps", url.Values{
+		"name":       {name},
+		"cpu_config": {"shared-cpu-1x"},
+		"ram_config": {"256MB"},
+	})
+	if res.StatusCode != http.StatusOK {
+		h.t.Fatalf("failed to create the application: %s", res.Status)
+	}
+
+	app, err := h.AppsRepo.FindOneBy(context.Background(), "organization_id", orgID, "name", name)
+	if err != nil || app == nil {
+		h.t.Fatalf("failed to create the application: %v", err)
+	}
+	return app
+}
+
+// Deploy uploads a tarball to an application, like "citadel deploy", and returns the ID of the queued deployment.
+func (h *Harness) Deploy(orgID string, appSlug string, tarball []byte) string {
+	h.t.Helper()
+
+	res := h.PostMultipart("/orgs/"+orgID+"/apps/"+appSlug+"/deployments", nil, map[string][]byte{"tarball": tarball})
+	if res.StatusCode != http.StatusOK {
+		h.t.Fatalf("failed to deploy: %s", res.Status)
+	}
+
+	var body struct {
+		DeploymentID string `json:"deployment_id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		h.t.Fatal(err)
+	}
+	return body.DeploymentID
+}
+
+// WaitForDeploymentStatus waits for a deployment to reach a status, be it set by the queue or by the driver,
+// and returns it as it is by then.
+func (h *Harness) WaitForDeploymentStatus(deplID string, status models.DeploymentStatus) *models.Deployment {
+	h.t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		depl, err := h.DeplsRepo.FindOneBy(context.Background(), "id", deplID)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		if depl.Status == status {
+			return depl
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("deployment %s is %s, expected %s", deplID, depl.Status, status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,46 @@
+package e2e
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrFileNotFound is returned when getting a file that isn't stored.
+var ErrFileNotFound = errors.New("file not found")
+
+// MemoryFileSystem stands in for the S3 bucket the tarballs of the deployments are uploaded to.
+type MemoryFileSystem struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func NewMemoryFileSystem() *MemoryFileSystem {
+	return &MemoryFileSystem{files: map[string][]byte{}}
+}
+
+func (fs *MemoryFileSystem) Put(key string, data []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.files[key] = append([]byte{}, data...)
+	return nil
+}
+
+func (fs *MemoryFileSystem) Get(key string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, ok := fs.files[key]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (fs *MemoryFileSystem) Delete(key string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	delete(fs.files, key)
+	return nil
+}
@@ -0,0 +1,50 @@
+package e2e
+
+import (
+	"citadel/internal/models"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestStorageBucketsListTheirFilesAndAreDeleted(t *testing.T) {
+	h := New(t)
+
+	orgID := h.OrganizationOf(h.SignUp("owner@citadel.test", "Owner", "password"))
+	storagePath := "/orgs/" + orgID + "/storage"
+
+	if res := h.PostForm(storagePath, url.Values{"name": {"assets"}}); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create the bucket: %s", res.Status)
+	}
+
+	var bucket models.StorageBucket
+	if err := h.DB.NewSelect().Model(&bucket).Where("organization_id = ?", orgID).Scan(context.Background()); err != nil {
+		t.Fatalf("the bucket wasn't stored: %v", err)
+	}
+	if bucket.KeyId == "" || bucket.SecretKey == "" || bucket.Host == "" {
+		t.Errorf("the bucket wasn't given its credentials: %+v", bucket)
+	}
+
+	if err := h.Driver.PutFile(bucket.Slug, "logo.png", 1024); err != nil {
+		t.Fatal(err)
+	}
+	res := h.Get(storagePath + "/" + bucket.Slug)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to show the bucket: %s", res.Status)
+	}
+	if body := h.ReadBody(res); !strings.Contains(body, "logo.png") {
+		t.Error("the files of the bucket aren't listed")
+	}
+
+	if res := h.Delete(storagePath + "/" + bucket.Slug); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to delete the bucket: %s", res.Status)
+	}
+	if _, _, err := h.Driver.GetFilesAndTotalSize(bucket); err == nil {
+		t.Error("the bucket wasn't deleted by the driver")
+	}
+	if res := h.Get(storagePath + "/" + bucket.Slug); res.StatusCode == http.StatusOK {
+		t.Errorf("the deleted bucket is still shown")
+	}
+}
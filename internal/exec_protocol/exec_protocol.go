@@ -0,0 +1,104 @@
+// Package execProtocol defines the WebSocket protocol used to run commands in the instances of an application.
+//
+// Binary frames carry the standard input of the command (from the client) and its output (from the server).
+// Text frames carry JSON control messages: the client reports its terminal size and the end of its input,
+// and the server reports the exit code of the command before closing the connection.
+package execProtocol
+
+import (
+	"encoding/json"
+	"errors"
+
+	"golang.org/x/net/websocket"
+)
+
+type ControlMessageType string
+
+const (
+	// ControlMessageResize is sent by the client when its terminal is resized.
+	ControlMessageResize ControlMessageType = "resize"
+
+	// ControlMessageStdinClosed is sent by the client once its standard input is exhausted.
+	ControlMessageStdinClosed ControlMessageType = "stdin_closed"
+
+	// ControlMessageExit is sent by the server once the command exited.
+	ControlMessageExit ControlMessageType = "exit"
+)
+
+// ControlMessage is a control message, sent as a text frame.
+type ControlMessage struct {
+	Type ControlMessageType `json:"type"`
+
+	// Cols and Rows are the terminal size, for resize messages.
+	Cols uint `json:"cols,omitempty"`
+	Rows uint `json:"rows,omitempty"`
+
+	// ExitCode and Error describe how the command ended, for exit messages.
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Frame is a frame received over the connection: either data, or a control message.
+type Frame struct {
+	Data    []byte
+	Control *ControlMessage
+}
+
+var codec = websocket.Codec{
+	Marshal: func(v any) ([]byte, byte, error) {
+		switch v := v.(type) {
+		case []byte:
+			return v, websocket.BinaryFrame, nil
+		case ControlMessage:
+			data, err := json.Marshal(v)
+			return data, websocket.TextFrame, err
+		default:
+			return nil, websocket.UnknownFrame, websocket.ErrNotSupported
+		}
+	},
+	Unmarshal: func(data []byte, payloadType byte, v any) error {
+		frame, ok := v.(*Frame)
+		if !ok {
+			return websocket.ErrNotSupported
+		}
+
+		switch payloadType {
+		case websocket.BinaryFrame:
+			frame.Data = data
+			return nil
+		case websocket.TextFrame:
+			frame.Control = &ControlMessage{}
+			return json.Unmarshal(data, frame.Control)
+		default:
+			return errors.New("unexpected frame type")
+		}
+	},
+}
+
+// Receive reads the next frame from the connection.
+func Receive(ws *websocket.Conn) (Frame, error) {
+	var frame Frame
+	err := codec.Receive(ws, &frame)
+	return frame, err
+}
+
+// SendControl sends a control message over the connection.
+func SendControl(ws *websocket.Conn, msg ControlMessage) error {
+	return codec.Send(ws, msg)
+}
+
+// Writer sends the data written to it over a connection, as binary frames.
+type Writer struct {
+	ws *websocket.Conn
+}
+
+func NewWriter(ws *websocket.Conn) *Writer {
+	return &Writer{ws}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if err := codec.Send(w.ws, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,98 @@
+package githubApp
+
+import (
+	"citadel/internal/models"
+	"context"
+	"os"
+
+	"github.com/google/go-github/v62/github"
+)
+
+const checkRunName = "Software Citadel"
+
+// CreateCheckRun creates a check run on the commit the deployment was triggered by,
+// and returns its ID.
+func CreateCheckRun(ctx context.Context, app models.Application, depl models.Deployment) (int64, error) {
+	client, err := NewInstallationClient(app.GitHubInstallationID)
+	if err != nil {
+		return 0, err
+	}
+
+	owner, repo, err := SplitRepository(app.GitHubRepository)
+	if err != nil {
+		return 0, err
+	}
+
+	status, _ := checkRunStatusAndConclusion(depl.Status)
+	checkRun, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+		Name:       checkRunName,
+		HeadSHA:    depl.CommitSHA,
+		DetailsURL: github.String(deploymentURL(app, depl)),
+		ExternalID: github.String(depl.ID),
+		Status:     github.String(status),
+		StartedAt:  &github.Timestamp{Time: depl.CreatedAt},
+		Output:     checkRunOutput(app, depl),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return checkRun.GetID(), nil
+}
+
+// UpdateCheckRun reflects the current status of the deployment on its check run.
+func UpdateCheckRun(ctx context.Context, app models.Application, depl models.Deployment) error {
+	client, err := NewInstallationClient(app.GitHubInstallationID)
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := SplitRepository(app.GitHubRepository)
+	if err != nil {
+		return err
+	}
+
+	status, conclusion := checkRunStatusAndConclusion(depl.Status)
+	opts := github.UpdateCheckRunOptions{
+		Name:       checkRunName,
+		DetailsURL: github.String(deploymentURL(app, depl)),
+		Status:     github.String(status),
+		Output:     checkRunOutput(app, depl),
+	}
+	if conclusion != "" {
+		opts.Conclusion = github.String(conclusion)
+		opts.CompletedAt = &github.Timestamp{Time: depl.UpdatedAt}
+	}
+
+	_, _, err = client.Checks.UpdateCheckRun(ctx, owner, repo, depl.GitHubCheckRunID, opts)
+	return err
+}
+
+func checkRunStatusAndConclusion(status models.DeploymentStatus) (string, string) {
+	switch status {
+	case models.DeploymentStatusSuccess:
+		return "completed", "success"
+	case models.DeploymentStatusBuildFailed, models.DeploymentStatusReleaseFailed, models.DeploymentStatusDeployFailed:
+		return "completed", "failure"
+	case models.DeploymentStatusCanceled:
+		return "completed", "cancelled"
+	case models.DeploymentStatusSuperseded:
+		return "completed", "skipped"
+	case models.DeploymentStatusQueued:
+		return "queued", ""
+	default:
+		return "in_progress", ""
+	}
+}
+
+func checkRunOutput(app models.Application, depl models.Deployment) *github.CheckRunOutput {
+	return &github.CheckRunOutput{
+		Title:   github.String(depl.Status.String()),
+		Summary: github.String("Deployment `" + depl.ID + "` of " + app.Name + ": " + depl.Status.String() + "."),
+	}
+}
+
+// deploymentURL returns the URL of the page of the deployment.
+func deploymentURL(app models.Application, depl models.Deployment) string {
+	return os.Getenv("APP_URL") + "/orgs/" + app.OrganizationID + "/apps/" + app.Slug + "/deployments/" + depl.ID
+}
@@ -0,0 +1,129 @@
+package githubApp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bradleyfalzon/ghinstallation"
+	"github.com/google/go-github/v62/github"
+)
+
+// NewInstallationClient creates a GitHub client authenticated as the given installation of the GitHub App.
+func NewInstallationClient(installationID int64) (*github.Client, error) {
+	githubAppId, err := strconv.ParseInt(os.Getenv("GITHUB_APP_ID"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	itr, err := ghinstallation.NewKeyFromFile(http.DefaultTransport, githubAppId, installationID, os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"))
+	if err != nil {
+		return nil, err
+	}
+
+	return github.NewClient(&http.Client{Transport: itr}), nil
+}
+
+// SplitRepository splits a full repository name ("owner/repo") into its owner and name.
+func SplitRepository(fullName string) (owner string, repo string, err error) {
+	owner, repo, ok := strings.Cut(fullName, "/")
+	if !ok || owner == "" || repo == "" {
+		return "", "", fmt.Errorf("invalid repository name: %s", fullName)
+	}
+	return owner, repo, nil
+}
+
+// DownloadTarball downloads the source code of a repository at the given ref, as a gzipped tarball.
+// The files are moved to the root of the archive, so that it has the same layout as the ones uploaded by the CLI.
+func DownloadTarball(ctx context.Context, client *github.Client, fullName string, ref string) ([]byte, error) {
+	owner, repo, err := SplitRepository(fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveURL, _, err := client.Repositories.GetArchiveLink(
+		ctx, owner, repo, github.Tarball,
+		&github.RepositoryContentGetOptions{Ref: ref},
+		3,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", archiveURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download tarball: HTTP status code %d", resp.StatusCode)
+	}
+
+	return stripRootDirectory(resp.Body)
+}
+
+// stripRootDirectory repackages a gzipped tarball, removing the top-level directory
+// GitHub wraps the repository files in (e.g. "owner-repo-sha/").
+func stripRootDirectory(r io.Reader) ([]byte, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	buf := bytes.NewBuffer(nil)
+	gzw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gzw)
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip the pax global header holding the commit SHA.
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		_, name, ok := strings.Cut(header.Name, "/")
+		if !ok || name == "" {
+			continue
+		}
+		header.Name = name
+
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,34 @@
+package githubApp
+
+import (
+	"citadel/internal/models"
+	"context"
+	"os"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// PreviewURL returns the URL a preview application is served at, on its own subdomain.
+func PreviewURL(preview models.Application) string {
+	return "https://" + preview.Slug + "." + os.Getenv("WILDCARD_TRAEFIK_DOMAIN")
+}
+
+// CommentPreviewURL comments on the pull request a preview application deploys with the URL it is served at.
+func CommentPreviewURL(ctx context.Context, preview models.Application) error {
+	client, err := NewInstallationClient(preview.GitHubInstallationID)
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := SplitRepository(preview.GitHubRepository)
+	if err != nil {
+		return err
+	}
+
+	body := "This pull request is deployed by " + checkRunName + " at " + PreviewURL(preview) + ".\n\n" +
+		"The preview is updated on every push, and destroyed when the pull request is closed."
+	_, _, err = client.Issues.CreateComment(ctx, owner, repo, preview.PullRequestNumber, &github.IssueComment{
+		Body: github.String(body),
+	})
+	return err
+}
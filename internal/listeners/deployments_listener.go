@@ -1,28 +1,23 @@
 package listeners
 
 import (
-	"citadel/internal/drivers"
-	"citadel/internal/models"
-	"citadel/util"
+	"citadel/internal/services"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 )
 
 type DeploymentsListener struct {
-	driver drivers.Driver
+	queue *services.DeploymentsQueue
 }
 
-func NewDeploymentsListener(driver drivers.Driver) *DeploymentsListener {
-	return &DeploymentsListener{driver}
+func NewDeploymentsListener(queue *services.DeploymentsQueue) *DeploymentsListener {
+	return &DeploymentsListener{queue}
 }
 
+// OnCreated starts the new deployment right away if its application and organization allow it.
+// Otherwise, it stays queued until DeploymentsQueue.DispatchPeriodically picks it up.
 func (deplListener *DeploymentsListener) OnCreated(msg *message.Message) ([]*message.Message, error) {
-	var deployment models.Deployment
-	if err := util.DecodeJSON(msg.Payload, &deployment); err != nil {
-		return nil, err
-	}
-
-	if err := deplListener.driver.IgniteBuilder(*deployment.Application, deployment); err != nil {
+	if err := deplListener.queue.Dispatch(msg.Context()); err != nil {
 		return nil, err
 	}
 
@@ -17,11 +17,27 @@ type Application struct {
 	Env            json.RawMessage `bun:"env,type:jsonb,default:'[]'"`
 	CpuConfig      string          `bun:"cpu_cfg"`
 	RamConfig      string          `bun:"ram_cfg"`
+	Replicas       int             `bun:"replicas,default:1"`
+
+	HealthCheckPath               string `bun:"health_check_path"`
+	HealthCheckPort               int    `bun:"health_check_port"`
+	HealthCheckInterval           int    `bun:"health_check_interval"`
+	HealthCheckTimeout            int    `bun:"health_check_timeout"`
+	HealthCheckHealthyThreshold   int    `bun:"health_check_healthy_threshold"`
+	HealthCheckUnhealthyThreshold int    `bun:"health_check_unhealthy_threshold"`
 
 	GitHubRepository     string `bun:"github_repository"`
 	GitHubBranch         string `bun:"github_branch"`
 	GitHubInstallationID int64  `bun:"github_installation_id,default:-1"`
 
+	// PreviewEnv holds the environment variables overriding the application's ones in its preview deployments.
+	PreviewEnv json.RawMessage `bun:"preview_env,type:jsonb,default:'[]'"`
+
+	// ParentApplicationID and PullRequestNumber are set on the preview applications,
+	// which deploy the pull requests opened against the GitHub branch of their parent.
+	ParentApplicationID string `bun:"parent_application_id"`
+	PullRequestNumber   int    `bun:"pull_request_number"`
+
 	Certificates []*Certificate `bun:"rel:has-many,join:id=application_id"`
 
 	OrganizationID string        `bun:"organization_id"`
@@ -44,11 +60,14 @@ func (app *Application) BeforeAppendModel(ctx context.Context, query bun.Query)
 	return nil
 }
 
+// envVar is an environment variable, as stored in Env and PreviewEnv.
+type envVar struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 func (app *Application) GetEnv() map[string]string {
-	var rawEnv []struct {
-		Key   string `json:"key"`
-		Value string `json:"value"`
-	}
+	var rawEnv []envVar
 
 	if err := json.Unmarshal(app.Env, &rawEnv); err != nil {
 		return nil
@@ -62,6 +81,44 @@ func (app *Application) GetEnv() map[string]string {
 	return env
 }
 
+// IsPreview reports whether the application deploys a pull request of its parent.
+func (app *Application) IsPreview() bool {
+	return app.ParentApplicationID != ""
+}
+
+// GetPreviewEnv returns the environment of the application's previews: its own, overridden by its PreviewEnv.
+func (app *Application) GetPreviewEnv() (json.RawMessage, error) {
+	var env, overrides []envVar
+	if len(app.Env) > 0 {
+		if err := json.Unmarshal(app.Env, &env); err != nil {
+			return nil, err
+		}
+	}
+	if len(app.PreviewEnv) > 0 {
+		if err := json.Unmarshal(app.PreviewEnv, &overrides); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, override := range overrides {
+		if override.Key == "" {
+			continue
+		}
+		overridden := false
+		for i := range env {
+			if env[i].Key == override.Key {
+				env[i].Value = override.Value
+				overridden = true
+			}
+		}
+		if !overridden {
+			env = append(env, override)
+		}
+	}
+
+	return json.Marshal(env)
+}
+
 func (app *Application) GetEnvVar(key string, defaultValues ...string) string {
 	env := app.GetEnv()
 	if val, ok := env[key]; ok {
@@ -74,3 +131,27 @@ func (app *Application) GetEnvVar(key string, defaultValues ...string) string {
 
 	return ""
 }
+
+func (app *Application) GetComputingSpecs() (ComputingSpecs, error) {
+	return ParseComputingSpecs(app.CpuConfig, app.RamConfig)
+}
+
+// MaxReplicas is the maximum number of instances an application can be scaled to.
+const MaxReplicas = 10
+
+// GetReplicas returns the number of instances the application runs, at least one.
+func (app *Application) GetReplicas() int {
+	if app.Replicas < 1 {
+		return 1
+	}
+	return app.Replicas
+}
+
+// InternalDomain is the domain of the hostnames the applications and databases are reachable at
+// from the other ones of their organization, on its private network.
+const InternalDomain = "internal"
+
+// InternalHostname returns the hostname the application is reachable at on the private network of its organization.
+func (app *Application) InternalHostname() string {
+	return app.Slug + "." + InternalDomain
+}
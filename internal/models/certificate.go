@@ -11,6 +11,7 @@ import (
 type Certificate struct {
 	ID            string            `bun:"id,pk"`
 	Domain        string            `bun:"domain"`
+	Www           bool              `bun:"www"`
 	Status        CertificateStatus `bun:"status"`
 	ValidDns      bool              `bun:"valid_dns"`
 	DnsEntries    []DnsEntry        `bun:"dns_entries,type:jsonb"`
@@ -20,6 +21,14 @@ type Certificate struct {
 	UpdatedAt     time.Time         `bun:"updated_at"`
 }
 
+// Hostnames returns the hostnames the certificate covers: its domain, and its "www" subdomain if enabled.
+func (cert *Certificate) Hostnames() []string {
+	if cert.Www {
+		return []string{cert.Domain, "www." + cert.Domain}
+	}
+	return []string{cert.Domain}
+}
+
 type CertificateStatus string
 
 const (
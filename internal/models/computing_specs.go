@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// CPUConfiguration is a CPU configuration, along with the RAM configurations it can be combined with.
+type CPUConfiguration struct {
+	Name       string   `json:"name"`
+	RAMConfigs []string `json:"ram_configs"`
+}
+
+// ComputingSpecsConfiguration lists the available CPU configurations, from the smallest to the largest,
+// along with the RAM configurations they can be combined with. The dashboard and the CLI offer them in this order.
+var ComputingSpecsConfiguration = []CPUConfiguration{
+	{Name: "shared-cpu-1x", RAMConfigs: []string{"256MB", "512MB", "1GB", "2GB"}},
+	{Name: "shared-cpu-2x", RAMConfigs: []string{"512MB", "1GB", "2GB", "4GB"}},
+	{Name: "shared-cpu-4x", RAMConfigs: []string{"1GB", "2GB", "4GB", "8GB"}},
+	{Name: "shared-cpu-8x", RAMConfigs: []string{"2GB", "4GB", "8GB", "16GB"}},
+
+	{Name: "performance-cpu-1x", RAMConfigs: []string{"2GB", "4GB", "8GB"}},
+	{Name: "performance-cpu-2x", RAMConfigs: []string{"4GB", "8GB", "16GB"}},
+	{Name: "performance-cpu-4x", RAMConfigs: []string{"8GB", "16GB", "32GB"}},
+	{Name: "performance-cpu-8x", RAMConfigs: []string{"16GB", "32GB", "64GB"}},
+	{Name: "performance-cpu-16x", RAMConfigs: []string{"32GB", "64GB", "128GB"}},
+}
+
+// RAMConfigs returns the RAM configurations a CPU configuration can be combined with,
+// or false if it isn't part of ComputingSpecsConfiguration.
+func RAMConfigs(cpuConfig string) ([]string, bool) {
+	for _, config := range ComputingSpecsConfiguration {
+		if config.Name == cpuConfig {
+			return config.RAMConfigs, true
+		}
+	}
+	return nil, false
+}
+
+const (
+	// sharedCPUShares is the relative CPU weight of shared CPUs, which yield to performance ones under contention.
+	sharedCPUShares = 512
+
+	// performanceCPUShares is the relative CPU weight of performance CPUs.
+	performanceCPUShares = 2048
+)
+
+var (
+	cpuConfigRegexp = regexp.MustCompile(`^(shared|performance)-cpu-(\d+)x$`)
+	ramConfigRegexp = regexp.MustCompile(`^(\d+)(MB|GB)$`)
+)
+
+// ComputingSpecs are the resource limits of a container.
+type ComputingSpecs struct {
+	// NanoCPUs is the CPU quota, in units of 10^-9 CPUs.
+	NanoCPUs int64
+
+	// CPUShares is the relative CPU weight of the container.
+	CPUShares int64
+
+	// Memory is the memory limit, in bytes.
+	Memory int64
+
+	// MemorySwap is the memory limit including swap, in bytes. It equals Memory, so that containers don't swap.
+	MemorySwap int64
+}
+
+// ParseComputingSpecs turns a CPU configuration (e.g. "shared-cpu-2x") and a RAM configuration (e.g. "1GB")
+// into resource limits. It fails if the combination isn't part of ComputingSpecsConfiguration.
+func ParseComputingSpecs(cpuConfig string, ramConfig string) (ComputingSpecs, error) {
+	ramConfigs, ok := RAMConfigs(cpuConfig)
+	if !ok {
+		return ComputingSpecs{}, fmt.Errorf("unknown CPU configuration: %q", cpuConfig)
+	}
+	if !slices.Contains(ramConfigs, ramConfig) {
+		return ComputingSpecs{}, fmt.Errorf("RAM configuration %q is not available with %q", ramConfig, cpuConfig)
+	}
+
+	cpuMatches := cpuConfigRegexp.FindStringSubmatch(cpuConfig)
+	if cpuMatches == nil {
+		return ComputingSpecs{}, fmt.Errorf("unknown CPU configuration: %q", cpuConfig)
+	}
+	cpus, err := strconv.ParseInt(cpuMatches[2], 10, 64)
+	if err != nil {
+		return ComputingSpecs{}, err
+	}
+
+	ramMatches := ramConfigRegexp.FindStringSubmatch(ramConfig)
+	if ramMatches == nil {
+		return ComputingSpecs{}, fmt.Errorf("unknown RAM configuration: %q", ramConfig)
+	}
+	ram, err := strconv.ParseInt(ramMatches[1], 10, 64)
+	if err != nil {
+		return ComputingSpecs{}, err
+	}
+
+	memory := ram * 1024 * 1024
+	if ramMatches[2] == "GB" {
+		memory *= 1024
+	}
+
+	cpuShares := int64(sharedCPUShares)
+	if cpuMatches[1] == "performance" {
+		cpuShares = performanceCPUShares
+	}
+
+	return ComputingSpecs{
+		NanoCPUs:   cpus * 1e9,
+		CPUShares:  cpuShares,
+		Memory:     memory,
+		MemorySwap: memory,
+	}, nil
+}
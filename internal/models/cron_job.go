@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/xid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// DefaultCronJobTimeout is how long, in seconds, the runs of a cron job may last unless told otherwise.
+	DefaultCronJobTimeout = 10 * 60
+
+	// MaxCronJobTimeout is the longest, in seconds, the runs of a cron job may be allowed to last.
+	MaxCronJobTimeout = 24 * 60 * 60
+)
+
+// CronJob is a command run periodically in a one-off instance of an application,
+// from the image of its current deployment and with its environment.
+type CronJob struct {
+	ID      string `bun:"id,pk"`
+	Name    string `bun:"name"`
+	Command string `bun:"command"`
+
+	// Schedule is a standard cron expression (e.g. "0 3 * * *"), or a descriptor (e.g. "@hourly"), in UTC.
+	Schedule string `bun:"schedule"`
+
+	// Timeout is how long, in seconds, a run may last before it is killed.
+	Timeout int `bun:"timeout"`
+
+	ApplicationID string       `bun:"application_id"`
+	Application   *Application `bun:"rel:belongs-to,join:application_id=id"`
+
+	CreatedAt time.Time `bun:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at"`
+}
+
+var _ bun.BeforeAppendModelHook = (*CronJob)(nil)
+
+func (job *CronJob) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery:
+		job.ID = xid.New().String()
+		job.CreatedAt = time.Now()
+	case *bun.UpdateQuery:
+		job.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// ParseCronSchedule parses a cron expression or descriptor, as accepted in CronJob.Schedule.
+func ParseCronSchedule(schedule string) (cron.Schedule, error) {
+	parsed, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return parsed, nil
+}
+
+// NextRun returns the time the job runs next, after the given one.
+func (job *CronJob) NextRun(after time.Time) (time.Time, error) {
+	schedule, err := ParseCronSchedule(job.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(after.UTC()), nil
+}
+
+// GetTimeout returns how long a run of the job may last.
+func (job *CronJob) GetTimeout() time.Duration {
+	if job.Timeout <= 0 {
+		return DefaultCronJobTimeout * time.Second
+	}
+	return time.Duration(min(job.Timeout, MaxCronJobTimeout)) * time.Second
+}
+
+// CronJobRun is a run of a cron job, be it scheduled or triggered by hand.
+type CronJobRun struct {
+	ID        string           `bun:"id,pk"`
+	CronJobID string           `bun:"cron_job_id"`
+	CronJob   *CronJob         `bun:"rel:belongs-to,join:cron_job_id=id"`
+	Status    CronJobRunStatus `bun:"status"`
+
+	// DeploymentID is the deployment whose image the job ran from, if any.
+	DeploymentID string `bun:"deployment_id"`
+
+	// ExitCode is the exit code of the command, or -1 if it didn't exit by itself.
+	ExitCode int    `bun:"exit_code"`
+	Output   string `bun:"output"`
+
+	StartedAt  time.Time `bun:"started_at"`
+	FinishedAt time.Time `bun:"finished_at,nullzero"`
+
+	CreatedAt time.Time `bun:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at"`
+}
+
+var _ bun.BeforeAppendModelHook = (*CronJobRun)(nil)
+
+func (run *CronJobRun) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery:
+		// The ID may be set beforehand, to name the instance running the job after it.
+		if run.ID == "" {
+			run.ID = xid.New().String()
+		}
+		run.CreatedAt = time.Now()
+	case *bun.UpdateQuery:
+		run.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// Duration returns how long the run lasted, or has been lasting.
+func (run *CronJobRun) Duration() time.Duration {
+	if run.FinishedAt.IsZero() {
+		return time.Since(run.StartedAt).Round(time.Second)
+	}
+	return run.FinishedAt.Sub(run.StartedAt).Round(time.Second)
+}
+
+type CronJobRunStatus string
+
+const (
+	CronJobRunStatusRunning   CronJobRunStatus = "Running"
+	CronJobRunStatusSucceeded CronJobRunStatus = "Succeeded"
+	CronJobRunStatusFailed    CronJobRunStatus = "Failed"
+	CronJobRunStatusTimedOut  CronJobRunStatus = "Timed Out"
+)
+
+func (status CronJobRunStatus) String() string {
+	return string(status)
+}
@@ -17,6 +17,9 @@ type Database struct {
 	Username string `bun:"username"`
 	Password string `bun:"password"`
 
+	CpuConfig string `bun:"cpu_cfg"`
+	RamConfig string `bun:"ram_cfg"`
+
 	Organization   *Organization `bun:"rel:belongs-to,join:organization_id=id"`
 	OrganizationID string        `bun:"organization_id"`
 
@@ -57,3 +60,20 @@ func (db *Database) GetURI() string {
 		return ""
 	}
 }
+
+// InternalHostname returns the hostname the database is reachable at on the private network of its organization.
+func (db *Database) InternalHostname() string {
+	return db.Slug + "." + InternalDomain
+}
+
+// GetInternalURI returns the URI of the database on the private network of its organization,
+// which doesn't go through the public entrypoint, nor TLS.
+func (db *Database) GetInternalURI() string {
+	internal := *db
+	internal.Host = db.InternalHostname()
+	return internal.GetURI()
+}
+
+func (db *Database) GetComputingSpecs() (ComputingSpecs, error) {
+	return ParseComputingSpecs(db.CpuConfig, db.RamConfig)
+}
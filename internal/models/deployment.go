@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/rs/xid"
@@ -14,8 +15,19 @@ type Deployment struct {
 	Status        DeploymentStatus `bun:"status"`
 	ApplicationID string           `bun:"application_id"`
 	Application   *Application     `bun:"rel:belongs-to,join:application_id=id"`
-	CreatedAt     time.Time        `bun:"created_at"`
-	UpdatedAt     time.Time        `bun:"updated_at"`
+
+	CommitSHA        string `bun:"commit_sha"`
+	GitHubCheckRunID int64  `bun:"github_check_run_id"`
+	ImageTag         string `bun:"image_tag"`
+	ReleaseCommand   string `bun:"release_command"`
+	ReleaseOutput    string `bun:"release_output"`
+
+	// Processes holds the commands of the process types declared by the deployment, by name.
+	// The web one, if any, overrides the default command of the image.
+	Processes map[string]string `bun:"processes,type:jsonb"`
+
+	CreatedAt time.Time `bun:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at"`
 }
 
 var _ bun.BeforeAppendModelHook = (*Deployment)(nil)
@@ -23,7 +35,10 @@ var _ bun.BeforeAppendModelHook = (*Deployment)(nil)
 func (deployment *Deployment) BeforeAppendModel(ctx context.Context, query bun.Query) error {
 	switch query.(type) {
 	case *bun.InsertQuery:
-		deployment.ID = xid.New().String()
+		// The ID may be set beforehand, to store the deployment's tarball under it before it gets queued.
+		if deployment.ID == "" {
+			deployment.ID = xid.New().String()
+		}
 		deployment.CreatedAt = time.Now()
 	case *bun.UpdateQuery:
 		deployment.UpdatedAt = time.Now()
@@ -34,8 +49,9 @@ func (deployment *Deployment) BeforeAppendModel(ctx context.Context, query bun.Q
 type DeploymentOrigin string
 
 const (
-	DeploymentOriginCli    DeploymentOrigin = "CLI"
-	DeploymentOriginGithub DeploymentOrigin = "GitHub"
+	DeploymentOriginCli      DeploymentOrigin = "CLI"
+	DeploymentOriginGithub   DeploymentOrigin = "GitHub"
+	DeploymentOriginRollback DeploymentOrigin = "Rollback"
 )
 
 func (origin DeploymentOrigin) String() string {
@@ -45,13 +61,54 @@ func (origin DeploymentOrigin) String() string {
 type DeploymentStatus string
 
 const (
-	DeploymentStatusBuilding     DeploymentStatus = "Building"
-	DeploymentStatusBuildFailed  DeploymentStatus = "Build Failed"
-	DeploymentStatusDeploying    DeploymentStatus = "Deploying"
-	DeploymentStatusDeployFailed DeploymentStatus = "Deployment Failed"
-	DeploymentStatusSuccess      DeploymentStatus = "Success"
+	DeploymentStatusQueued        DeploymentStatus = "Queued"
+	DeploymentStatusSuperseded    DeploymentStatus = "Superseded"
+	DeploymentStatusBuilding      DeploymentStatus = "Building"
+	DeploymentStatusBuildFailed   DeploymentStatus = "Build Failed"
+	DeploymentStatusReleasing     DeploymentStatus = "Releasing"
+	DeploymentStatusReleaseFailed DeploymentStatus = "Release Failed"
+	DeploymentStatusDeploying     DeploymentStatus = "Deploying"
+	DeploymentStatusDeployFailed  DeploymentStatus = "Deployment Failed"
+	DeploymentStatusSuccess       DeploymentStatus = "Success"
+	DeploymentStatusCanceled      DeploymentStatus = "Canceled"
 )
 
 func (status DeploymentStatus) String() string {
 	return string(status)
 }
+
+// CanBeRolledBackTo reports whether the deployment's image can be deployed again.
+func (deployment *Deployment) CanBeRolledBackTo() bool {
+	return deployment.Status == DeploymentStatusSuccess && deployment.ImageTag != ""
+}
+
+// IsActive reports whether the deployment is being built, released or rolled out.
+func (deployment *Deployment) IsActive() bool {
+	switch deployment.Status {
+	case DeploymentStatusBuilding, DeploymentStatusReleasing, DeploymentStatusDeploying:
+		return true
+	}
+	return false
+}
+
+// CanBeCanceled reports whether the deployment is queued or active.
+func (deployment *Deployment) CanBeCanceled() bool {
+	return deployment.Status == DeploymentStatusQueued || deployment.IsActive()
+}
+
+// WebCommand returns the command declared for the web process type, if any.
+func (deployment *Deployment) WebCommand() string {
+	return deployment.Processes[ProcessTypeWeb]
+}
+
+// BackgroundProcessTypes returns the names of the process types declared by the deployment besides the web one, sorted.
+func (deployment *Deployment) BackgroundProcessTypes() []string {
+	names := []string{}
+	for name := range deployment.Processes {
+		if name != ProcessTypeWeb {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultHealthCheckInterval           = 5
+	defaultHealthCheckTimeout            = 5
+	defaultHealthCheckHealthyThreshold   = 1
+	defaultHealthCheckUnhealthyThreshold = 12
+)
+
+// HealthCheck is the HTTP health check probing the new deployments of an application.
+type HealthCheck struct {
+	Path               string
+	Port               string
+	Interval           time.Duration
+	Timeout            time.Duration
+	HealthyThreshold   int
+	UnhealthyThreshold int
+}
+
+// healthCheckPathRegexp matches the URL paths, with an optional query, a health check may probe.
+var healthCheckPathRegexp = regexp.MustCompile(`^/[A-Za-z0-9._~%/?=&+,:@-]*$`)
+
+// ValidateHealthCheckPath checks that the health check path of an application is a URL path.
+func ValidateHealthCheckPath(path string) error {
+	if path != "" && !healthCheckPathRegexp.MatchString(path) {
+		return fmt.Errorf("invalid health check path %q: it must start with a slash, and only contain the characters of URL paths", path)
+	}
+	return nil
+}
+
+// HasHealthCheck reports whether new deployments of the application are probed over HTTP.
+func (app *Application) HasHealthCheck() bool {
+	return app.HealthCheckPath != ""
+}
+
+// GetHealthCheck returns the health check settings of the application, with defaults for the unset ones.
+func (app *Application) GetHealthCheck() HealthCheck {
+	hc := HealthCheck{
+		Path:               app.HealthCheckPath,
+		Port:               app.GetEnvVar("PORT", "3000"),
+		Interval:           defaultHealthCheckInterval * time.Second,
+		Timeout:            defaultHealthCheckTimeout * time.Second,
+		HealthyThreshold:   defaultHealthCheckHealthyThreshold,
+		UnhealthyThreshold: defaultHealthCheckUnhealthyThreshold,
+	}
+
+	if app.HealthCheckPort > 0 {
+		hc.Port = strconv.Itoa(app.HealthCheckPort)
+	}
+	if app.HealthCheckInterval > 0 {
+		hc.Interval = time.Duration(app.HealthCheckInterval) * time.Second
+	}
+	if app.HealthCheckTimeout > 0 {
+		hc.Timeout = time.Duration(app.HealthCheckTimeout) * time.Second
+	}
+	if app.HealthCheckHealthyThreshold > 0 {
+		hc.HealthyThreshold = app.HealthCheckHealthyThreshold
+	}
+	if app.HealthCheckUnhealthyThreshold > 0 {
+		hc.UnhealthyThreshold = app.HealthCheckUnhealthyThreshold
+	}
+
+	return hc
+}
+
+type HealthCheckStatus string
+
+const (
+	// HealthCheckStatusPending means the health check hasn't reached any of its thresholds yet.
+	HealthCheckStatusPending HealthCheckStatus = "pending"
+	HealthCheckStatusPassing HealthCheckStatus = "passing"
+	HealthCheckStatusFailed  HealthCheckStatus = "failed"
+)
+
+// HealthCheckEvent reports the result of a health check probe on a new deployment.
+// It is sent over the logs stream as a "health" event.
+type HealthCheckEvent struct {
+	DeploymentID string            `json:"deployment_id"`
+	Status       HealthCheckStatus `json:"status"`
+	Message      string            `json:"message"`
+	Timestamp    time.Time         `json:"timestamp"`
+}
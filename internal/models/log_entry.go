@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/uptrace/bun"
+)
+
+// LogEntry is a line of output of an application or builder container, kept until the retention period
+// of the application's organization runs out.
+type LogEntry struct {
+	ID            string         `bun:"id,pk"`
+	ApplicationID string         `bun:"application_id,notnull"`
+	DeploymentID  string         `bun:"deployment_id"`
+	Source        LogEntrySource `bun:"source,notnull"`
+	Process       string         `bun:"process"`
+	Stream        LogEntryStream `bun:"stream,notnull"`
+	Instance      string         `bun:"instance"`
+	Message       string         `bun:"message"`
+	Timestamp     time.Time      `bun:"timestamp,notnull"`
+
+	// Level is the level of the structured (JSON) lines, parsed out on insert, so that they can be searched by it.
+	Level LogLevel `bun:"level"`
+}
+
+var _ bun.BeforeAppendModelHook = (*LogEntry)(nil)
+
+func (m *LogEntry) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery:
+		m.ID = xid.New().String()
+		m.Level = NewLogEvent(m.Timestamp, m.Stream, m.DeploymentID, m.Message).Level
+	}
+	return nil
+}
+
+type LogEntrySource string
+
+const (
+	LogEntrySourceApp     LogEntrySource = "app"
+	LogEntrySourceBuilder LogEntrySource = "builder"
+	LogEntrySourceRelease LogEntrySource = "release"
+)
+
+// LogEntryStream is the output a line of logs was written to. The lines collected by the Kubernetes driver
+// are all stdout, as Kubernetes merges the output of the containers into a single stream.
+type LogEntryStream string
+
+const (
+	LogEntryStreamStdout LogEntryStream = "stdout"
+	LogEntryStreamStderr LogEntryStream = "stderr"
+)
+
+// DefaultLogRetentionDays is the number of days the logs of an organization's applications are kept by default.
+const DefaultLogRetentionDays = 7
+
+// MaxLogRetentionDays is the maximum number of days the logs of an organization's applications can be kept.
+const MaxLogRetentionDays = 90
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// LogEvent is a line of logs as streamed to the clients. When the line is a JSON object (i.e. structured logs),
+// its fields and level are parsed out of it.
+type LogEvent struct {
+	Timestamp    time.Time      `json:"timestamp"`
+	Stream       LogEntryStream `json:"stream"`
+	DeploymentID string         `json:"deployment_id"`
+	Process      string         `json:"process,omitempty"`
+	Message      string         `json:"message"`
+	Level        LogLevel       `json:"level,omitempty"`
+	Fields       map[string]any `json:"fields,omitempty"`
+}
+
+// NewLogEvent returns the event of a line of logs, parsing its fields and level if it's a JSON object.
+func NewLogEvent(timestamp time.Time, stream LogEntryStream, deploymentID string, message string) LogEvent {
+	event := LogEvent{
+		Timestamp:    timestamp,
+		Stream:       stream,
+		DeploymentID: deploymentID,
+		Message:      message,
+	}
+
+	if trimmed := strings.TrimSpace(message); strings.HasPrefix(trimmed, "{") {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			event.Fields = fields
+			event.Level = levelFromFields(fields)
+		}
+	}
+
+	return event
+}
+
+// Event returns the event of a stored log entry.
+func (m LogEntry) Event() LogEvent {
+	event := NewLogEvent(m.Timestamp, m.Stream, m.DeploymentID, m.Message)
+	event.Process = m.Process
+	return event
+}
+
+type LogLevel string
+
+const (
+	LogLevelDebug LogLevel = "debug"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+	LogLevelFatal LogLevel = "fatal"
+)
+
+// LogLevels are the log levels, from the least to the most severe.
+var LogLevels = []LogLevel{LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelFatal}
+
+// AtLeast returns the levels of LogLevels as severe as this one or more.
+func (level LogLevel) AtLeast() []LogLevel {
+	if severity := level.Severity(); severity >= 0 {
+		return LogLevels[severity:]
+	}
+	return nil
+}
+
+// Severity returns the rank of the level in LogLevels, or -1 for an unknown (or missing) level.
+func (level LogLevel) Severity() int {
+	for i, l := range LogLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// logLevelKeys are the fields holding the level of the lines of the common logging libraries.
+var logLevelKeys = []string{"level", "lvl", "severity", "log.level", "levelname"}
+
+// levelFromFields returns the level of a structured line of logs, normalized to one of LogLevels.
+func levelFromFields(fields map[string]any) LogLevel {
+	for _, key := range logLevelKeys {
+		switch value := fields[key].(type) {
+		case string:
+			return normalizeLogLevel(value)
+		case float64:
+			// Numeric levels, as used by pino and bunyan.
+			switch {
+			case value >= 60:
+				return LogLevelFatal
+			case value >= 50:
+				return LogLevelError
+			case value >= 40:
+				return LogLevelWarn
+			case value >= 30:
+				return LogLevelInfo
+			default:
+				return LogLevelDebug
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeLogLevel(level string) LogLevel {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return LogLevelDebug
+	case "info", "information", "notice":
+		return LogLevelInfo
+	case "warn", "warning":
+		return LogLevelWarn
+	case "err", "error":
+		return LogLevelError
+	case "fatal", "critical", "crit", "panic", "emergency", "alert":
+		return LogLevelFatal
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+type MetricResourceType string
+
+const (
+	MetricResourceApplication MetricResourceType = "application"
+	MetricResourceDatabase    MetricResourceType = "database"
+)
+
+// The resolutions of the metric samples, in seconds. The containers are sampled at the finest one, and their
+// samples are downsampled to the coarser ones as they age, each resolution being kept for its retention period.
+const (
+	MetricResolutionRaw    = 30
+	MetricResolutionMinute = 5 * 60
+	MetricResolutionHour   = 60 * 60
+)
+
+// MetricRetentions are how long the samples of each resolution are kept.
+var MetricRetentions = map[int]time.Duration{
+	MetricResolutionRaw:    24 * time.Hour,
+	MetricResolutionMinute: 7 * 24 * time.Hour,
+	MetricResolutionHour:   90 * 24 * time.Hour,
+}
+
+// MetricSample is the resource usage of the instances of an application's process type, or of a database,
+// over a period of time starting at its timestamp and lasting for its resolution.
+type MetricSample struct {
+	ResourceType MetricResourceType `bun:"resource_type,pk" json:"resource_type"`
+	ResourceID   string             `bun:"resource_id,pk" json:"resource_id"`
+	Process      string             `bun:"process,pk" json:"process"`
+	Resolution   int                `bun:"resolution,pk" json:"resolution"`
+	Timestamp    time.Time          `bun:"timestamp,pk" json:"timestamp"`
+
+	// Instances is the number of containers sampled.
+	Instances int `bun:"instances" json:"instances"`
+
+	// CPUPercent is the CPU usage of all the instances, in percent of one CPU (e.g. 150 for one and a half).
+	CPUPercent float64 `bun:"cpu_percent" json:"cpu_percent"`
+
+	// MemoryUsage and MemoryLimit are the memory used by all the instances, and the one they're limited to, in bytes.
+	MemoryUsage int64 `bun:"memory_usage" json:"memory_usage"`
+	MemoryLimit int64 `bun:"memory_limit" json:"memory_limit"`
+
+	// NetworkRx and NetworkTx are the bytes received and sent by the instances over the period.
+	NetworkRx int64 `bun:"network_rx" json:"network_rx"`
+	NetworkTx int64 `bun:"network_tx" json:"network_tx"`
+
+	// Restarts is the number of instances that exited unexpectedly (e.g. crashed or ran out of memory) over the period.
+	Restarts int `bun:"restarts" json:"restarts"`
+}
@@ -13,6 +13,9 @@ type Organization struct {
 	Name string `bun:"name,notnull"`
 	Slug string `bun:"slug,notnull"`
 
+	LogRetentionDays    int `bun:"log_retention_days,default:7"`
+	MaxConcurrentBuilds int `bun:"max_concurrent_builds,default:2"`
+
 	OrganizationMembers []*OrganizationMember `bun:"rel:has-many,join:id=organization_id"`
 
 	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
@@ -31,3 +34,25 @@ func (m *Organization) BeforeAppendModel(ctx context.Context, query bun.Query) e
 	}
 	return nil
 }
+
+// GetLogRetentionDays returns the number of days the logs of the organization's applications are kept.
+func (m *Organization) GetLogRetentionDays() int {
+	if m.LogRetentionDays < 1 {
+		return DefaultLogRetentionDays
+	}
+	return m.LogRetentionDays
+}
+
+// DefaultMaxConcurrentBuilds is the number of deployments of an organization that can be built at once by default.
+const DefaultMaxConcurrentBuilds = 2
+
+// MaxConcurrentBuildsLimit is the highest number of concurrent builds an organization can set.
+const MaxConcurrentBuildsLimit = 10
+
+// GetMaxConcurrentBuilds returns the number of deployments of the organization that can be built at once.
+func (m *Organization) GetMaxConcurrentBuilds() int {
+	if m.MaxConcurrentBuilds < 1 {
+		return DefaultMaxConcurrentBuilds
+	}
+	return m.MaxConcurrentBuilds
+}
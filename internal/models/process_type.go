@@ -0,0 +1,106 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/uptrace/bun"
+)
+
+// ProcessTypeWeb is the process type serving the HTTP traffic of an application. It runs as the application
+// itself, with its replicas and computing specs, and the default command of its image unless one is declared.
+const ProcessTypeWeb = "web"
+
+// processTypeNameRegexp matches the names of the process types, which are part of the names of their containers.
+var processTypeNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]{0,29}$`)
+
+// ProcessType is a kind of process an application runs besides its web one (e.g. "worker" or "scheduler"),
+// as declared in its citadel.toml or Procfile. Its instances run its command, and don't receive any traffic.
+type ProcessType struct {
+	ID      string `bun:"id,pk"`
+	Name    string `bun:"name"`
+	Command string `bun:"command"`
+
+	// Replicas is the number of instances of the process type, which may be scaled down to zero.
+	Replicas int `bun:"replicas"`
+
+	// CpuConfig and RamConfig default to the ones of the application when empty.
+	CpuConfig string `bun:"cpu_cfg"`
+	RamConfig string `bun:"ram_cfg"`
+
+	ApplicationID string       `bun:"application_id"`
+	Application   *Application `bun:"rel:belongs-to,join:application_id=id"`
+
+	CreatedAt time.Time `bun:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at"`
+}
+
+var _ bun.BeforeAppendModelHook = (*ProcessType)(nil)
+
+func (process *ProcessType) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery:
+		process.ID = xid.New().String()
+		process.CreatedAt = time.Now()
+	case *bun.UpdateQuery:
+		process.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// GetReplicas returns the number of instances the process type runs.
+func (process *ProcessType) GetReplicas() int {
+	return min(max(process.Replicas, 0), MaxReplicas)
+}
+
+// GetComputingSpecs returns the resource limits of the instances of the process type,
+// which are the ones of the application unless it has its own.
+func (process *ProcessType) GetComputingSpecs(app Application) (ComputingSpecs, error) {
+	if process.CpuConfig == "" || process.RamConfig == "" {
+		return app.GetComputingSpecs()
+	}
+	return ParseComputingSpecs(process.CpuConfig, process.RamConfig)
+}
+
+// ValidateProcessTypeName checks that the name of a process type is made of lowercase letters, digits and dashes.
+func ValidateProcessTypeName(name string) error {
+	if !processTypeNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid process type %q: it must start with a letter, and be made of at most 30 lowercase letters, digits and dashes", name)
+	}
+	return nil
+}
+
+// ParseProcfile parses the process types declared in a Procfile, one "<name>: <command>" per line.
+// The blank lines and the ones starting with a "#" are ignored.
+func ParseProcfile(content []byte) (map[string]string, error) {
+	processes := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, command, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid Procfile line %q: it must be \"<name>: <command>\"", line)
+		}
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if err := ValidateProcessTypeName(name); err != nil {
+			return nil, err
+		}
+		if command == "" {
+			return nil, fmt.Errorf("the process type %q has no command", name)
+		}
+		processes[name] = command
+	}
+
+	return processes, scanner.Err()
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// MinVolumeSize and MaxVolumeSize bound the size of the volumes, in gigabytes.
+	MinVolumeSize = 1
+	MaxVolumeSize = 500
+)
+
+// reservedMountPaths are the directories of the containers volumes can't be mounted on, nor in.
+var reservedMountPaths = []string{"/bin", "/dev", "/etc", "/lib", "/proc", "/sbin", "/sys", "/usr"}
+
+// Volume is a persistent disk of an organization. Once attached to an application, it is mounted at its mount path
+// in all the containers of the application, from its next deployment, and its content is kept across deployments.
+type Volume struct {
+	ID        string `bun:"id,pk"`
+	Name      string `bun:"name"`
+	Slug      string `bun:"slug,unique"`
+	MountPath string `bun:"mount_path"`
+
+	// Size is the size of the volume, in gigabytes.
+	Size int `bun:"size"`
+
+	Organization   *Organization `bun:"rel:belongs-to,join:organization_id=id"`
+	OrganizationID string        `bun:"organization_id"`
+
+	// ApplicationID is empty while the volume isn't attached to any application.
+	Application   *Application `bun:"rel:belongs-to,join:application_id=id"`
+	ApplicationID string       `bun:"application_id,nullzero"`
+
+	CreatedAt time.Time `bun:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at"`
+}
+
+var _ bun.BeforeAppendModelHook = (*Volume)(nil)
+
+func (volume *Volume) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery:
+		volume.ID = xid.New().String()
+		volume.CreatedAt = time.Now()
+	case *bun.UpdateQuery:
+		volume.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// SizeInBytes returns the size of the volume, in bytes.
+func (volume *Volume) SizeInBytes() int64 {
+	return int64(volume.Size) * 1024 * 1024 * 1024
+}
+
+// ValidateMountPath checks that a mount path is an absolute path, other than the root and the system directories.
+func ValidateMountPath(mountPath string) error {
+	if !path.IsAbs(mountPath) || path.Clean(mountPath) != mountPath {
+		return fmt.Errorf("the mount path must be an absolute path, such as /data")
+	}
+	if mountPath == "/" {
+		return fmt.Errorf("volumes can't be mounted at the root of the containers")
+	}
+	for _, reserved := range reservedMountPaths {
+		if mountPath == reserved || strings.HasPrefix(mountPath, reserved+"/") {
+			return fmt.Errorf("volumes can't be mounted in %s", reserved)
+		}
+	}
+	return nil
+}
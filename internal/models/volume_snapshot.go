@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/uptrace/bun"
+)
+
+type VolumeSnapshotStatus string
+
+const (
+	VolumeSnapshotStatusPending   VolumeSnapshotStatus = "pending"
+	VolumeSnapshotStatusCompleted VolumeSnapshotStatus = "completed"
+	VolumeSnapshotStatusFailed    VolumeSnapshotStatus = "failed"
+)
+
+// VolumeSnapshot is a copy of the content of a volume at some point in time, stored as a gzipped tarball
+// in the platform's S3 bucket, which the volume can be restored from.
+type VolumeSnapshot struct {
+	ID     string               `bun:"id,pk"`
+	Status VolumeSnapshotStatus `bun:"status"`
+
+	// Size is the size of the tarball, in bytes.
+	Size int64 `bun:"size"`
+
+	Volume   *Volume `bun:"rel:belongs-to,join:volume_id=id"`
+	VolumeID string  `bun:"volume_id"`
+
+	CreatedAt time.Time `bun:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at"`
+}
+
+var _ bun.BeforeAppendModelHook = (*VolumeSnapshot)(nil)
+
+func (snapshot *VolumeSnapshot) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery:
+		snapshot.ID = xid.New().String()
+		snapshot.CreatedAt = time.Now()
+	case *bun.UpdateQuery:
+		snapshot.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// ObjectKey returns the key of the tarball of the snapshot of a volume in the platform's S3 bucket,
+// under the prefix of the organization of the volume.
+func (snapshot *VolumeSnapshot) ObjectKey(volume Volume) string {
+	return "volume-snapshots/" + volume.OrganizationID + "/" + volume.ID + "/" + snapshot.ID + ".tar.gz"
+}
@@ -3,6 +3,8 @@ package repositories
 import (
 	"citadel/internal/models"
 	"context"
+	"database/sql"
+	"errors"
 
 	"github.com/Squwid/go-randomizer"
 	"github.com/caesar-rocks/orm"
@@ -23,8 +25,11 @@ func (r ApplicationsRepository) Create(ctx context.Context, app *models.Applicat
 	slug := slug.Make(app.Name)
 
 	for {
-		_, err := r.FindOneBy(ctx, "slug", slug)
+		taken, err := slugTaken(ctx, r.Database, slug)
 		if err != nil {
+			return err
+		}
+		if !taken {
 			break
 		}
 
@@ -40,6 +45,11 @@ func (r ApplicationsRepository) Create(ctx context.Context, app *models.Applicat
 	return nil
 }
 
+// Exists reports whether an application, given by ID, exists.
+func (r ApplicationsRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return r.NewSelect().Model((*models.Application)(nil)).Where("id = ?", id).Exists(ctx)
+}
+
 func (r ApplicationsRepository) FindAllFromOrg(ctx context.Context, orgId string) ([]models.Application, error) {
 	var items []models.Application = make([]models.Application, 0)
 
@@ -50,3 +60,56 @@ func (r ApplicationsRepository) FindAllFromOrg(ctx context.Context, orgId string
 
 	return items, nil
 }
+
+// FindAllFromGitHubRepository returns the applications deploying a branch of a GitHub repository.
+// The previews, which deploy pull requests, are left out.
+func (r ApplicationsRepository) FindAllFromGitHubRepository(ctx context.Context, repository string, branch string) ([]models.Application, error) {
+	var items []models.Application = make([]models.Application, 0)
+
+	err := r.NewSelect().
+		Model((*models.Application)(nil)).
+		Where("github_repository = ?", repository).
+		Where("github_branch = ?", branch).
+		Where("COALESCE(parent_application_id, '') = ''").
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindAllPreviews returns the previews of an application.
+func (r ApplicationsRepository) FindAllPreviews(ctx context.Context, parentId string) ([]models.Application, error) {
+	var items []models.Application = make([]models.Application, 0)
+
+	err := r.NewSelect().
+		Model((*models.Application)(nil)).
+		Where("parent_application_id = ?", parentId).
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindPreview returns the preview of an application for a pull request, or nil if it has none.
+func (r ApplicationsRepository) FindPreview(ctx context.Context, parentId string, pullRequestNumber int) (*models.Application, error) {
+	var item *models.Application = new(models.Application)
+
+	err := r.NewSelect().
+		Model(item).
+		Where("parent_application_id = ?", parentId).
+		Where("pull_request_number = ?", pullRequestNumber).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
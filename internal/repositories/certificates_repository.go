@@ -29,3 +29,18 @@ func (r *CertificatesRepository) FindAllFromApp(ctx context.Context, appId strin
 
 	return items, nil
 }
+
+func (r *CertificatesRepository) FindAllVerifiedFromApp(ctx context.Context, appId string) ([]models.Certificate, error) {
+	var items []models.Certificate
+
+	err := r.NewSelect().Model((*models.Certificate)(nil)).Where(
+		"application_id = ?", appId,
+	).Where(
+		"status = ?", models.CertificateStatusVerified,
+	).Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
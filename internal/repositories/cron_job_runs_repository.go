@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+	"time"
+
+	"github.com/caesar-rocks/orm"
+)
+
+type CronJobRunsRepository struct {
+	*orm.Repository[models.CronJobRun]
+}
+
+func NewCronJobRunsRepository(db *orm.Database) *CronJobRunsRepository {
+	return &CronJobRunsRepository{Repository: &orm.Repository[models.CronJobRun]{
+		Database: db,
+	}}
+}
+
+// FindLatestFromCronJob returns the most recent runs of a cron job, from the most recent.
+func (r *CronJobRunsRepository) FindLatestFromCronJob(ctx context.Context, jobId string, limit int) ([]models.CronJobRun, error) {
+	var items []models.CronJobRun = make([]models.CronJobRun, 0)
+
+	err := r.NewSelect().
+		Model((*models.CronJobRun)(nil)).
+		Where("cron_job_id = ?", jobId).
+		Order("started_at DESC").
+		Limit(limit).
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FailAllRunning marks the runs still running as failed, as they can't be waited for anymore
+// (e.g. when Citadel restarted in the middle of them), and returns how many there were.
+func (r *CronJobRunsRepository) FailAllRunning(ctx context.Context) (int64, error) {
+	res, err := r.NewUpdate().
+		Model((*models.CronJobRun)(nil)).
+		Set("status = ?", models.CronJobRunStatusFailed).
+		Set("exit_code = ?", -1).
+		Set("finished_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("status = ?", models.CronJobRunStatusRunning).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// DeleteAllFromCronJob deletes the runs of a cron job.
+func (r *CronJobRunsRepository) DeleteAllFromCronJob(ctx context.Context, jobId string) error {
+	_, err := r.NewDelete().Model((*models.CronJobRun)(nil)).Where("cron_job_id = ?", jobId).Exec(ctx)
+	return err
+}
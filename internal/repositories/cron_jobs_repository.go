@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/caesar-rocks/orm"
+)
+
+type CronJobsRepository struct {
+	*orm.Repository[models.CronJob]
+}
+
+func NewCronJobsRepository(db *orm.Database) *CronJobsRepository {
+	return &CronJobsRepository{Repository: &orm.Repository[models.CronJob]{
+		Database: db,
+	}}
+}
+
+func (r *CronJobsRepository) FindAllFromApplication(ctx context.Context, appId string) ([]models.CronJob, error) {
+	var items []models.CronJob = make([]models.CronJob, 0)
+
+	err := r.NewSelect().Model((*models.CronJob)(nil)).Where("application_id = ?", appId).Order("name ASC").Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindAllWithRelatedApp returns all the cron jobs, along with their application.
+func (r *CronJobsRepository) FindAllWithRelatedApp(ctx context.Context) ([]models.CronJob, error) {
+	var items []models.CronJob = make([]models.CronJob, 0)
+
+	err := r.NewSelect().Model(&items).Relation("Application").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -21,8 +21,11 @@ func (r DatabasesRepository) Create(ctx context.Context, db *models.Database) er
 	slug := slug.Make(db.Name)
 
 	for {
-		_, err := r.FindOneBy(ctx, "slug", slug)
+		taken, err := slugTaken(ctx, r.Database, slug)
 		if err != nil {
+			return err
+		}
+		if !taken {
 			break
 		}
 
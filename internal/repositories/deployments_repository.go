@@ -3,8 +3,12 @@ package repositories
 import (
 	"citadel/internal/models"
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/caesar-rocks/orm"
+	"github.com/uptrace/bun"
 )
 
 type DeploymentsRepository struct {
@@ -44,3 +48,113 @@ func (r *DeploymentsRepository) FindOneByIdWithRelatedAppAndCerts(ctx context.Co
 
 	return item, nil
 }
+
+// FindAllQueued returns the queued deployments with their application and organization, from the oldest.
+func (r *DeploymentsRepository) FindAllQueued(ctx context.Context) ([]models.Deployment, error) {
+	var items []models.Deployment = make([]models.Deployment, 0)
+
+	err := r.NewSelect().
+		Model(&items).
+		Where("deployment.status = ?", models.DeploymentStatusQueued).
+		Relation("Application").
+		Relation("Application.Organization").
+		Order("deployment.created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindAllActiveUpdatedSince returns the deployments being built, released or rolled out
+// whose status changed since the given time, with their application.
+func (r *DeploymentsRepository) FindAllActiveUpdatedSince(ctx context.Context, since time.Time) ([]models.Deployment, error) {
+	var items []models.Deployment = make([]models.Deployment, 0)
+
+	err := r.NewSelect().
+		Model(&items).
+		Where("deployment.status IN (?)", bun.In([]models.DeploymentStatus{
+			models.DeploymentStatusBuilding,
+			models.DeploymentStatusReleasing,
+			models.DeploymentStatusDeploying,
+		})).
+		Where("deployment.updated_at >= ?", since).
+		Relation("Application").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindLatestSuccessfulFromApplication returns the deployment an application runs, i.e. its most recent
+// successful one, or nil if it has never been deployed successfully.
+func (r *DeploymentsRepository) FindLatestSuccessfulFromApplication(ctx context.Context, appId string) (*models.Deployment, error) {
+	var item *models.Deployment = new(models.Deployment)
+
+	err := r.NewSelect().
+		Model(item).
+		Where("deployment.application_id = ?", appId).
+		Where("deployment.status = ?", models.DeploymentStatusSuccess).
+		Order("deployment.created_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// TransitionStatus changes the status of a deployment only if it still is the given one,
+// and reports whether it did.
+func (r *DeploymentsRepository) TransitionStatus(ctx context.Context, depl *models.Deployment, from models.DeploymentStatus, to models.DeploymentStatus) (bool, error) {
+	depl.Status = to
+
+	res, err := r.NewUpdate().
+		Model(depl).
+		Column("status", "updated_at").
+		Where("id = ?", depl.ID).
+		Where("status = ?", from).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		depl.Status = from
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// UpdateUnlessCanceled updates a deployment only if it hasn't been canceled in the meantime,
+// and reports whether it did.
+func (r *DeploymentsRepository) UpdateUnlessCanceled(ctx context.Context, depl *models.Deployment) (bool, error) {
+	res, err := r.NewUpdate().
+		Model(depl).
+		Where("id = ?", depl.ID).
+		Where("status <> ?", models.DeploymentStatusCanceled).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"citadel/database"
+	"citadel/internal/models"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/caesar-rocks/orm"
+)
+
+// newTestDeploymentsRepository returns a repository whose deployments are stored in a SQLite database of its own.
+func newTestDeploymentsRepository(t *testing.T) *DeploymentsRepository {
+	t.Helper()
+
+	db := orm.NewDatabase(&orm.DatabaseConfig{
+		DBMS: orm.DBMS("sqlite"),
+		DSN:  filepath.Join(t.TempDir(), "citadel.sqlite"),
+	})
+	db.Migrate(database.GetMigrations())
+
+	return NewDeploymentsRepository(db)
+}
+
+func TestUpdateUnlessCanceledKeepsTheCanceledStatus(t *testing.T) {
+	r := newTestDeploymentsRepository(t)
+	ctx := context.Background()
+
+	depl := &models.Deployment{ID: "depl1", ApplicationID: "app1", Status: models.DeploymentStatusBuilding}
+	if err := r.Create(ctx, depl); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	depl.Status = models.DeploymentStatusDeploying
+	updated, err := r.UpdateUnlessCanceled(ctx, depl)
+	if err != nil || !updated {
+		t.Fatalf("UpdateUnlessCanceled = %v, %v, want true, nil", updated, err)
+	}
+
+	canceled := *depl
+	canceled.Status = models.DeploymentStatusCanceled
+	if err := r.UpdateOneWhere(ctx, &canceled, "id", depl.ID); err != nil {
+		t.Fatalf("UpdateOneWhere: %v", err)
+	}
+
+	depl.Status = models.DeploymentStatusSuccess
+	updated, err = r.UpdateUnlessCanceled(ctx, depl)
+	if err != nil || updated {
+		t.Fatalf("UpdateUnlessCanceled = %v, %v, want false, nil", updated, err)
+	}
+
+	stored, err := r.FindOneBy(ctx, "id", depl.ID)
+	if err != nil {
+		t.Fatalf("FindOneBy: %v", err)
+	}
+	if stored.Status != models.DeploymentStatusCanceled {
+		t.Errorf("status = %q, want %q", stored.Status, models.DeploymentStatusCanceled)
+	}
+}
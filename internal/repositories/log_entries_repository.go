@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/caesar-rocks/orm"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// maxLogEntriesPerQuery caps the number of log entries returned by a search.
+	maxLogEntriesPerQuery = 1000
+
+	// MaxLogTextSearchPeriod is the longest period searched for a text. The messages aren't indexed, so the search
+	// scans all the entries of the period, found through the index on the application and timestamp.
+	MaxLogTextSearchPeriod = 24 * time.Hour
+)
+
+// ErrLogTextSearchPeriodTooLong is returned when searching for a text over more than MaxLogTextSearchPeriod.
+var ErrLogTextSearchPeriodTooLong = errors.New("text searches cover 24 hours at most: search since a more recent time, or until an earlier one")
+
+type LogEntriesRepository struct {
+	*orm.Repository[models.LogEntry]
+}
+
+func NewLogEntriesRepository(db *orm.Database) *LogEntriesRepository {
+	return &LogEntriesRepository{Repository: &orm.Repository[models.LogEntry]{
+		Database: db,
+	}}
+}
+
+// LogEntriesQuery filters the log entries of an application. Zero values don't filter.
+type LogEntriesQuery struct {
+	ApplicationID string
+	DeploymentID  string
+	Source        models.LogEntrySource
+	Process       string
+	Stream        models.LogEntryStream
+	Since         time.Time
+	Until         time.Time
+
+	// Text is searched for in the messages, case-insensitively. The period from Since to Until (or now)
+	// must not be longer than MaxLogTextSearchPeriod.
+	Text string
+
+	// Level selects the structured lines of this level or above.
+	Level models.LogLevel
+
+	Limit int
+}
+
+// Search returns the most recent log entries matching the query, in chronological order.
+func (r *LogEntriesRepository) Search(ctx context.Context, query LogEntriesQuery) ([]models.LogEntry, error) {
+	var items []models.LogEntry = make([]models.LogEntry, 0)
+
+	limit := query.Limit
+	if limit <= 0 || limit > maxLogEntriesPerQuery {
+		limit = maxLogEntriesPerQuery
+	}
+
+	q := r.NewSelect().Model((*models.LogEntry)(nil)).Where("application_id = ?", query.ApplicationID)
+	if query.DeploymentID != "" {
+		q = q.Where("deployment_id = ?", query.DeploymentID)
+	}
+	if query.Source != "" {
+		q = q.Where("source = ?", query.Source)
+	}
+	if query.Process != "" {
+		q = q.Where("process = ?", query.Process)
+	}
+	if query.Stream != "" {
+		q = q.Where("stream = ?", query.Stream)
+	}
+	if query.Level != "" {
+		q = q.Where("level IN (?)", bun.In(query.Level.AtLeast()))
+	}
+	if query.Text != "" {
+		until := query.Until
+		if until.IsZero() {
+			until = time.Now()
+		}
+		if query.Since.Before(until.Add(-MaxLogTextSearchPeriod)) {
+			return nil, ErrLogTextSearchPeriodTooLong
+		}
+	}
+	if !query.Since.IsZero() {
+		q = q.Where("timestamp >= ?", query.Since)
+	}
+	if !query.Until.IsZero() {
+		q = q.Where("timestamp <= ?", query.Until)
+	}
+	if query.Text != "" {
+		q = q.Where("LOWER(message) LIKE ? ESCAPE '\\'", "%"+escapeLikePattern(strings.ToLower(query.Text))+"%")
+	}
+
+	err := q.Order("timestamp DESC").Limit(limit).Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	// The most recent entries are selected, but read from the oldest.
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+
+	return items, nil
+}
+
+// FindAllFromDeployment returns all the log entries of a deployment from a given source, in chronological order.
+func (r *LogEntriesRepository) FindAllFromDeployment(ctx context.Context, deploymentId string, source models.LogEntrySource) ([]models.LogEntry, error) {
+	var items []models.LogEntry = make([]models.LogEntry, 0)
+
+	err := r.NewSelect().
+		Model((*models.LogEntry)(nil)).
+		Where("deployment_id = ?", deploymentId).
+		Where("source = ?", source).
+		Order("timestamp ASC").
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// CreateMany inserts a batch of log entries.
+func (r *LogEntriesRepository) CreateMany(ctx context.Context, entries []models.LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	_, err := r.NewInsert().Model(&entries).Exec(ctx)
+	return err
+}
+
+// FindLatestTimestampOfInstance returns the timestamp of the last entry collected from a container,
+// or the zero time if there's none.
+func (r *LogEntriesRepository) FindLatestTimestampOfInstance(ctx context.Context, instance string) (time.Time, error) {
+	var items []models.LogEntry = make([]models.LogEntry, 0)
+
+	err := r.NewSelect().Model((*models.LogEntry)(nil)).Where("instance = ?", instance).Order("timestamp DESC").Limit(1).Scan(ctx, &items)
+	if err != nil || len(items) == 0 {
+		return time.Time{}, err
+	}
+
+	return items[0].Timestamp, nil
+}
+
+// DeleteOlderThanFromOrganization deletes the log entries of an organization's applications older than a given time.
+func (r *LogEntriesRepository) DeleteOlderThanFromOrganization(ctx context.Context, orgId string, before time.Time) error {
+	_, err := r.NewDelete().
+		Model((*models.LogEntry)(nil)).
+		Where("application_id IN (?)", r.NewSelect().Model((*models.Application)(nil)).Column("id").Where("organization_id = ?", orgId)).
+		Where("timestamp < ?", before).
+		Exec(ctx)
+	return err
+}
+
+// DeleteOrphaned deletes the log entries of the applications that no longer exist.
+func (r *LogEntriesRepository) DeleteOrphaned(ctx context.Context) error {
+	_, err := r.NewDelete().
+		Model((*models.LogEntry)(nil)).
+		Where("application_id NOT IN (?)", r.NewSelect().Model((*models.Application)(nil)).Column("id")).
+		Exec(ctx)
+	return err
+}
+
+// escapeLikePattern escapes the wildcards of a LIKE pattern, so that they're matched literally.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
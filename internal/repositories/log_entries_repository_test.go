@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"citadel/database"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caesar-rocks/orm"
+)
+
+// newTestLogEntriesRepository returns a repository whose log entries are stored in a SQLite database of its own.
+func newTestLogEntriesRepository(t *testing.T) *LogEntriesRepository {
+	t.Helper()
+
+	db := orm.NewDatabase(&orm.DatabaseConfig{
+		DBMS: orm.DBMS("sqlite"),
+		DSN:  filepath.Join(t.TempDir(), "citadel.sqlite"),
+	})
+	db.Migrate(database.GetMigrations())
+
+	return NewLogEntriesRepository(db)
+}
+
+func TestSearchRejectsTextSearchesOverLongPeriods(t *testing.T) {
+	r := newTestLogEntriesRepository(t)
+	ctx := context.Background()
+	until := time.Date(2024, 7, 2, 12, 0, 0, 0, time.UTC)
+
+	for _, since := range []time.Time{{}, until.Add(-MaxLogTextSearchPeriod - time.Minute)} {
+		_, err := r.Search(ctx, LogEntriesQuery{ApplicationID: "app1", Text: "error", Since: since, Until: until})
+		if !errors.Is(err, ErrLogTextSearchPeriodTooLong) {
+			t.Errorf("Search since %v: got %v, want ErrLogTextSearchPeriodTooLong", since, err)
+		}
+	}
+
+	if _, err := r.Search(ctx, LogEntriesQuery{ApplicationID: "app1", Text: "error", Since: until.Add(-MaxLogTextSearchPeriod), Until: until}); err != nil {
+		t.Errorf("Search over MaxLogTextSearchPeriod: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/caesar-rocks/orm"
+)
+
+type MetricSamplesRepository struct {
+	*orm.Repository[models.MetricSample]
+}
+
+func NewMetricSamplesRepository(db *orm.Database) *MetricSamplesRepository {
+	return &MetricSamplesRepository{Repository: &orm.Repository[models.MetricSample]{
+		Database: db,
+	}}
+}
+
+// MetricSamplesQuery selects the samples of a resource at a resolution, to be aggregated into periods
+// of Step seconds, a multiple of the resolution. An empty process selects all of them.
+type MetricSamplesQuery struct {
+	ResourceType models.MetricResourceType
+	ResourceID   string
+	Process      string
+	Resolution   int
+	Step         int
+	Since        time.Time
+	Until        time.Time
+}
+
+// Aggregate returns the usage of a resource over the periods of the query, in chronological order.
+// The usage of the process types is summed up, and then averaged over each period, except for the network
+// traffic and the restarts, which are summed up over it.
+func (r *MetricSamplesRepository) Aggregate(ctx context.Context, query MetricSamplesQuery) ([]models.MetricSample, error) {
+	var samples []models.MetricSample = make([]models.MetricSample, 0)
+
+	selectQuery := r.NewSelect().
+		Model((*models.MetricSample)(nil)).
+		ColumnExpr("timestamp").
+		ColumnExpr("SUM(instances) AS instances").
+		ColumnExpr("SUM(cpu_percent) AS cpu_percent").
+		ColumnExpr("SUM(memory_usage) AS memory_usage").
+		ColumnExpr("SUM(memory_limit) AS memory_limit").
+		ColumnExpr("SUM(network_rx) AS network_rx").
+		ColumnExpr("SUM(network_tx) AS network_tx").
+		ColumnExpr("SUM(restarts) AS restarts").
+		Where("resource_type = ?", query.ResourceType).
+		Where("resource_id = ?", query.ResourceID).
+		Where("resolution = ?", query.Resolution).
+		Where("timestamp >= ?", query.Since).
+		Where("timestamp < ?", query.Until).
+		Group("timestamp").
+		Order("timestamp ASC")
+	if query.Process != "" {
+		selectQuery = selectQuery.Where("process = ?", query.Process)
+	}
+	if err := selectQuery.Scan(ctx, &samples); err != nil {
+		return nil, err
+	}
+
+	items := aggregateMetricSamples(samples, query.Step)
+	for i := range items {
+		items[i].ResourceType = query.ResourceType
+		items[i].ResourceID = query.ResourceID
+		items[i].Process = query.Process
+	}
+
+	return items, nil
+}
+
+// CreateMany inserts a batch of samples.
+func (r *MetricSamplesRepository) CreateMany(ctx context.Context, samples []models.MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	_, err := r.NewInsert().Model(&samples).On("CONFLICT DO NOTHING").Exec(ctx)
+	return err
+}
+
+// Downsample aggregates the samples of a resolution into samples of a coarser one, for the periods
+// ending before a given time that haven't been downsampled yet.
+func (r *MetricSamplesRepository) Downsample(ctx context.Context, from int, to int, until time.Time) error {
+	var latest models.MetricSample
+	err := r.NewSelect().
+		Model(&latest).
+		Where("resolution = ?", to).
+		Order("timestamp DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	since := time.Time{}
+	if err == nil {
+		since = latest.Timestamp.Add(time.Duration(to) * time.Second)
+	}
+	until = metricPeriod(until, to)
+
+	var samples []models.MetricSample = make([]models.MetricSample, 0)
+	err = r.NewSelect().
+		Model(&samples).
+		Where("resolution = ?", from).
+		Where("timestamp >= ?", since).
+		Where("timestamp < ?", until).
+		Order("timestamp ASC").
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.CreateMany(ctx, aggregateMetricSamples(samples, to))
+}
+
+// aggregateMetricSamples aggregates samples into samples of the periods of step seconds they fall in, by resource
+// and process type, in the order of their first sample. The usage is averaged over each period, except for
+// the network traffic and the restarts, which are summed up over it. The SQL of the databases Citadel runs on
+// differ too much on dates for it to be done by them.
+func aggregateMetricSamples(samples []models.MetricSample, step int) []models.MetricSample {
+	type period struct {
+		sample models.MetricSample
+		count  int
+	}
+
+	periods := map[models.MetricSample]*period{}
+	var order []models.MetricSample
+	for _, sample := range samples {
+		key := models.MetricSample{
+			ResourceType: sample.ResourceType,
+			ResourceID:   sample.ResourceID,
+			Process:      sample.Process,
+			Resolution:   step,
+			Timestamp:    metricPeriod(sample.Timestamp, step),
+		}
+
+		p, ok := periods[key]
+		if !ok {
+			p = &period{sample: key}
+			periods[key] = p
+			order = append(order, key)
+		}
+		p.count++
+		p.sample.Instances += sample.Instances
+		p.sample.CPUPercent += sample.CPUPercent
+		p.sample.MemoryUsage += sample.MemoryUsage
+		p.sample.MemoryLimit += sample.MemoryLimit
+		p.sample.NetworkRx += sample.NetworkRx
+		p.sample.NetworkTx += sample.NetworkTx
+		p.sample.Restarts += sample.Restarts
+	}
+
+	items := make([]models.MetricSample, 0, len(order))
+	for _, key := range order {
+		p := periods[key]
+		count := float64(p.count)
+		p.sample.Instances = int(math.Round(float64(p.sample.Instances) / count))
+		p.sample.CPUPercent /= count
+		p.sample.MemoryUsage = int64(math.Round(float64(p.sample.MemoryUsage) / count))
+		p.sample.MemoryLimit = int64(math.Round(float64(p.sample.MemoryLimit) / count))
+		items = append(items, p.sample)
+	}
+
+	return items
+}
+
+// metricPeriod returns the start of the period of step seconds a time falls in, counted from the Unix epoch.
+func metricPeriod(t time.Time, step int) time.Time {
+	seconds := t.Unix()
+	return time.Unix(seconds-seconds%int64(step), 0).UTC()
+}
+
+// DeleteOlderThan deletes the samples of a resolution older than a given time.
+func (r *MetricSamplesRepository) DeleteOlderThan(ctx context.Context, resolution int, before time.Time) error {
+	_, err := r.NewDelete().
+		Model((*models.MetricSample)(nil)).
+		Where("resolution = ?", resolution).
+		Where("timestamp < ?", before).
+		Exec(ctx)
+	return err
+}
+
+// DeleteOrphaned deletes the samples of the applications and databases that no longer exist.
+func (r *MetricSamplesRepository) DeleteOrphaned(ctx context.Context) error {
+	_, err := r.NewDelete().
+		Model((*models.MetricSample)(nil)).
+		Where("resource_type = ?", models.MetricResourceApplication).
+		Where("resource_id NOT IN (?)", r.NewSelect().Model((*models.Application)(nil)).Column("id")).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.NewDelete().
+		Model((*models.MetricSample)(nil)).
+		Where("resource_type = ?", models.MetricResourceDatabase).
+		Where("resource_id NOT IN (?)", r.NewSelect().Model((*models.Database)(nil)).Column("id")).
+		Exec(ctx)
+	return err
+}
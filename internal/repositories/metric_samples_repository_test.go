@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"citadel/database"
+	"citadel/internal/models"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caesar-rocks/orm"
+)
+
+// newTestMetricSamplesRepository returns a repository whose samples are stored in a SQLite database of its own.
+func newTestMetricSamplesRepository(t *testing.T) *MetricSamplesRepository {
+	t.Helper()
+
+	db := orm.NewDatabase(&orm.DatabaseConfig{
+		DBMS: orm.DBMS("sqlite"),
+		DSN:  filepath.Join(t.TempDir(), "citadel.sqlite"),
+	})
+	db.Migrate(database.GetMigrations())
+
+	return NewMetricSamplesRepository(db)
+}
+
+// rawSamples returns a sample of the web process of an application every raw resolution period
+// from a given time, with a CPU usage going up by 10% each time.
+func rawSamples(start time.Time, count int) []models.MetricSample {
+	samples := make([]models.MetricSample, 0, count)
+	for i := 0; i < count; i++ {
+		samples = append(samples, models.MetricSample{
+			ResourceType: models.MetricResourceApplication,
+			ResourceID:   "app1",
+			Process:      models.ProcessTypeWeb,
+			Resolution:   models.MetricResolutionRaw,
+			Timestamp:    start.Add(time.Duration(i*models.MetricResolutionRaw) * time.Second),
+			Instances:    1,
+			CPUPercent:   float64(i * 10),
+			MemoryUsage:  100,
+			MemoryLimit:  1000,
+			NetworkRx:    10,
+			NetworkTx:    1,
+			Restarts:     i % 2,
+		})
+	}
+	return samples
+}
+
+func TestAggregateAveragesTheUsageOverEachPeriod(t *testing.T) {
+	r := newTestMetricSamplesRepository(t)
+	ctx := context.Background()
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	// Two periods of a minute, of two samples each.
+	if err := r.CreateMany(ctx, rawSamples(start, 4)); err != nil {
+		t.Fatalf("CreateMany: %v", err)
+	}
+
+	items, err := r.Aggregate(ctx, MetricSamplesQuery{
+		ResourceType: models.MetricResourceApplication,
+		ResourceID:   "app1",
+		Resolution:   models.MetricResolutionRaw,
+		Step:         60,
+		Since:        start,
+		Until:        start.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("got %d periods, want 2", len(items))
+	}
+	for i, want := range []float64{5, 25} {
+		item := items[i]
+		if !item.Timestamp.Equal(start.Add(time.Duration(i) * time.Minute)) {
+			t.Errorf("period %d starts at %s", i, item.Timestamp)
+		}
+		if item.CPUPercent != want {
+			t.Errorf("period %d: CPU is %v, want the average %v", i, item.CPUPercent, want)
+		}
+		if item.MemoryUsage != 100 || item.Instances != 1 {
+			t.Errorf("period %d: got %d instances using %d bytes, want the averages", i, item.Instances, item.MemoryUsage)
+		}
+		if item.NetworkRx != 20 || item.Restarts != 1 {
+			t.Errorf("period %d: got %d bytes received and %d restarts, want the sums", i, item.NetworkRx, item.Restarts)
+		}
+		if item.Resolution != 60 {
+			t.Errorf("period %d lasts %d seconds, want 60", i, item.Resolution)
+		}
+	}
+}
+
+func TestDownsampleAggregatesTheEndedPeriodsOnce(t *testing.T) {
+	r := newTestMetricSamplesRepository(t)
+	ctx := context.Background()
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	minute := models.MetricResolutionMinute
+
+	// Two periods of five minutes, the second of which is still going on.
+	if err := r.CreateMany(ctx, rawSamples(start, 15)); err != nil {
+		t.Fatalf("CreateMany: %v", err)
+	}
+	now := start.Add(7 * time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if err := r.Downsample(ctx, models.MetricResolutionRaw, minute, now); err != nil {
+			t.Fatalf("Downsample: %v", err)
+		}
+	}
+
+	items, err := r.Aggregate(ctx, MetricSamplesQuery{
+		ResourceType: models.MetricResourceApplication,
+		ResourceID:   "app1",
+		Resolution:   minute,
+		Step:         minute,
+		Since:        start,
+		Until:        now,
+	})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+
+	if len(items) != 1 {
+		t.Fatalf("got %d downsampled periods, want only the ended one", len(items))
+	}
+	if !items[0].Timestamp.Equal(start) {
+		t.Errorf("the downsampled period starts at %s, want %s", items[0].Timestamp, start)
+	}
+	if items[0].CPUPercent != 45 || items[0].NetworkRx != 100 || items[0].Restarts != 5 {
+		t.Errorf("got %v%% CPU, %d bytes received and %d restarts, want 45%%, 100 and 5", items[0].CPUPercent, items[0].NetworkRx, items[0].Restarts)
+	}
+}
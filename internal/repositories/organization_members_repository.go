@@ -30,3 +30,13 @@ func (r *OrganizationMembersRepository) FindAllFromOrganizationWithUser(ctx cont
 
 	return members, nil
 }
+
+// IsMember reports whether a user is a member of an organization, whatever their role.
+func (r *OrganizationMembersRepository) IsMember(ctx context.Context, orgID string, userID string) (bool, error) {
+	return r.
+		NewSelect().
+		Model((*models.OrganizationMember)(nil)).
+		Where("organization_id = ?", orgID).
+		Where("user_id = ?", userID).
+		Exists(ctx)
+}
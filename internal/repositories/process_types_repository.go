@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/caesar-rocks/orm"
+)
+
+type ProcessTypesRepository struct {
+	*orm.Repository[models.ProcessType]
+}
+
+func NewProcessTypesRepository(db *orm.Database) *ProcessTypesRepository {
+	return &ProcessTypesRepository{Repository: &orm.Repository[models.ProcessType]{
+		Database: db,
+	}}
+}
+
+func (r *ProcessTypesRepository) FindAllFromApplication(ctx context.Context, appId string) ([]models.ProcessType, error) {
+	var items []models.ProcessType = make([]models.ProcessType, 0)
+
+	err := r.NewSelect().Model((*models.ProcessType)(nil)).Where("application_id = ?", appId).Order("name ASC").Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindOneFromApplication returns the process type of an application with the given name, or nil if there is none.
+func (r *ProcessTypesRepository) FindOneFromApplication(ctx context.Context, appId string, name string) (*models.ProcessType, error) {
+	var item *models.ProcessType = new(models.ProcessType)
+
+	err := r.NewSelect().
+		Model(item).
+		Where("application_id = ?", appId).
+		Where("name = ?", name).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Declare brings the process types of an application in line with the ones declared by a new deployment:
+// the new ones are created with a single instance, the commands of the existing ones are updated,
+// and the ones that aren't declared anymore are removed. The web process type is the application itself.
+func (r *ProcessTypesRepository) Declare(ctx context.Context, appId string, declared map[string]string) error {
+	existing, err := r.FindAllFromApplication(ctx, appId)
+	if err != nil {
+		return err
+	}
+
+	for _, process := range existing {
+		command, stillDeclared := declared[process.Name]
+		if !stillDeclared {
+			if _, err := r.NewDelete().Model((*models.ProcessType)(nil)).Where("id = ?", process.ID).Exec(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		if command != process.Command {
+			process.Command = command
+			if err := r.UpdateOneWhere(ctx, &process, "id", process.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, command := range declared {
+		if name == models.ProcessTypeWeb || containsProcessType(existing, name) {
+			continue
+		}
+		process := &models.ProcessType{
+			Name:          name,
+			Command:       command,
+			Replicas:      1,
+			ApplicationID: appId,
+		}
+		if err := r.Create(ctx, process); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func containsProcessType(processes []models.ProcessType, name string) bool {
+	for _, process := range processes {
+		if process.Name == name {
+			return true
+		}
+	}
+	return false
+}
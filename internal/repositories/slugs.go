@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+
+	"github.com/caesar-rocks/orm"
+)
+
+// slugTaken tells whether an application or a database already has a slug. Their hostnames on the private
+// networks of the organizations are made of their slugs, so an application and a database never share one.
+func slugTaken(ctx context.Context, db *orm.Database, slug string) (bool, error) {
+	for _, model := range []any{(*models.Application)(nil), (*models.Database)(nil)} {
+		exists, err := db.NewSelect().Model(model).Where("slug = ?", slug).Exists(ctx)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
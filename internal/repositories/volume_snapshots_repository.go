@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+	"time"
+
+	"github.com/caesar-rocks/orm"
+)
+
+type VolumeSnapshotsRepository struct {
+	*orm.Repository[models.VolumeSnapshot]
+}
+
+func NewVolumeSnapshotsRepository(db *orm.Database) *VolumeSnapshotsRepository {
+	return &VolumeSnapshotsRepository{Repository: &orm.Repository[models.VolumeSnapshot]{
+		Database: db,
+	}}
+}
+
+// FindAllFromVolume returns the snapshots of a volume, from the most recent.
+func (r *VolumeSnapshotsRepository) FindAllFromVolume(ctx context.Context, volumeId string) ([]models.VolumeSnapshot, error) {
+	var items []models.VolumeSnapshot = make([]models.VolumeSnapshot, 0)
+
+	err := r.NewSelect().
+		Model((*models.VolumeSnapshot)(nil)).
+		Where("volume_id = ?", volumeId).
+		Order("created_at DESC").
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FailAllPending marks the snapshots still being taken as failed, as they can't be waited for anymore
+// (e.g. when Citadel restarted in the middle of them).
+func (r *VolumeSnapshotsRepository) FailAllPending(ctx context.Context) error {
+	_, err := r.NewUpdate().
+		Model((*models.VolumeSnapshot)(nil)).
+		Set("status = ?", models.VolumeSnapshotStatusFailed).
+		Set("updated_at = ?", time.Now()).
+		Where("status = ?", models.VolumeSnapshotStatusPending).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"citadel/internal/models"
+	"context"
+	"time"
+
+	"github.com/Squwid/go-randomizer"
+	"github.com/caesar-rocks/orm"
+	"github.com/gosimple/slug"
+)
+
+type VolumesRepository struct {
+	*orm.Repository[models.Volume]
+}
+
+func NewVolumesRepository(db *orm.Database) *VolumesRepository {
+	return &VolumesRepository{Repository: &orm.Repository[models.Volume]{
+		Database: db,
+	}}
+}
+
+func (r *VolumesRepository) Create(ctx context.Context, volume *models.Volume) error {
+	slug := slug.Make(volume.Name)
+
+	for {
+		_, err := r.FindOneBy(ctx, "slug", slug)
+		if err != nil {
+			break
+		}
+
+		slug = slug + "-" + randomizer.Noun()
+	}
+	volume.Slug = slug
+
+	_, err := r.NewInsert().Model(volume).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *VolumesRepository) FindAllFromOrg(ctx context.Context, orgId string) ([]models.Volume, error) {
+	var items []models.Volume = make([]models.Volume, 0)
+
+	err := r.NewSelect().
+		Model((*models.Volume)(nil)).
+		Relation("Application").
+		Where("volume.organization_id = ?", orgId).
+		Order("volume.name ASC").
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// FindAllFromApplication returns the volumes attached to an application, which are mounted in its containers.
+func (r *VolumesRepository) FindAllFromApplication(ctx context.Context, appId string) ([]models.Volume, error) {
+	var items []models.Volume = make([]models.Volume, 0)
+
+	err := r.NewSelect().
+		Model((*models.Volume)(nil)).
+		Where("application_id = ?", appId).
+		Order("mount_path ASC").
+		Scan(ctx, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Detach detaches a volume from the application it is attached to, if any.
+func (r *VolumesRepository) Detach(ctx context.Context, volumeId string) error {
+	_, err := r.NewUpdate().
+		Model((*models.Volume)(nil)).
+		Set("application_id = NULL").
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", volumeId).
+		Exec(ctx)
+	return err
+}
+
+// DetachAllFromApplication detaches the volumes attached to an application, e.g. when it is deleted.
+func (r *VolumesRepository) DetachAllFromApplication(ctx context.Context, appId string) error {
+	_, err := r.NewUpdate().
+		Model((*models.Volume)(nil)).
+		Set("application_id = NULL").
+		Set("updated_at = ?", time.Now()).
+		Where("application_id = ?", appId).
+		Exec(ctx)
+	return err
+}
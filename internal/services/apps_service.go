@@ -1,24 +1,47 @@
 package services
 
 import (
+	"citadel/internal/drivers"
 	"citadel/internal/models"
 	"citadel/internal/repositories"
+	"context"
+	"log/slog"
 
+	caesarAuth "github.com/caesar-rocks/auth"
 	caesar "github.com/caesar-rocks/core"
 	"github.com/caesar-rocks/events"
 )
 
 type AppsService struct {
-	usersRepo *repositories.UsersRepository
-	appsRepo  *repositories.ApplicationsRepository
-	emitter   *events.EventsEmitter
+	usersRepo      *repositories.UsersRepository
+	appsRepo       *repositories.ApplicationsRepository
+	deplsRepo      *repositories.DeploymentsRepository
+	orgMembersRepo *repositories.OrganizationMembersRepository
+	emitter        *events.EventsEmitter
+	driver         drivers.Driver
 }
 
-func NewAppsService(usersRepo *repositories.UsersRepository, appsRepo *repositories.ApplicationsRepository, emitter *events.EventsEmitter) *AppsService {
-	return &AppsService{usersRepo, appsRepo, emitter}
+func NewAppsService(usersRepo *repositories.UsersRepository, appsRepo *repositories.ApplicationsRepository, deplsRepo *repositories.DeploymentsRepository, orgMembersRepo *repositories.OrganizationMembersRepository, emitter *events.EventsEmitter, driver drivers.Driver) *AppsService {
+	return &AppsService{usersRepo, appsRepo, deplsRepo, orgMembersRepo, emitter, driver}
 }
 
+// GetAppOwnedByCurrentOrg returns the application of the "slug" path parameter, within the organization
+// of the "orgId" one. It fails with a 404 error if the current user isn't a member of the organization,
+// so that they can't tell which applications it has.
 func (s *AppsService) GetAppOwnedByCurrentOrg(ctx *caesar.Context) (*models.Application, error) {
+	user, err := caesarAuth.RetrieveUserFromCtx[models.User](ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	isMember, err := s.orgMembersRepo.IsMember(ctx.Context(), ctx.PathValue("orgId"), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, caesar.NewError(404)
+	}
+
 	app, err := s.appsRepo.FindOneBy(
 		ctx.Context(),
 		"slug", ctx.PathValue("slug"),
@@ -30,3 +53,45 @@ func (s *AppsService) GetAppOwnedByCurrentOrg(ctx *caesar.Context) (*models.Appl
 
 	return app, nil
 }
+
+// DeletePreviews deletes the previews of an application, e.g. when it is deleted itself.
+func (s *AppsService) DeletePreviews(ctx context.Context, app models.Application) error {
+	previews, err := s.appsRepo.FindAllPreviews(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, preview := range previews {
+		if err := s.DeletePreview(ctx, preview); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeletePreview cancels the deployments of a preview, and deletes it along with its service.
+func (s *AppsService) DeletePreview(ctx context.Context, preview models.Application) error {
+	depls, err := s.deplsRepo.FindAllFromApplication(ctx, preview.ID)
+	if err != nil {
+		return err
+	}
+	for _, depl := range depls {
+		if !depl.CanBeCanceled() {
+			continue
+		}
+		if err := s.driver.CancelDeployment(preview, depl); err != nil {
+			slog.Warn("Failed to cancel deployment of preview", "error", err, "deployment_id", depl.ID)
+		}
+	}
+
+	if err := s.driver.DeleteApplication(preview); err != nil {
+		return err
+	}
+	if err := s.appsRepo.DeleteOneWhere(ctx, "id", preview.ID); err != nil {
+		return err
+	}
+
+	slog.Info("Destroyed preview", "app_id", preview.ID, "pull_request", preview.PullRequestNumber)
+	return nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// ErrCronJobRunning is returned when running a cron job whose previous run hasn't finished yet.
+var ErrCronJobRunning = errors.New("cron job is already running")
+
+// CronScheduler runs the cron jobs of the applications when their schedule says so, from the image of
+// the current deployment of their application. A job never runs twice at once.
+type CronScheduler struct {
+	cronJobsRepo *repositories.CronJobsRepository
+	runsRepo     *repositories.CronJobRunsRepository
+	deplsRepo    *repositories.DeploymentsRepository
+	driver       drivers.Driver
+
+	// running holds the IDs of the cron jobs being run.
+	running sync.Map
+}
+
+func NewCronScheduler(cronJobsRepo *repositories.CronJobsRepository, runsRepo *repositories.CronJobRunsRepository, deplsRepo *repositories.DeploymentsRepository, driver drivers.Driver) *CronScheduler {
+	return &CronScheduler{cronJobsRepo: cronJobsRepo, runsRepo: runsRepo, deplsRepo: deplsRepo, driver: driver}
+}
+
+// RunPeriodically runs the cron jobs due at the start of every minute, forever.
+// The runs interrupted by a restart are marked as failed first.
+func (s *CronScheduler) RunPeriodically() {
+	if interrupted, err := s.runsRepo.FailAllRunning(context.Background()); err != nil {
+		slog.Error("Failed to mark the interrupted cron job runs as failed", "error", err)
+	} else if interrupted > 0 {
+		slog.Info("Marked the interrupted cron job runs as failed", "count", interrupted)
+	}
+
+	for {
+		tick := time.Now().Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(time.Until(tick))
+
+		if err := s.RunDue(context.Background(), tick); err != nil {
+			slog.Error("Failed to run the cron jobs due", "error", err)
+		}
+	}
+}
+
+// RunDue starts the runs of the cron jobs scheduled at the given minute.
+func (s *CronScheduler) RunDue(ctx context.Context, tick time.Time) error {
+	jobs, err := s.cronJobsRepo.FindAllWithRelatedApp(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if job.Application == nil {
+			continue
+		}
+
+		next, err := job.NextRun(tick.Add(-time.Second))
+		if err != nil {
+			slog.Error("Failed to schedule cron job", "error", err, "cron_job_id", job.ID)
+			continue
+		}
+		if next.After(tick) {
+			continue
+		}
+
+		if _, err := s.Run(ctx, job); err != nil {
+			slog.Warn("Failed to run cron job", "error", err, "cron_job_id", job.ID, "app_id", job.ApplicationID)
+		}
+	}
+
+	return nil
+}
+
+// Run starts a run of a cron job, whose application must be loaded, and returns it.
+// The run goes on in the background, and is updated with its outcome once it finishes.
+func (s *CronScheduler) Run(ctx context.Context, job models.CronJob) (*models.CronJobRun, error) {
+	if _, running := s.running.LoadOrStore(job.ID, struct{}{}); running {
+		return nil, ErrCronJobRunning
+	}
+
+	run, depl, err := s.start(ctx, job)
+	if err != nil || depl == nil {
+		s.running.Delete(job.ID)
+		return run, err
+	}
+
+	go func() {
+		defer s.running.Delete(job.ID)
+		if err := s.complete(job, run, *depl); err != nil {
+			slog.Error("Failed to complete cron job run", "error", err, "cron_job_id", job.ID, "run_id", run.ID)
+		}
+	}()
+
+	return run, nil
+}
+
+// start records the start of a run, and returns the deployment it runs from. The runs of the applications
+// without a successful deployment fail straight away, and no deployment is returned.
+func (s *CronScheduler) start(ctx context.Context, job models.CronJob) (*models.CronJobRun, *models.Deployment, error) {
+	depl, err := s.deplsRepo.FindLatestSuccessfulFromApplication(ctx, job.ApplicationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	run := &models.CronJobRun{
+		ID:        xid.New().String(),
+		CronJobID: job.ID,
+		Status:    models.CronJobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if depl == nil {
+		run.Status = models.CronJobRunStatusFailed
+		run.ExitCode = -1
+		run.Output = "The application has no successful deployment to run the job from.\n"
+		run.FinishedAt = run.StartedAt
+	} else {
+		run.DeploymentID = depl.ID
+	}
+
+	if err := s.runsRepo.Create(ctx, run); err != nil {
+		return nil, nil, err
+	}
+
+	return run, depl, nil
+}
+
+// complete runs a job, and records the outcome of its run.
+func (s *CronScheduler) complete(job models.CronJob, run *models.CronJobRun, depl models.Deployment) error {
+	result, err := s.driver.RunJob(*job.Application, depl, drivers.JobOptions{
+		Name:    run.ID,
+		Cmd:     job.Command,
+		Timeout: job.GetTimeout(),
+	})
+
+	run.ExitCode = result.ExitCode
+	run.Output = result.Output
+	run.FinishedAt = time.Now()
+
+	switch {
+	case errors.Is(err, drivers.ErrJobTimedOut):
+		run.Status = models.CronJobRunStatusTimedOut
+		run.ExitCode = -1
+		run.Output += fmt.Sprintf("\nThe job was killed after running for %s.\n", job.GetTimeout())
+	case err != nil:
+		run.Status = models.CronJobRunStatusFailed
+		run.ExitCode = -1
+		run.Output += "\nThe job could not be run: " + err.Error() + "\n"
+	case result.ExitCode != 0:
+		run.Status = models.CronJobRunStatusFailed
+		run.Output += fmt.Sprintf("\nThe job exited with code %d.\n", result.ExitCode)
+	default:
+		run.Status = models.CronJobRunStatusSucceeded
+	}
+
+	slog.Info("Cron job run finished", "cron_job_id", job.ID, "run_id", run.ID, "status", run.Status, "exit_code", run.ExitCode)
+
+	return s.runsRepo.UpdateOneWhere(context.Background(), run, "id", run.ID)
+}
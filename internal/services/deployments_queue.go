@@ -0,0 +1,155 @@
+package services
+
+import (
+	"citadel/internal/drivers"
+	githubApp "citadel/internal/github_app"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// deploymentsQueueInterval is how often the queue is checked for deployments that can start,
+	// as the active ones complete asynchronously in the driver.
+	deploymentsQueueInterval = 3 * time.Second
+
+	// staleDeploymentTimeout is how long an active deployment can keep the same status before it stops
+	// holding up the queue of its application (e.g. when Citadel restarted in the middle of it).
+	staleDeploymentTimeout = time.Hour
+)
+
+// DeploymentsQueue starts the queued deployments, one at a time per application,
+// and no more builds at once than their organization allows.
+type DeploymentsQueue struct {
+	mu        sync.Mutex
+	deplsRepo *repositories.DeploymentsRepository
+	driver    drivers.Driver
+}
+
+func NewDeploymentsQueue(deplsRepo *repositories.DeploymentsRepository, driver drivers.Driver) *DeploymentsQueue {
+	return &DeploymentsQueue{deplsRepo: deplsRepo, driver: driver}
+}
+
+// Dispatch starts the latest queued deployment of each application that has no active one,
+// as long as its organization has a build slot to spare. The older queued deployments are superseded.
+func (q *DeploymentsQueue) Dispatch(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued, err := q.deplsRepo.FindAllQueued(ctx)
+	if err != nil {
+		return err
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+
+	active, err := q.deplsRepo.FindAllActiveUpdatedSince(ctx, time.Now().Add(-staleDeploymentTimeout))
+	if err != nil {
+		return err
+	}
+
+	busyApps := make(map[string]bool)
+	builds := make(map[string]int)
+	for _, depl := range active {
+		busyApps[depl.ApplicationID] = true
+		if depl.Status == models.DeploymentStatusBuilding && depl.Application != nil {
+			builds[depl.Application.OrganizationID]++
+		}
+	}
+
+	// The queued deployments are sorted from the oldest, so the last one of each application wins.
+	latest := make(map[string]string)
+	for _, depl := range queued {
+		latest[depl.ApplicationID] = depl.ID
+	}
+
+	for i := range queued {
+		depl := &queued[i]
+
+		if latest[depl.ApplicationID] != depl.ID {
+			if _, err := q.transition(ctx, depl, models.DeploymentStatusQueued, models.DeploymentStatusSuperseded); err != nil {
+				slog.Error("Failed to supersede deployment", "error", err, "deployment_id", depl.ID)
+			}
+			continue
+		}
+
+		if busyApps[depl.ApplicationID] {
+			continue
+		}
+
+		// Rollbacks reuse an image, and don't take a build slot.
+		isBuild := depl.Origin != models.DeploymentOriginRollback
+		org := depl.Application.Organization
+		if isBuild && org != nil && builds[org.ID] >= org.GetMaxConcurrentBuilds() {
+			continue
+		}
+
+		if err := q.start(ctx, depl); err != nil {
+			slog.Error("Failed to start deployment", "error", err, "deployment_id", depl.ID)
+			continue
+		}
+
+		busyApps[depl.ApplicationID] = true
+		if isBuild {
+			builds[depl.Application.OrganizationID]++
+		}
+	}
+
+	return nil
+}
+
+// DispatchPeriodically runs Dispatch every deploymentsQueueInterval, forever.
+func (q *DeploymentsQueue) DispatchPeriodically() {
+	for {
+		if err := q.Dispatch(context.Background()); err != nil {
+			slog.Error("Failed to dispatch the queued deployments", "error", err)
+		}
+		time.Sleep(deploymentsQueueInterval)
+	}
+}
+
+// start builds a queued deployment, or rolls it out straight away if it is a rollback.
+func (q *DeploymentsQueue) start(ctx context.Context, depl *models.Deployment) error {
+	status, failedStatus := models.DeploymentStatusBuilding, models.DeploymentStatusBuildFailed
+	ignite := q.driver.IgniteBuilder
+	if depl.Origin == models.DeploymentOriginRollback {
+		status, failedStatus = models.DeploymentStatusDeploying, models.DeploymentStatusDeployFailed
+		ignite = q.driver.IgniteApplication
+	}
+
+	// The deployment may have been canceled in the meantime.
+	started, err := q.transition(ctx, depl, models.DeploymentStatusQueued, status)
+	if err != nil || !started {
+		return err
+	}
+
+	if err := ignite(*depl.Application, *depl); err != nil {
+		if _, err := q.transition(ctx, depl, status, failedStatus); err != nil {
+			slog.Error("Failed to update deployment status", "error", err, "deployment_id", depl.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// transition changes the status of a deployment if it still is the given one,
+// and reports it on the GitHub commit the deployment was triggered by, if any.
+func (q *DeploymentsQueue) transition(ctx context.Context, depl *models.Deployment, from models.DeploymentStatus, to models.DeploymentStatus) (bool, error) {
+	ok, err := q.deplsRepo.TransitionStatus(ctx, depl, from, to)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	if depl.GitHubCheckRunID != 0 && depl.Application != nil {
+		if err := githubApp.UpdateCheckRun(ctx, *depl.Application, *depl); err != nil {
+			slog.Warn("Failed to update GitHub check run", "error", err, "deployment_id", depl.ID)
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"citadel/internal/repositories"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// logRetentionInterval is how often the logs past the retention period of their organization are deleted.
+const logRetentionInterval = time.Hour
+
+type LogsService struct {
+	orgsRepo *repositories.OrganizationsRepository
+	logsRepo *repositories.LogEntriesRepository
+}
+
+func NewLogsService(orgsRepo *repositories.OrganizationsRepository, logsRepo *repositories.LogEntriesRepository) *LogsService {
+	return &LogsService{orgsRepo, logsRepo}
+}
+
+// EnforceRetention deletes the log entries older than the retention period of their organization,
+// along with the ones of the deleted applications.
+func (s *LogsService) EnforceRetention(ctx context.Context) error {
+	orgs, err := s.orgsRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		cutoff := time.Now().AddDate(0, 0, -org.GetLogRetentionDays())
+		if err := s.logsRepo.DeleteOlderThanFromOrganization(ctx, org.ID, cutoff); err != nil {
+			return err
+		}
+	}
+
+	return s.logsRepo.DeleteOrphaned(ctx)
+}
+
+// EnforceRetentionPeriodically runs EnforceRetention every logRetentionInterval, forever.
+func (s *LogsService) EnforceRetentionPeriodically() {
+	for {
+		if err := s.EnforceRetention(context.Background()); err != nil {
+			slog.Error("Failed to enforce the log retention", "error", err)
+		}
+		time.Sleep(logRetentionInterval)
+	}
+}
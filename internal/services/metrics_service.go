@@ -0,0 +1,51 @@
+package services
+
+import (
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// metricsMaintenanceInterval is how often the metric samples are downsampled and purged.
+const metricsMaintenanceInterval = 5 * time.Minute
+
+type MetricsService struct {
+	metricsRepo *repositories.MetricSamplesRepository
+}
+
+func NewMetricsService(metricsRepo *repositories.MetricSamplesRepository) *MetricsService {
+	return &MetricsService{metricsRepo}
+}
+
+// Maintain downsamples the metric samples to the coarser resolutions, and deletes the ones older than the retention
+// period of their resolution, along with the ones of the deleted applications and databases.
+func (s *MetricsService) Maintain(ctx context.Context) error {
+	now := time.Now()
+
+	if err := s.metricsRepo.Downsample(ctx, models.MetricResolutionRaw, models.MetricResolutionMinute, now); err != nil {
+		return err
+	}
+	if err := s.metricsRepo.Downsample(ctx, models.MetricResolutionMinute, models.MetricResolutionHour, now); err != nil {
+		return err
+	}
+
+	for resolution, retention := range models.MetricRetentions {
+		if err := s.metricsRepo.DeleteOlderThan(ctx, resolution, now.Add(-retention)); err != nil {
+			return err
+		}
+	}
+
+	return s.metricsRepo.DeleteOrphaned(ctx)
+}
+
+// MaintainPeriodically runs Maintain every metricsMaintenanceInterval, forever.
+func (s *MetricsService) MaintainPeriodically() {
+	for {
+		if err := s.Maintain(context.Background()); err != nil {
+			slog.Error("Failed to maintain the metric samples", "error", err)
+		}
+		time.Sleep(metricsMaintenanceInterval)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"citadel/internal/models"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// maxProcessesConfigSize is the size, in bytes, beyond which the citadel.toml and Procfile files aren't read.
+const maxProcessesConfigSize = 64 * 1024
+
+// ReadDeclaredProcessTypes returns the process types declared in the source code of a deployment, given as a
+// gzipped tarball, by name. They are read from the "processes" table of its citadel.toml, or else from its Procfile.
+// The tarballs of GitHub commits hold the source code in a top-level directory, which is looked into instead.
+// It returns nil if none is declared.
+func ReadDeclaredProcessTypes(tarball []byte) (map[string]string, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	files := map[string][]byte{}
+	topDirs := map[string]bool{}
+	hasRootFiles := false
+
+	reader := tar.NewReader(gzipReader)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean(header.Name), "/")
+		if top, _, nested := strings.Cut(name, "/"); nested {
+			topDirs[top] = true
+		} else if header.Typeflag != tar.TypeDir {
+			hasRootFiles = true
+		}
+
+		base := path.Base(name)
+		if header.Typeflag != tar.TypeReg || strings.Count(name, "/") > 1 || (base != "citadel.toml" && base != "Procfile") {
+			continue
+		}
+		if header.Size > maxProcessesConfigSize {
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+		if files[name], err = io.ReadAll(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	root := ""
+	if !hasRootFiles && len(topDirs) == 1 {
+		for dir := range topDirs {
+			root = dir + "/"
+		}
+	}
+
+	if content, ok := files[root+"citadel.toml"]; ok {
+		var config struct {
+			Processes map[string]string `toml:"processes"`
+		}
+		if err := toml.Unmarshal(content, &config); err != nil {
+			return nil, fmt.Errorf("invalid citadel.toml: %w", err)
+		}
+		if len(config.Processes) > 0 {
+			for name, command := range config.Processes {
+				if err := models.ValidateProcessTypeName(name); err != nil {
+					return nil, err
+				}
+				if strings.TrimSpace(command) == "" {
+					return nil, fmt.Errorf("the process type %q has no command", name)
+				}
+			}
+			return config.Processes, nil
+		}
+	}
+
+	if content, ok := files[root+"Procfile"]; ok {
+		processes, err := models.ParseProcfile(content)
+		if err != nil || len(processes) == 0 {
+			return nil, err
+		}
+		return processes, nil
+	}
+
+	return nil, nil
+}
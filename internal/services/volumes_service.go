@@ -0,0 +1,124 @@
+package services
+
+import (
+	"citadel/internal/drivers"
+	"citadel/internal/models"
+	"citadel/internal/repositories"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// ErrVolumeBusy is returned when snapshotting, restoring or deleting a volume while it is being snapshotted or restored.
+var ErrVolumeBusy = errors.New("volume is being snapshotted or restored")
+
+// VolumesService takes the snapshots of the volumes and restores them. A volume is never snapshotted
+// and restored at once.
+type VolumesService struct {
+	volumesRepo   *repositories.VolumesRepository
+	snapshotsRepo *repositories.VolumeSnapshotsRepository
+	driver        drivers.Driver
+
+	// busy holds the IDs of the volumes being snapshotted or restored.
+	busy sync.Map
+}
+
+func NewVolumesService(volumesRepo *repositories.VolumesRepository, snapshotsRepo *repositories.VolumeSnapshotsRepository, driver drivers.Driver) *VolumesService {
+	return &VolumesService{volumesRepo: volumesRepo, snapshotsRepo: snapshotsRepo, driver: driver}
+}
+
+// FailInterruptedSnapshots marks the snapshots interrupted by a restart as failed.
+func (s *VolumesService) FailInterruptedSnapshots() {
+	if err := s.snapshotsRepo.FailAllPending(context.Background()); err != nil {
+		slog.Error("Failed to mark the interrupted volume snapshots as failed", "error", err)
+	}
+}
+
+// Snapshot starts a snapshot of a volume, and returns it. The snapshot goes on in the background,
+// and is updated with its outcome once it finishes.
+func (s *VolumesService) Snapshot(ctx context.Context, volume models.Volume) (*models.VolumeSnapshot, error) {
+	if _, busy := s.busy.LoadOrStore(volume.ID, struct{}{}); busy {
+		return nil, ErrVolumeBusy
+	}
+
+	snapshot := &models.VolumeSnapshot{VolumeID: volume.ID, Status: models.VolumeSnapshotStatusPending}
+	if err := s.snapshotsRepo.Create(ctx, snapshot); err != nil {
+		s.busy.Delete(volume.ID)
+		return nil, err
+	}
+
+	go func() {
+		defer s.busy.Delete(volume.ID)
+		if err := s.complete(volume, snapshot); err != nil {
+			slog.Error("Failed to complete volume snapshot", "error", err, "volume_id", volume.ID, "snapshot_id", snapshot.ID)
+		}
+	}()
+
+	return snapshot, nil
+}
+
+// complete copies the content of a volume, and records the outcome of its snapshot.
+func (s *VolumesService) complete(volume models.Volume, snapshot *models.VolumeSnapshot) error {
+	size, err := s.driver.SnapshotVolume(volume, *snapshot)
+	if err != nil {
+		slog.Warn("Failed to snapshot volume", "error", err, "volume_id", volume.ID, "snapshot_id", snapshot.ID)
+		snapshot.Status = models.VolumeSnapshotStatusFailed
+	} else {
+		snapshot.Status = models.VolumeSnapshotStatusCompleted
+		snapshot.Size = size
+	}
+
+	return s.snapshotsRepo.UpdateOneWhere(context.Background(), snapshot, "id", snapshot.ID)
+}
+
+// Restore starts replacing the content of a volume with the one of a completed snapshot. The restore goes on
+// in the background, the instances the volume is mounted in being stopped until it is done.
+func (s *VolumesService) Restore(volume models.Volume, snapshot models.VolumeSnapshot) error {
+	if _, busy := s.busy.LoadOrStore(volume.ID, struct{}{}); busy {
+		return ErrVolumeBusy
+	}
+
+	go func() {
+		defer s.busy.Delete(volume.ID)
+		if err := s.driver.RestoreVolume(volume, snapshot); err != nil {
+			slog.Error("Failed to restore volume", "error", err, "volume_id", volume.ID, "snapshot_id", snapshot.ID)
+			return
+		}
+		slog.Info("Restored volume", "volume_id", volume.ID, "snapshot_id", snapshot.ID)
+	}()
+
+	return nil
+}
+
+// DeleteSnapshot removes a snapshot of a volume, along with its copy of the content of the volume.
+func (s *VolumesService) DeleteSnapshot(ctx context.Context, volume models.Volume, snapshot models.VolumeSnapshot) error {
+	if err := s.driver.DeleteVolumeSnapshot(volume, snapshot); err != nil {
+		return err
+	}
+	return s.snapshotsRepo.DeleteOneWhere(ctx, "id", snapshot.ID)
+}
+
+// Delete removes a volume, along with its content and its snapshots.
+func (s *VolumesService) Delete(ctx context.Context, volume models.Volume) error {
+	if _, busy := s.busy.LoadOrStore(volume.ID, struct{}{}); busy {
+		return ErrVolumeBusy
+	}
+	defer s.busy.Delete(volume.ID)
+
+	if err := s.driver.DeleteVolume(volume); err != nil {
+		return err
+	}
+
+	snapshots, err := s.snapshotsRepo.FindAllFromVolume(ctx, volume.ID)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if err := s.DeleteSnapshot(ctx, volume, snapshot); err != nil {
+			return err
+		}
+	}
+
+	return s.volumesRepo.DeleteOneWhere(ctx, "id", volume.ID)
+}